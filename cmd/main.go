@@ -13,15 +13,26 @@ import (
 	"choizee/internal/api"
 	"choizee/internal/database"
 	"choizee/internal/services"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"path"
+	"strconv"
+	"time"
 
 	"github.com/gorilla/mux"
 )
 
 func main() {
+	// Управление миграциями: choizee migrate up|down [N]|status
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(os.Args[2:]); err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+		return
+	}
+
 	// Инициализация базы данных
 	db, err := database.New()
 	if err != nil {
@@ -30,16 +41,43 @@ func main() {
 	defer db.Close()
 
 	// Инициализация сервисов
-	jobService := services.NewJobService(db)
-	candidateService := services.NewCandidateService(db)
-	questionService := services.NewQuestionService(db)
-	evaluationService := services.NewEvaluationService(db)
+	auditService := services.NewAuditService(db)
+	jobService := services.NewJobService(db, auditService)
+	attachmentService := services.NewAttachmentService(db, auditService)
+	candidateService := services.NewCandidateService(db, auditService, attachmentService)
+	questionService := services.NewQuestionService(db, auditService)
+	evaluationService := services.NewEvaluationService(db, auditService)
 	templateService := services.NewTemplateService()
-	answerService := services.NewAnswerService(db)
-	criteriaService := services.NewCriteriaService(db)
+	answerService := services.NewAnswerService(db, auditService)
+	criteriaService := services.NewCriteriaService(db, auditService)
+	backupService := services.NewBackupService(db)
+	searchService := services.NewSearchService(db)
+	pipelineService := services.NewPipelineService(db, auditService)
+	applicationService := services.NewApplicationService(db, auditService)
+	reliabilityService := services.NewReliabilityService(db)
+	rankingService := services.NewRankingService(db, auditService)
+	calibrationService := services.NewCalibrationService(db)
+	comparisonService := services.NewComparisonService(db, evaluationService)
+	analyticsService := services.NewAnalyticsService(db)
+	insightsService := services.NewInsightsService(db, templateService)
+	reportService := services.NewReportService(db, comparisonService)
+
+	// Текст вложений, загруженных до появления извлечения текста
+	if extracted, err := attachmentService.ExtractPendingTexts(); err != nil {
+		log.Printf("Failed to extract attachment texts: %v", err)
+	} else if extracted > 0 {
+		log.Printf("Extracted text from %d attachments", extracted)
+	}
+
+	// Плановые снимки базы данных
+	backupInterval, backupKeep := backupSchedule()
+	if backupInterval > 0 {
+		backupService.StartScheduler(backupInterval, backupKeep)
+		log.Printf("Scheduled backups every %s, keeping last %d", backupInterval, backupKeep)
+	}
 
 	// Инициализация handlers
-	handlers := api.NewHandlers(jobService, candidateService, questionService, evaluationService, templateService, answerService, criteriaService)
+	handlers := api.NewHandlers(jobService, candidateService, questionService, evaluationService, templateService, answerService, criteriaService, backupService, auditService, searchService, pipelineService, applicationService, attachmentService, reliabilityService, rankingService, calibrationService, comparisonService, analyticsService, insightsService, reportService)
 
 	// Настройка роутинга
 	router := setupRoutes(handlers)
@@ -49,6 +87,97 @@ func main() {
 	log.Fatal(http.ListenAndServe(":8080", router))
 }
 
+// backupSchedule читает настройки плановых снимков из окружения:
+// CHOIZEE_BACKUP_INTERVAL (например, "24h", "0" отключает) и CHOIZEE_BACKUP_KEEP
+func backupSchedule() (time.Duration, int) {
+	interval := 24 * time.Hour
+	keep := 7
+
+	if value := os.Getenv("CHOIZEE_BACKUP_INTERVAL"); value != "" {
+		if value == "0" {
+			return 0, keep
+		}
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed < time.Minute {
+			log.Printf("Invalid CHOIZEE_BACKUP_INTERVAL %q, using %s", value, interval)
+		} else {
+			interval = parsed
+		}
+	}
+
+	if value := os.Getenv("CHOIZEE_BACKUP_KEEP"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			log.Printf("Invalid CHOIZEE_BACKUP_KEEP %q, using %d", value, keep)
+		} else {
+			keep = parsed
+		}
+	}
+
+	return interval, keep
+}
+
+// runMigrate выполняет команду управления миграциями схемы
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: migrate up | down [steps] | status")
+	}
+
+	db, err := database.Open()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	switch args[0] {
+	case "up":
+		applied, err := db.MigrateUp()
+		for _, m := range applied {
+			fmt.Printf("applied  %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Println("schema is up to date")
+		}
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("invalid number of steps: %s", args[1])
+			}
+		}
+		reverted, err := db.MigrateDown(steps)
+		for _, m := range reverted {
+			fmt.Printf("reverted %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+	case "status":
+		statuses, err := db.MigrationStatus()
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			switch {
+			case status.Applied:
+				fmt.Printf("[x] %04d_%s  (%s)\n", status.Version, status.Name, status.AppliedAt.Format("2006-01-02 15:04:05"))
+			case status.Legacy:
+				fmt.Printf("[~] %04d_%s  (legacy schema, will be marked as applied)\n", status.Version, status.Name)
+			default:
+				fmt.Printf("[ ] %04d_%s\n", status.Version, status.Name)
+			}
+		}
+	default:
+		return fmt.Errorf("unknown migrate command: %s", args[0])
+	}
+
+	return nil
+}
+
 func setupRoutes(handlers *api.Handlers) *mux.Router {
 	router := mux.NewRouter()
 
@@ -62,10 +191,15 @@ func setupRoutes(handlers *api.Handlers) *mux.Router {
 	apiRouter.HandleFunc("/jobs/{id}", handlers.UpdateJob).Methods("PUT")
 	apiRouter.HandleFunc("/jobs/{id}", handlers.DeleteJob).Methods("DELETE")
 	apiRouter.HandleFunc("/jobs/{id}/candidates", handlers.GetJobCandidates).Methods("GET")
+	apiRouter.HandleFunc("/jobs/{id}/candidates/import", handlers.ImportJobCandidates).Methods("POST")
 	apiRouter.HandleFunc("/jobs/{id}/questions", handlers.GetJobQuestions).Methods("GET")
 	apiRouter.HandleFunc("/jobs/{id}/criteria", handlers.GetJobCriteria).Methods("GET")
 	apiRouter.HandleFunc("/jobs/{id}/criteria", handlers.UpdateJobCriteria).Methods("PUT")
 	apiRouter.HandleFunc("/jobs/{id}/criteria/reorder", handlers.ReorderCriteria).Methods("POST")
+	apiRouter.HandleFunc("/jobs/{id}/stages", handlers.GetJobStages).Methods("GET")
+	apiRouter.HandleFunc("/jobs/{id}/stages", handlers.CreateJobStage).Methods("POST")
+	apiRouter.HandleFunc("/jobs/{id}/stages/reorder", handlers.ReorderJobStages).Methods("POST")
+	apiRouter.HandleFunc("/jobs/{id}/board", handlers.GetJobBoard).Methods("GET")
 
 	// Questions endpoints
 	apiRouter.HandleFunc("/questions", handlers.CreateQuestion).Methods("POST")
@@ -78,27 +212,92 @@ func setupRoutes(handlers *api.Handlers) *mux.Router {
 	apiRouter.HandleFunc("/candidates/{id}", handlers.GetCandidate).Methods("GET")
 	apiRouter.HandleFunc("/candidates/{id}", handlers.UpdateCandidate).Methods("PUT")
 	apiRouter.HandleFunc("/candidates/{id}", handlers.DeleteCandidate).Methods("DELETE")
+	apiRouter.HandleFunc("/candidates/{id}/transition", handlers.TransitionCandidate).Methods("POST")
+	apiRouter.HandleFunc("/candidates/{id}/transitions", handlers.GetCandidateTransitions).Methods("GET")
+	apiRouter.HandleFunc("/candidates/{id}/move", handlers.MoveCandidate).Methods("POST")
+	apiRouter.HandleFunc("/candidates/{id}/performance", handlers.SetApplicationPerformance).Methods("PUT")
+	apiRouter.HandleFunc("/candidates/{id}/report.pdf", handlers.GetCandidateReport).Methods("GET")
+	apiRouter.HandleFunc("/candidates/{id}/chart.svg", handlers.GetCandidateChart).Methods("GET")
+	apiRouter.HandleFunc("/candidates/{id}/applications", handlers.GetCandidateApplications).Methods("GET")
+	apiRouter.HandleFunc("/candidates/{id}/applications", handlers.CreateCandidateApplication).Methods("POST")
+
+	// Attachments endpoints
+	apiRouter.HandleFunc("/candidates/{id}/attachments", handlers.GetCandidateAttachments).Methods("GET")
+	apiRouter.HandleFunc("/candidates/{id}/attachments", handlers.UploadCandidateAttachment).Methods("POST")
+	apiRouter.HandleFunc("/candidates/{id}/attachments/{attachment_id}", handlers.DownloadCandidateAttachment).Methods("GET")
+	apiRouter.HandleFunc("/candidates/{id}/attachments/{attachment_id}", handlers.DeleteCandidateAttachment).Methods("DELETE")
+	apiRouter.HandleFunc("/candidates/{id}/resume-text", handlers.GetCandidateResumeText).Methods("GET")
+
+	// Applications endpoints
+	apiRouter.HandleFunc("/applications/{application_id}", handlers.GetApplication).Methods("GET")
+	apiRouter.HandleFunc("/applications/{application_id}", handlers.DeleteApplication).Methods("DELETE")
+	apiRouter.HandleFunc("/applications/{application_id}/transition", handlers.TransitionCandidate).Methods("POST")
+	apiRouter.HandleFunc("/applications/{application_id}/transitions", handlers.GetCandidateTransitions).Methods("GET")
+	apiRouter.HandleFunc("/applications/{application_id}/move", handlers.MoveCandidate).Methods("POST")
+	apiRouter.HandleFunc("/applications/{application_id}/performance", handlers.SetApplicationPerformance).Methods("PUT")
+	apiRouter.HandleFunc("/applications/{application_id}/report.pdf", handlers.GetCandidateReport).Methods("GET")
+	apiRouter.HandleFunc("/applications/{application_id}/chart.svg", handlers.GetCandidateChart).Methods("GET")
+	apiRouter.HandleFunc("/applications/{application_id}/evaluations", handlers.SaveCandidateEvaluations).Methods("POST")
+	apiRouter.HandleFunc("/applications/{application_id}/evaluations", handlers.GetCandidateEvaluations).Methods("GET")
+	apiRouter.HandleFunc("/applications/{application_id}/evaluations/form", handlers.GetCandidateEvaluationForm).Methods("GET")
+	apiRouter.HandleFunc("/applications/{application_id}/evaluations/history", handlers.GetCandidateEvaluationHistory).Methods("GET")
+	apiRouter.HandleFunc("/applications/{application_id}/evaluations/history/diff", handlers.GetCandidateEvaluationDiff).Methods("GET")
+	apiRouter.HandleFunc("/applications/{application_id}/answers", handlers.SaveCandidateAnswers).Methods("POST")
+	apiRouter.HandleFunc("/applications/{application_id}/answers", handlers.GetCandidateAnswers).Methods("GET")
 
 	// Evaluations endpoints
 	apiRouter.HandleFunc("/candidates/{id}/evaluations", handlers.SaveCandidateEvaluations).Methods("POST")
 	apiRouter.HandleFunc("/candidates/{id}/evaluations", handlers.GetCandidateEvaluations).Methods("GET")
+	apiRouter.HandleFunc("/candidates/{id}/evaluations/form", handlers.GetCandidateEvaluationForm).Methods("GET")
+	apiRouter.HandleFunc("/candidates/{id}/evaluations/history", handlers.GetCandidateEvaluationHistory).Methods("GET")
+	apiRouter.HandleFunc("/candidates/{id}/evaluations/history/diff", handlers.GetCandidateEvaluationDiff).Methods("GET")
 	apiRouter.HandleFunc("/jobs/{id}/evaluations/summary", handlers.GetJobEvaluationsSummary).Methods("GET")
+	apiRouter.HandleFunc("/jobs/{id}/reliability", handlers.GetJobReliability).Methods("GET")
+	apiRouter.HandleFunc("/jobs/{id}/ahp", handlers.GetJobAHPWeights).Methods("GET")
+	apiRouter.HandleFunc("/jobs/{id}/ahp", handlers.UpdateJobComparisons).Methods("PUT")
+	apiRouter.HandleFunc("/jobs/{id}/compare", handlers.CompareCandidates).Methods("GET")
+	apiRouter.HandleFunc("/jobs/{id}/compare.svg", handlers.CompareChart).Methods("GET")
+	apiRouter.HandleFunc("/jobs/{id}/report.pdf", handlers.GetJobReport).Methods("GET")
+	apiRouter.HandleFunc("/jobs/{id}/export.xlsx", handlers.ExportJob).Methods("GET")
+	apiRouter.HandleFunc("/jobs/{id}/analytics", handlers.GetJobAnalytics).Methods("GET")
+	apiRouter.HandleFunc("/analytics", handlers.GetAnalytics).Methods("GET")
+	apiRouter.HandleFunc("/jobs/{id}/insights", handlers.GetJobInsights).Methods("GET")
+	apiRouter.HandleFunc("/insights", handlers.GetInsights).Methods("GET")
+	apiRouter.HandleFunc("/interviewers/{id}/calibration", handlers.GetInterviewerCalibration).Methods("GET")
+	apiRouter.HandleFunc("/scales", handlers.GetScoringScales).Methods("GET")
 
 	// Answers endpoints
 	apiRouter.HandleFunc("/candidates/{id}/answers", handlers.SaveCandidateAnswers).Methods("POST")
 	apiRouter.HandleFunc("/candidates/{id}/answers", handlers.GetCandidateAnswers).Methods("GET")
 
+	// Audit endpoints
+	apiRouter.HandleFunc("/audit", handlers.GetAuditLog).Methods("GET")
+	apiRouter.HandleFunc("/candidates/{id}/audit", handlers.GetCandidateAuditLog).Methods("GET")
+
+	// Search endpoints
+	apiRouter.HandleFunc("/search", handlers.Search).Methods("GET")
+
+	// Admin endpoints
+	apiRouter.HandleFunc("/admin/backups", handlers.GetBackups).Methods("GET")
+	apiRouter.HandleFunc("/admin/backups", handlers.CreateBackup).Methods("POST")
+	apiRouter.HandleFunc("/admin/backups/{name}/restore", handlers.RestoreBackup).Methods("POST")
+
 	// Templates endpoints
 	apiRouter.HandleFunc("/templates", handlers.GetAllTemplates).Methods("GET")
 	apiRouter.HandleFunc("/templates/categories", handlers.GetTemplateCategories).Methods("GET")
 	apiRouter.HandleFunc("/templates/category/{category}", handlers.GetTemplatesByCategory).Methods("GET")
 	apiRouter.HandleFunc("/templates/{id}", handlers.GetTemplateByID).Methods("GET")
+	apiRouter.HandleFunc("/templates/{id}/jobs", handlers.CreateJobFromTemplate).Methods("POST")
 
 	// Criteria endpoints
 	apiRouter.HandleFunc("/criteria", handlers.CreateCriterion).Methods("POST")
 	apiRouter.HandleFunc("/criteria/{id}", handlers.UpdateCriterion).Methods("PUT")
 	apiRouter.HandleFunc("/criteria/{id}", handlers.DeleteCriterion).Methods("DELETE")
 
+	// Stages endpoints
+	apiRouter.HandleFunc("/stages/{id}", handlers.UpdateJobStage).Methods("PUT")
+	apiRouter.HandleFunc("/stages/{id}", handlers.DeleteJobStage).Methods("DELETE")
+
 	// Serve static files with SPA fallback
 	staticDir := "./web/dist/"
 	router.PathPrefix("/").HandlerFunc(spaHandler(staticDir))
@@ -113,7 +312,7 @@ func corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Actor")
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
@@ -58,7 +58,7 @@ echo ""
 
 # Запуск backend в режиме разработки
 echo "⚙️  Запуск Go backend на http://localhost:8080..."
-go run cmd/main.go > backend.log 2>&1 &
+go run -tags sqlite_fts5 cmd/main.go > backend.log 2>&1 &
 BACKEND_PID=$!
 
 # Ожидание запуска backend
@@ -7,6 +7,8 @@ module choizee
 go 1.24.4
 
 require (
+	github.com/go-pdf/fpdf v0.9.0
 	github.com/gorilla/mux v1.8.1
 	github.com/mattn/go-sqlite3 v1.14.28
+	golang.org/x/image v0.25.0
 )
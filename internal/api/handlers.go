@@ -1,37 +1,118 @@
 package api
 
 import (
+	"bytes"
+	"choizee/internal/export"
 	"choizee/internal/models"
 	"choizee/internal/services"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 )
 
 type Handlers struct {
-	jobService        *services.JobService
-	candidateService  *services.CandidateService
-	questionService   *services.QuestionService
-	evaluationService *services.EvaluationService
-	templateService   *services.TemplateService
-	answerService     *services.AnswerService
-	criteriaService   *services.CriteriaService
+	jobService         *services.JobService
+	candidateService   *services.CandidateService
+	questionService    *services.QuestionService
+	evaluationService  *services.EvaluationService
+	templateService    *services.TemplateService
+	answerService      *services.AnswerService
+	criteriaService    *services.CriteriaService
+	backupService      *services.BackupService
+	auditService       *services.AuditService
+	searchService      *services.SearchService
+	pipelineService    *services.PipelineService
+	applicationService *services.ApplicationService
+	attachmentService  *services.AttachmentService
+	reliabilityService *services.ReliabilityService
+	rankingService     *services.RankingService
+	calibrationService *services.CalibrationService
+	comparisonService  *services.ComparisonService
+	analyticsService   *services.AnalyticsService
+	insightsService    *services.InsightsService
+	reportService      *services.ReportService
 }
 
-func NewHandlers(jobService *services.JobService, candidateService *services.CandidateService, questionService *services.QuestionService, evaluationService *services.EvaluationService, templateService *services.TemplateService, answerService *services.AnswerService, criteriaService *services.CriteriaService) *Handlers {
+func NewHandlers(jobService *services.JobService, candidateService *services.CandidateService, questionService *services.QuestionService, evaluationService *services.EvaluationService, templateService *services.TemplateService, answerService *services.AnswerService, criteriaService *services.CriteriaService, backupService *services.BackupService, auditService *services.AuditService, searchService *services.SearchService, pipelineService *services.PipelineService, applicationService *services.ApplicationService, attachmentService *services.AttachmentService, reliabilityService *services.ReliabilityService, rankingService *services.RankingService, calibrationService *services.CalibrationService, comparisonService *services.ComparisonService, analyticsService *services.AnalyticsService, insightsService *services.InsightsService, reportService *services.ReportService) *Handlers {
 	return &Handlers{
-		jobService:        jobService,
-		candidateService:  candidateService,
-		questionService:   questionService,
-		evaluationService: evaluationService,
-		templateService:   templateService,
-		answerService:     answerService,
-		criteriaService:   criteriaService,
+		jobService:         jobService,
+		candidateService:   candidateService,
+		questionService:    questionService,
+		evaluationService:  evaluationService,
+		templateService:    templateService,
+		answerService:      answerService,
+		criteriaService:    criteriaService,
+		backupService:      backupService,
+		auditService:       auditService,
+		searchService:      searchService,
+		pipelineService:    pipelineService,
+		applicationService: applicationService,
+		attachmentService:  attachmentService,
+		reliabilityService: reliabilityService,
+		rankingService:     rankingService,
+		calibrationService: calibrationService,
+		comparisonService:  comparisonService,
+		analyticsService:   analyticsService,
+		insightsService:    insightsService,
+		reportService:      reportService,
 	}
 }
 
+// actorFromRequest возвращает автора изменения из заголовка X-Actor
+func actorFromRequest(r *http.Request) string {
+	if actor := strings.TrimSpace(r.Header.Get("X-Actor")); actor != "" {
+		return actor
+	}
+	return "anonymous"
+}
+
+// applicationIDFromRequest возвращает ID отклика из пути /applications/{application_id}/...
+// Для путей /candidates/{id}/... отклик выбирается по кандидату и параметру ?job_id=,
+// а без него берется отклик на вакансию, для которой кандидат был добавлен.
+// При ошибке ответ уже записан и возвращается false
+func (h *Handlers) applicationIDFromRequest(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	vars := mux.Vars(r)
+	if value, ok := vars["application_id"]; ok {
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid application ID", http.StatusBadRequest)
+			return 0, false
+		}
+		return id, true
+	}
+
+	candidateID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid candidate ID", http.StatusBadRequest)
+		return 0, false
+	}
+
+	var jobID int64
+	if value := r.URL.Query().Get("job_id"); value != "" {
+		jobID, err = strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid job ID", http.StatusBadRequest)
+			return 0, false
+		}
+	}
+
+	id, err := h.applicationService.ResolveApplication(candidateID, jobID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return 0, false
+	}
+	return id, true
+}
+
 // Jobs handlers
 
 func (h *Handlers) CreateJob(w http.ResponseWriter, r *http.Request) {
@@ -41,9 +122,9 @@ func (h *Handlers) CreateJob(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	createdJob, err := h.jobService.CreateJob(&job)
+	createdJob, err := h.jobService.CreateJob(&job, actorFromRequest(r))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeScoringError(w, err)
 		return
 	}
 
@@ -94,9 +175,9 @@ func (h *Handlers) UpdateJob(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	updatedJob, err := h.jobService.UpdateJob(id, &job)
+	updatedJob, err := h.jobService.UpdateJob(id, &job, actorFromRequest(r))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeScoringError(w, err)
 		return
 	}
 
@@ -112,7 +193,7 @@ func (h *Handlers) DeleteJob(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.jobService.DeleteJob(id); err != nil {
+	if err := h.jobService.DeleteJob(id, actorFromRequest(r)); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -129,7 +210,7 @@ func (h *Handlers) CreateQuestion(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	createdQuestion, err := h.questionService.CreateQuestion(&question)
+	createdQuestion, err := h.questionService.CreateQuestion(&question, actorFromRequest(r))
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -189,7 +270,7 @@ func (h *Handlers) UpdateQuestion(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	updatedQuestion, err := h.questionService.UpdateQuestion(id, &question)
+	updatedQuestion, err := h.questionService.UpdateQuestion(id, &question, actorFromRequest(r))
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -207,7 +288,7 @@ func (h *Handlers) DeleteQuestion(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.questionService.DeleteQuestion(id); err != nil {
+	if err := h.questionService.DeleteQuestion(id, actorFromRequest(r)); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -224,7 +305,7 @@ func (h *Handlers) CreateCandidate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	createdCandidate, err := h.candidateService.CreateCandidate(&candidate)
+	createdCandidate, err := h.candidateService.CreateCandidate(&candidate, actorFromRequest(r))
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -234,6 +315,89 @@ func (h *Handlers) CreateCandidate(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(createdCandidate)
 }
 
+// ImportJobCandidates добавляет кандидатов вакансии из CSV в поле file формы multipart/form-data.
+// Поле mapping - JSON вида {"name": "ФИО", "email": "Почта"}, сопоставляющий поля кандидата
+// с колонками файла. Без ?commit=true импорт пробный: строки только проверяются
+func (h *Handlers) ImportJobCandidates(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	jobID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid job ID", http.StatusBadRequest)
+		return
+	}
+
+	commit := r.URL.Query().Get("commit")
+	if commit != "" && commit != "true" && commit != "false" {
+		http.Error(w, "Invalid commit flag", http.StatusBadRequest)
+		return
+	}
+	options := services.CandidateImportOptions{DryRun: commit != "true"}
+
+	if _, err := h.jobService.GetJobByID(jobID); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	// Запас сверх размера файла на заголовки и остальные поля формы
+	r.Body = http.MaxBytesReader(w, r.Body, services.MaxImportSize+1<<20)
+	reader, err := r.MultipartReader()
+	if err != nil {
+		http.Error(w, "Expected multipart/form-data", http.StatusBadRequest)
+		return
+	}
+
+	// Поле mapping может идти и после файла, поэтому файл читается целиком
+	var file []byte
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			writeImportError(w, err)
+			return
+		}
+		switch part.FormName() {
+		case "mapping":
+			if err := json.NewDecoder(part).Decode(&options.Mapping); err != nil {
+				http.Error(w, "Invalid mapping", http.StatusBadRequest)
+				return
+			}
+		case "file":
+			if file, err = io.ReadAll(part); err != nil {
+				writeImportError(w, err)
+				return
+			}
+		}
+	}
+	if file == nil {
+		http.Error(w, "File field is required", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.candidateService.ImportCandidates(jobID, bytes.NewReader(file), options, actorFromRequest(r))
+	if err != nil {
+		writeImportError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// writeImportError отвечает статусом, соответствующим ошибке импорта кандидатов
+func writeImportError(w http.ResponseWriter, err error) {
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		http.Error(w, fmt.Sprintf("import file is larger than %d MB", services.MaxImportSize>>20), http.StatusRequestEntityTooLarge)
+	case errors.Is(err, services.ErrInvalidImport):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
 func (h *Handlers) GetCandidate(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.ParseInt(vars["id"], 10, 64)
@@ -260,7 +424,25 @@ func (h *Handlers) GetJobCandidates(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	candidates, err := h.candidateService.GetCandidatesByJobID(jobID)
+	// Фильтр по этапам воронки вакансии: ?stage=interview,offer
+	var stages []string
+	if value := r.URL.Query().Get("stage"); value != "" {
+		jobStages, err := h.pipelineService.GetJobStages(jobID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		for _, stage := range strings.Split(value, ",") {
+			stage = strings.TrimSpace(stage)
+			if !slices.ContainsFunc(jobStages, func(s models.JobStage) bool { return s.Key == stage }) {
+				http.Error(w, "Invalid stage: "+stage, http.StatusBadRequest)
+				return
+			}
+			stages = append(stages, stage)
+		}
+	}
+
+	candidates, err := h.candidateService.GetCandidatesByJobID(jobID, stages)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -284,7 +466,7 @@ func (h *Handlers) UpdateCandidate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	updatedCandidate, err := h.candidateService.UpdateCandidate(id, &candidate)
+	updatedCandidate, err := h.candidateService.UpdateCandidate(id, &candidate, actorFromRequest(r))
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -302,7 +484,7 @@ func (h *Handlers) DeleteCandidate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.candidateService.DeleteCandidate(id); err != nil {
+	if err := h.candidateService.DeleteCandidate(id, actorFromRequest(r)); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -310,130 +492,155 @@ func (h *Handlers) DeleteCandidate(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
-// Evaluations handlers
-
-// SaveCandidateEvaluations сохраняет все оценки кандидата
-func (h *Handlers) SaveCandidateEvaluations(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	candidateID, err := strconv.ParseInt(vars["id"], 10, 64)
-	if err != nil {
-		http.Error(w, "Invalid candidate ID", http.StatusBadRequest)
+// TransitionCandidate переводит отклик кандидата на другой этап найма
+func (h *Handlers) TransitionCandidate(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.applicationIDFromRequest(w, r)
+	if !ok {
 		return
 	}
 
-	var evaluations []models.Evaluation
-	if err := json.NewDecoder(r.Body).Decode(&evaluations); err != nil {
+	var request models.StageTransitionRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
 		http.Error(w, "Invalid JSON", http.StatusBadRequest)
 		return
 	}
 
-	if err := h.evaluationService.SaveCandidateEvaluations(candidateID, evaluations); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	application, err := h.applicationService.TransitionApplication(id, &request, actorFromRequest(r))
+	if err != nil {
+		writePipelineError(w, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{"status": "success"})
+	json.NewEncoder(w).Encode(application)
 }
 
-// GetCandidateEvaluations получает все оценки кандидата
-func (h *Handlers) GetCandidateEvaluations(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	candidateID, err := strconv.ParseInt(vars["id"], 10, 64)
-	if err != nil {
-		http.Error(w, "Invalid candidate ID", http.StatusBadRequest)
+// GetCandidateTransitions возвращает историю переходов отклика кандидата между этапами
+func (h *Handlers) GetCandidateTransitions(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.applicationIDFromRequest(w, r)
+	if !ok {
 		return
 	}
 
-	evaluations, err := h.evaluationService.GetEvaluationsByCandidate(candidateID)
+	transitions, err := h.applicationService.GetStageTransitions(id)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(evaluations)
+	json.NewEncoder(w).Encode(transitions)
 }
 
-// GetJobEvaluationsSummary получает сводку оценок для сравнения кандидатов
-func (h *Handlers) GetJobEvaluationsSummary(w http.ResponseWriter, r *http.Request) {
+// Applications handlers
+
+// CreateCandidateApplication создает отклик кандидата на вакансию
+func (h *Handlers) CreateCandidateApplication(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
-	jobID, err := strconv.ParseInt(vars["id"], 10, 64)
+	candidateID, err := strconv.ParseInt(vars["id"], 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid job ID", http.StatusBadRequest)
+		http.Error(w, "Invalid candidate ID", http.StatusBadRequest)
+		return
+	}
+
+	var request models.ApplicationRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
 		return
 	}
 
-	summaries, err := h.evaluationService.GetEvaluationsSummary(jobID)
+	application, err := h.applicationService.CreateApplication(candidateID, request.JobID, actorFromRequest(r))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writePipelineError(w, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(summaries)
+	json.NewEncoder(w).Encode(application)
 }
 
-// Templates handlers
+// GetCandidateApplications возвращает отклики кандидата с этапами и оценками
+func (h *Handlers) GetCandidateApplications(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	candidateID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid candidate ID", http.StatusBadRequest)
+		return
+	}
 
-// GetAllTemplates возвращает все шаблоны вакансий
-func (h *Handlers) GetAllTemplates(w http.ResponseWriter, r *http.Request) {
-	templates, err := h.templateService.GetAllTemplates()
+	applications, err := h.applicationService.GetCandidateApplications(candidateID)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(templates)
+	json.NewEncoder(w).Encode(applications)
 }
 
-// GetTemplateByID возвращает шаблон по ID
-func (h *Handlers) GetTemplateByID(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	id := vars["id"]
+// GetApplication возвращает отклик по ID
+func (h *Handlers) GetApplication(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.applicationIDFromRequest(w, r)
+	if !ok {
+		return
+	}
 
-	template, err := h.templateService.GetTemplateByID(id)
+	application, err := h.applicationService.GetApplicationByID(id)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(template)
+	json.NewEncoder(w).Encode(application)
 }
 
-// GetTemplatesByCategory возвращает шаблоны по категории
-func (h *Handlers) GetTemplatesByCategory(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	category := vars["category"]
+// SetApplicationPerformance ставит или снимает оценку работы принятого кандидата
+func (h *Handlers) SetApplicationPerformance(w http.ResponseWriter, r *http.Request) {
+	applicationID, ok := h.applicationIDFromRequest(w, r)
+	if !ok {
+		return
+	}
 
-	templates, err := h.templateService.GetTemplatesByCategory(category)
+	var request models.PerformanceRatingRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	application, err := h.applicationService.SetPerformanceRating(applicationID, request.Rating, actorFromRequest(r))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		if errors.Is(err, services.ErrInvalidPerformance) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(templates)
+	json.NewEncoder(w).Encode(application)
 }
 
-// GetTemplateCategories возвращает список категорий
-func (h *Handlers) GetTemplateCategories(w http.ResponseWriter, r *http.Request) {
-	categories, err := h.templateService.GetCategories()
-	if err != nil {
+// DeleteApplication удаляет отклик вместе с его оценками и ответами
+func (h *Handlers) DeleteApplication(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.applicationIDFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.applicationService.DeleteApplication(id, actorFromRequest(r)); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(categories)
+	w.WriteHeader(http.StatusNoContent)
 }
 
-// Answers handlers
+// Attachments handlers
 
-// SaveCandidateAnswers сохраняет все ответы кандидата
-func (h *Handlers) SaveCandidateAnswers(w http.ResponseWriter, r *http.Request) {
+// UploadCandidateAttachment загружает файл кандидата из поля file формы multipart/form-data
+func (h *Handlers) UploadCandidateAttachment(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	candidateID, err := strconv.ParseInt(vars["id"], 10, 64)
 	if err != nil {
@@ -441,23 +648,43 @@ func (h *Handlers) SaveCandidateAnswers(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	var answers []models.Answer
-	if err := json.NewDecoder(r.Body).Decode(&answers); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+	// Запас сверх размера файла на заголовки и остальные поля формы
+	r.Body = http.MaxBytesReader(w, r.Body, services.MaxAttachmentSize+1<<20)
+	reader, err := r.MultipartReader()
+	if err != nil {
+		http.Error(w, "Expected multipart/form-data", http.StatusBadRequest)
 		return
 	}
 
-	if err := h.answerService.SaveCandidateAnswers(candidateID, answers); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			writeAttachmentError(w, err)
+			return
+		}
+		if part.FormName() != "file" || part.FileName() == "" {
+			continue
+		}
+
+		attachment, err := h.attachmentService.CreateAttachment(candidateID, part.FileName(), part, actorFromRequest(r))
+		if err != nil {
+			writeAttachmentError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(attachment)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{"status": "success"})
+	http.Error(w, "File field is required", http.StatusBadRequest)
 }
 
-// GetCandidateAnswers получает все ответы кандидата
-func (h *Handlers) GetCandidateAnswers(w http.ResponseWriter, r *http.Request) {
+// GetCandidateAttachments возвращает список вложений кандидата
+func (h *Handlers) GetCandidateAttachments(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	candidateID, err := strconv.ParseInt(vars["id"], 10, 64)
 	if err != nil {
@@ -465,124 +692,212 @@ func (h *Handlers) GetCandidateAnswers(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	answers, err := h.answerService.GetAnswersByCandidate(candidateID)
+	attachments, err := h.attachmentService.GetCandidateAttachments(candidateID)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(answers)
+	json.NewEncoder(w).Encode(attachments)
 }
 
-// Criteria handlers
-
-// GetJobCriteria получает все критерии для вакансии
-func (h *Handlers) GetJobCriteria(w http.ResponseWriter, r *http.Request) {
+// GetCandidateResumeText возвращает текст резюме, извлеченный из вложений кандидата
+func (h *Handlers) GetCandidateResumeText(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
-	jobID, err := strconv.ParseInt(vars["id"], 10, 64)
+	candidateID, err := strconv.ParseInt(vars["id"], 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid job ID", http.StatusBadRequest)
+		http.Error(w, "Invalid candidate ID", http.StatusBadRequest)
 		return
 	}
 
-	criteria, err := h.criteriaService.GetJobCriteria(jobID)
+	resume, err := h.attachmentService.GetResumeText(candidateID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(criteria)
+	json.NewEncoder(w).Encode(resume)
 }
 
-// CreateCriterion создает новый критерий
-func (h *Handlers) CreateCriterion(w http.ResponseWriter, r *http.Request) {
-	var criterion models.Criterion
-	if err := json.NewDecoder(r.Body).Decode(&criterion); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+// DownloadCandidateAttachment отдает содержимое вложения
+func (h *Handlers) DownloadCandidateAttachment(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	candidateID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid candidate ID", http.StatusBadRequest)
+		return
+	}
+	id, err := strconv.ParseInt(vars["attachment_id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid attachment ID", http.StatusBadRequest)
 		return
 	}
 
-	createdCriterion, err := h.criteriaService.CreateCriterion(criterion)
+	attachment, file, err := h.attachmentService.OpenAttachment(candidateID, id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
+	defer file.Close()
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(createdCriterion)
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.FileName}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	http.ServeContent(w, r, attachment.FileName, attachment.CreatedAt, file)
 }
 
-// UpdateCriterion обновляет критерий
-func (h *Handlers) UpdateCriterion(w http.ResponseWriter, r *http.Request) {
+// DeleteCandidateAttachment удаляет вложение кандидата
+func (h *Handlers) DeleteCandidateAttachment(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
-	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	candidateID, err := strconv.ParseInt(vars["id"], 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid criterion ID", http.StatusBadRequest)
+		http.Error(w, "Invalid candidate ID", http.StatusBadRequest)
+		return
+	}
+	id, err := strconv.ParseInt(vars["attachment_id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid attachment ID", http.StatusBadRequest)
 		return
 	}
 
-	var update models.CriterionUpdate
-	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+	if err := h.attachmentService.DeleteAttachment(candidateID, id, actorFromRequest(r)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	updatedCriterion, err := h.criteriaService.UpdateCriterion(id, update)
-	if err != nil {
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeAttachmentError отвечает 413 на слишком большой файл, 415 на недопустимый тип,
+// 400 на некорректный файл и 500 на остальные ошибки
+func writeAttachmentError(w http.ResponseWriter, err error) {
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.Is(err, services.ErrAttachmentTooLarge), errors.As(err, &maxBytesErr):
+		http.Error(w, services.ErrAttachmentTooLarge.Error(), http.StatusRequestEntityTooLarge)
+	case errors.Is(err, services.ErrUnsupportedAttachmentType):
+		http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+	case errors.Is(err, services.ErrInvalidAttachment):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
 		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// Evaluations handlers
+
+// SaveCandidateEvaluations сохраняет оценочный лист интервьюера по отклику кандидата.
+// Интервьюер задается параметром ?evaluator=, по умолчанию это автор изменения
+func (h *Handlers) SaveCandidateEvaluations(w http.ResponseWriter, r *http.Request) {
+	applicationID, ok := h.applicationIDFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var evaluations []models.Evaluation
+	if err := json.NewDecoder(r.Body).Decode(&evaluations); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	evaluator := r.URL.Query().Get("evaluator")
+	if err := h.evaluationService.SaveApplicationEvaluations(applicationID, evaluator, evaluations, actorFromRequest(r)); err != nil {
+		writeScoringError(w, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(updatedCriterion)
+	json.NewEncoder(w).Encode(map[string]string{"status": "success"})
 }
 
-// DeleteCriterion удаляет критерий
-func (h *Handlers) DeleteCriterion(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	id, err := strconv.ParseInt(vars["id"], 10, 64)
+// GetCandidateEvaluationForm возвращает оценочный лист интервьюера с рубриками критериев.
+// Интервьюер задается параметром ?evaluator=, по умолчанию это автор запроса
+func (h *Handlers) GetCandidateEvaluationForm(w http.ResponseWriter, r *http.Request) {
+	applicationID, ok := h.applicationIDFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	form, err := h.evaluationService.GetEvaluationForm(applicationID, r.URL.Query().Get("evaluator"), actorFromRequest(r))
 	if err != nil {
-		http.Error(w, "Invalid criterion ID", http.StatusBadRequest)
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(form)
+}
+
+// GetCandidateEvaluations получает оценки отклика кандидата всех интервьюеров
+// или одного, если задан параметр ?evaluator=
+func (h *Handlers) GetCandidateEvaluations(w http.ResponseWriter, r *http.Request) {
+	applicationID, ok := h.applicationIDFromRequest(w, r)
+	if !ok {
 		return
 	}
 
-	if err := h.criteriaService.DeleteCriterion(id); err != nil {
+	evaluations, err := h.evaluationService.GetEvaluationsByApplication(applicationID, r.URL.Query().Get("evaluator"))
+	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	w.WriteHeader(http.StatusNoContent)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(evaluations)
 }
 
-// UpdateJobCriteria обновляет все критерии вакансии
-func (h *Handlers) UpdateJobCriteria(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	jobID, err := strconv.ParseInt(vars["id"], 10, 64)
+// GetCandidateEvaluationHistory получает все ревизии оценок отклика кандидата
+func (h *Handlers) GetCandidateEvaluationHistory(w http.ResponseWriter, r *http.Request) {
+	applicationID, ok := h.applicationIDFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	history, err := h.evaluationService.GetEvaluationHistory(applicationID)
 	if err != nil {
-		http.Error(w, "Invalid job ID", http.StatusBadRequest)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	var criteriaNames []string
-	if err := json.NewDecoder(r.Body).Decode(&criteriaNames); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(history)
+}
+
+// GetCandidateEvaluationDiff сравнивает две ревизии оценок отклика кандидата (?from=&to=)
+func (h *Handlers) GetCandidateEvaluationDiff(w http.ResponseWriter, r *http.Request) {
+	applicationID, ok := h.applicationIDFromRequest(w, r)
+	if !ok {
 		return
 	}
 
-	updatedCriteria, err := h.criteriaService.UpdateJobCriteria(jobID, criteriaNames)
+	from, err := strconv.Atoi(r.URL.Query().Get("from"))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, "Invalid from revision", http.StatusBadRequest)
+		return
+	}
+	to, err := strconv.Atoi(r.URL.Query().Get("to"))
+	if err != nil {
+		http.Error(w, "Invalid to revision", http.StatusBadRequest)
+		return
+	}
+
+	diff, err := h.evaluationService.DiffEvaluationRevisions(applicationID, from, to)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(updatedCriteria)
+	json.NewEncoder(w).Encode(diff)
 }
 
-// ReorderCriteria изменяет порядок критериев
-func (h *Handlers) ReorderCriteria(w http.ResponseWriter, r *http.Request) {
+// GetJobEvaluationsSummary получает сводку оценок для сравнения кандидатов. Параметр method
+// выбирает метод ранжирования, с knockout=exclude кандидаты, не прошедшие отсеивающие
+// критерии, не попадают в сводку, а с normalize=zscore к оценкам добавляется поправка
+// на строгость интервьюеров
+func (h *Handlers) GetJobEvaluationsSummary(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	jobID, err := strconv.ParseInt(vars["id"], 10, 64)
 	if err != nil {
@@ -590,17 +905,1071 @@ func (h *Handlers) ReorderCriteria(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var criteriaIDs []int64
-	if err := json.NewDecoder(r.Body).Decode(&criteriaIDs); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+	options := services.SummaryOptions{Method: r.URL.Query().Get("method")}
+	switch r.URL.Query().Get("knockout") {
+	case "", "flag":
+	case "exclude":
+		options.ExcludeKnockedOut = true
+	default:
+		http.Error(w, "Invalid knockout mode", http.StatusBadRequest)
+		return
+	}
+	switch r.URL.Query().Get("normalize") {
+	case "", "none":
+	case "zscore":
+		options.Calibrate = true
+	default:
+		http.Error(w, "Invalid normalization mode", http.StatusBadRequest)
 		return
 	}
 
-	if err := h.criteriaService.ReorderCriteria(jobID, criteriaIDs); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	summaries, err := h.evaluationService.GetEvaluationsSummary(jobID, options)
+	if err != nil {
+		writeScoringError(w, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{"status": "success"})
+	json.NewEncoder(w).Encode(summaries)
+}
+
+// GetJobAHPWeights возвращает попарные сравнения критериев вакансии и веса AHP
+func (h *Handlers) GetJobAHPWeights(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	jobID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid job ID", http.StatusBadRequest)
+		return
+	}
+
+	weights, err := h.rankingService.GetJobAHPWeights(jobID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(weights)
+}
+
+// UpdateJobComparisons заменяет попарные сравнения критериев вакансии
+func (h *Handlers) UpdateJobComparisons(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	jobID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid job ID", http.StatusBadRequest)
+		return
+	}
+
+	var comparisons []models.CriterionComparison
+	if err := json.NewDecoder(r.Body).Decode(&comparisons); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	weights, err := h.rankingService.UpdateJobComparisons(jobID, comparisons, actorFromRequest(r))
+	if err != nil {
+		writeScoringError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(weights)
+}
+
+// GetInterviewerCalibration возвращает строгость интервьюера относительно остальных.
+// ID интервьюера - его имя, под которым он ставит оценки
+func (h *Handlers) GetInterviewerCalibration(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	report, err := h.calibrationService.GetInterviewerCalibration(vars["id"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// GetJobReliability возвращает согласованность оценок интервьюеров по вакансии
+func (h *Handlers) GetJobReliability(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	jobID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid job ID", http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.reliabilityService.GetJobReliability(jobID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// CompareCandidates сравнивает кандидатов вакансии бок о бок. Кандидаты передаются списком
+// ?candidates=1,2,3; с ?format=html сравнение отдается страницей для печати
+func (h *Handlers) CompareCandidates(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	jobID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid job ID", http.StatusBadRequest)
+		return
+	}
+
+	var candidateIDs []int64
+	for _, value := range strings.Split(r.URL.Query().Get("candidates"), ",") {
+		if value = strings.TrimSpace(value); value == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid candidate ID", http.StatusBadRequest)
+			return
+		}
+		candidateIDs = append(candidateIDs, id)
+	}
+
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "html" {
+		http.Error(w, "Invalid format", http.StatusBadRequest)
+		return
+	}
+
+	comparison, err := h.comparisonService.CompareCandidates(jobID, candidateIDs)
+	if err != nil {
+		writeComparisonError(w, err)
+		return
+	}
+
+	if format == "html" {
+		var document bytes.Buffer
+		if err := export.CompareHTML(&document, comparison); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": fmt.Sprintf("compare-job-%d.html", jobID)}))
+		w.Header().Set("Content-Length", strconv.Itoa(document.Len()))
+		document.WriteTo(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(comparison)
+}
+
+// writeComparisonError отвечает 400 на некорректный набор кандидатов, 404 на отсутствующую
+// вакансию и 500 на остальные ошибки
+func writeComparisonError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidComparison):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, services.ErrComparisonNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// Report handlers
+
+// GetCandidateReport отдает PDF-карточку кандидата: данные, радар-диаграмму,
+// оценки по критериям с комментариями и ответы на вопросы
+func (h *Handlers) GetCandidateReport(w http.ResponseWriter, r *http.Request) {
+	applicationID, ok := h.applicationIDFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	report, err := h.reportService.GetCandidateReport(applicationID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	var document bytes.Buffer
+	if err := export.CandidatePDF(&document, report); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writePDF(w, &document, fmt.Sprintf("candidate-%d-job-%d.pdf", report.Candidate.ID, report.Application.JobID))
+}
+
+// GetJobReport отдает PDF-отчет по вакансии: сравнение всех кандидатов и их карточки
+func (h *Handlers) GetJobReport(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	jobID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid job ID", http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.reportService.GetJobReport(jobID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	var document bytes.Buffer
+	if err := export.JobPDF(&document, report); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writePDF(w, &document, fmt.Sprintf("job-%d-report.pdf", jobID))
+}
+
+// GetCandidateChart отдает SVG-диаграмму средних оценок кандидата по критериям вакансии
+// (?type=radar по умолчанию или ?type=bar) для вставки в письма, PDF и вики
+func (h *Handlers) GetCandidateChart(w http.ResponseWriter, r *http.Request) {
+	kind, ok := chartKindFromRequest(w, r)
+	if !ok {
+		return
+	}
+	applicationID, ok := h.applicationIDFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	comparison, err := h.reportService.GetCandidateChart(applicationID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	var document bytes.Buffer
+	if err := export.ComparisonSVG(&document, comparison, kind); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeSVG(w, &document, fmt.Sprintf("candidate-%d-job-%d.svg", comparison.Candidates[0].CandidateID, comparison.JobID))
+}
+
+// CompareChart отдает SVG-диаграмму с наложенными оценками кандидатов вакансии
+// из ?candidates=1,2,3 - тех же, что и в сравнении
+func (h *Handlers) CompareChart(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	jobID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid job ID", http.StatusBadRequest)
+		return
+	}
+	kind, ok := chartKindFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var candidateIDs []int64
+	for _, value := range strings.Split(r.URL.Query().Get("candidates"), ",") {
+		if value = strings.TrimSpace(value); value == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid candidate ID", http.StatusBadRequest)
+			return
+		}
+		candidateIDs = append(candidateIDs, id)
+	}
+
+	comparison, err := h.comparisonService.CompareCandidates(jobID, candidateIDs)
+	if err != nil {
+		writeComparisonError(w, err)
+		return
+	}
+
+	var document bytes.Buffer
+	if err := export.ComparisonSVG(&document, comparison, kind); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeSVG(w, &document, fmt.Sprintf("compare-job-%d.svg", jobID))
+}
+
+// chartKindFromRequest читает вид диаграммы из ?type=; по умолчанию радар
+func chartKindFromRequest(w http.ResponseWriter, r *http.Request) (string, bool) {
+	switch kind := r.URL.Query().Get("type"); kind {
+	case "":
+		return export.ChartRadar, true
+	case export.ChartRadar, export.ChartBar:
+		return kind, true
+	default:
+		http.Error(w, "Invalid chart type", http.StatusBadRequest)
+		return "", false
+	}
+}
+
+// writeSVG отдает готовую SVG-картинку, собранную в памяти, как и PDF
+func writeSVG(w http.ResponseWriter, document *bytes.Buffer, filename string) {
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": filename}))
+	w.Header().Set("Content-Length", strconv.Itoa(document.Len()))
+	document.WriteTo(w)
+}
+
+// ExportJob выгружает оценки вакансии книгой Excel: сводка по критериям,
+// оценки интервьюеров с комментариями и ответы на вопросы
+func (h *Handlers) ExportJob(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	jobID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid job ID", http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.reportService.GetJobReport(jobID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	var document bytes.Buffer
+	if err := export.JobXLSX(&document, report); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": fmt.Sprintf("job-%d-evaluations.xlsx", jobID)}))
+	w.Header().Set("Content-Length", strconv.Itoa(document.Len()))
+	document.WriteTo(w)
+}
+
+// writePDF отдает готовый PDF-документ. Документ собирается в памяти целиком,
+// чтобы ошибку формирования можно было вернуть статусом 500, а не обрывом файла
+func writePDF(w http.ResponseWriter, document *bytes.Buffer, filename string) {
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": filename}))
+	w.Header().Set("Content-Length", strconv.Itoa(document.Len()))
+	document.WriteTo(w)
+}
+
+// Analytics handlers
+
+// GetJobAnalytics возвращает воронку, распределения оценок и время найма по вакансии
+func (h *Handlers) GetJobAnalytics(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	jobID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid job ID", http.StatusBadRequest)
+		return
+	}
+
+	filter, ok := analyticsFilterFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	analytics, err := h.analyticsService.GetJobAnalytics(jobID, filter)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(analytics)
+}
+
+// GetAnalytics возвращает аналитику найма по всем вакансиям
+func (h *Handlers) GetAnalytics(w http.ResponseWriter, r *http.Request) {
+	filter, ok := analyticsFilterFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	analytics, err := h.analyticsService.GetAnalytics(filter)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(analytics)
+}
+
+// GetJobInsights возвращает, какие критерии и вопросы вакансии отличают принятых от отказников
+func (h *Handlers) GetJobInsights(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	jobID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid job ID", http.StatusBadRequest)
+		return
+	}
+
+	insights, err := h.insightsService.GetJobInsights(jobID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(insights)
+}
+
+// GetInsights возвращает прогностическую силу критериев и вопросов по всем вакансиям
+func (h *Handlers) GetInsights(w http.ResponseWriter, r *http.Request) {
+	insights, err := h.insightsService.GetInsights()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(insights)
+}
+
+// analyticsFilterFromRequest читает период ?from= и ?to= (RFC 3339 или YYYY-MM-DD).
+// При ошибке отвечает 400 и возвращает false
+func analyticsFilterFromRequest(w http.ResponseWriter, r *http.Request) (models.AnalyticsFilter, bool) {
+	var filter models.AnalyticsFilter
+	query := r.URL.Query()
+	if value := query.Get("from"); value != "" {
+		from, err := parseTimeParam(value, false)
+		if err != nil {
+			http.Error(w, "Invalid from", http.StatusBadRequest)
+			return filter, false
+		}
+		filter.From = &from
+	}
+	if value := query.Get("to"); value != "" {
+		to, err := parseTimeParam(value, true)
+		if err != nil {
+			http.Error(w, "Invalid to", http.StatusBadRequest)
+			return filter, false
+		}
+		filter.To = &to
+	}
+	if filter.From != nil && filter.To != nil && filter.To.Before(*filter.From) {
+		http.Error(w, "Invalid date range", http.StatusBadRequest)
+		return filter, false
+	}
+	return filter, true
+}
+
+// Templates handlers
+
+// GetAllTemplates возвращает все шаблоны вакансий
+func (h *Handlers) GetAllTemplates(w http.ResponseWriter, r *http.Request) {
+	templates, err := h.templateService.GetAllTemplates()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(templates)
+}
+
+// GetTemplateByID возвращает шаблон по ID
+func (h *Handlers) GetTemplateByID(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	template, err := h.templateService.GetTemplateByID(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(template)
+}
+
+// CreateJobFromTemplate создает вакансию по шаблону с критериями, рубриками и вопросами
+func (h *Handlers) CreateJobFromTemplate(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	template, err := h.templateService.GetTemplateByID(vars["id"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	job, err := h.jobService.CreateJobFromTemplate(template, actorFromRequest(r))
+	if err != nil {
+		writeScoringError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(job)
+}
+
+// GetTemplatesByCategory возвращает шаблоны по категории
+func (h *Handlers) GetTemplatesByCategory(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	category := vars["category"]
+
+	templates, err := h.templateService.GetTemplatesByCategory(category)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(templates)
+}
+
+// GetTemplateCategories возвращает список категорий
+func (h *Handlers) GetTemplateCategories(w http.ResponseWriter, r *http.Request) {
+	categories, err := h.templateService.GetCategories()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(categories)
+}
+
+// Answers handlers
+
+// SaveCandidateAnswers сохраняет все ответы отклика кандидата
+func (h *Handlers) SaveCandidateAnswers(w http.ResponseWriter, r *http.Request) {
+	applicationID, ok := h.applicationIDFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var answers []models.Answer
+	if err := json.NewDecoder(r.Body).Decode(&answers); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.answerService.SaveApplicationAnswers(applicationID, answers, actorFromRequest(r)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "success"})
+}
+
+// GetCandidateAnswers получает все ответы отклика кандидата
+func (h *Handlers) GetCandidateAnswers(w http.ResponseWriter, r *http.Request) {
+	applicationID, ok := h.applicationIDFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	answers, err := h.answerService.GetAnswersByApplication(applicationID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(answers)
+}
+
+// Criteria handlers
+
+// GetJobCriteria получает все критерии для вакансии
+func (h *Handlers) GetJobCriteria(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	jobID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid job ID", http.StatusBadRequest)
+		return
+	}
+
+	criteria, err := h.criteriaService.GetJobCriteria(jobID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(criteria)
+}
+
+// CreateCriterion создает новый критерий
+func (h *Handlers) CreateCriterion(w http.ResponseWriter, r *http.Request) {
+	var criterion models.Criterion
+	if err := json.NewDecoder(r.Body).Decode(&criterion); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	createdCriterion, err := h.criteriaService.CreateCriterion(criterion, actorFromRequest(r))
+	if err != nil {
+		writeScoringError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(createdCriterion)
+}
+
+// UpdateCriterion обновляет критерий
+func (h *Handlers) UpdateCriterion(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid criterion ID", http.StatusBadRequest)
+		return
+	}
+
+	var update models.CriterionUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	updatedCriterion, err := h.criteriaService.UpdateCriterion(id, update, actorFromRequest(r))
+	if err != nil {
+		writeScoringError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updatedCriterion)
+}
+
+// DeleteCriterion удаляет критерий
+func (h *Handlers) DeleteCriterion(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid criterion ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.criteriaService.DeleteCriterion(id, actorFromRequest(r)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UpdateJobCriteria обновляет все критерии вакансии
+func (h *Handlers) UpdateJobCriteria(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	jobID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid job ID", http.StatusBadRequest)
+		return
+	}
+
+	// Критерий задается названием или объектом {"name": ..., "weight": ...}
+	var criteria []models.CriterionInput
+	if err := json.NewDecoder(r.Body).Decode(&criteria); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	updatedCriteria, err := h.criteriaService.UpdateJobCriteria(jobID, criteria, actorFromRequest(r))
+	if err != nil {
+		writeScoringError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updatedCriteria)
+}
+
+// ReorderCriteria изменяет порядок критериев
+func (h *Handlers) ReorderCriteria(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	jobID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid job ID", http.StatusBadRequest)
+		return
+	}
+
+	var criteriaIDs []int64
+	if err := json.NewDecoder(r.Body).Decode(&criteriaIDs); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.criteriaService.ReorderCriteria(jobID, criteriaIDs, actorFromRequest(r)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "success"})
+}
+
+// Backups handlers
+
+// CreateBackup создает снимок базы данных без остановки сервера
+func (h *Handlers) CreateBackup(w http.ResponseWriter, r *http.Request) {
+	backup, err := h.backupService.CreateBackup(services.BackupKindManual)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(backup)
+}
+
+// GetBackups возвращает список снимков базы данных
+func (h *Handlers) GetBackups(w http.ResponseWriter, r *http.Request) {
+	backups, err := h.backupService.ListBackups()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(backups)
+}
+
+// RestoreBackup восстанавливает базу данных из снимка
+func (h *Handlers) RestoreBackup(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	name := vars["name"]
+
+	backup, err := h.backupService.RestoreBackup(name)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidBackup):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, services.ErrBackupNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(backup)
+}
+
+// Audit handlers
+
+// GetAuditLog возвращает записи журнала аудита с фильтрами
+// entity, entity_id, actor, from, to (RFC 3339 или YYYY-MM-DD), limit и offset
+func (h *Handlers) GetAuditLog(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := models.AuditFilter{
+		Entity: query.Get("entity"),
+		Actor:  query.Get("actor"),
+	}
+
+	var err error
+	if value := query.Get("entity_id"); value != "" {
+		if filter.EntityID, err = strconv.ParseInt(value, 10, 64); err != nil {
+			http.Error(w, "Invalid entity_id", http.StatusBadRequest)
+			return
+		}
+	}
+	if value := query.Get("from"); value != "" {
+		from, err := parseTimeParam(value, false)
+		if err != nil {
+			http.Error(w, "Invalid from", http.StatusBadRequest)
+			return
+		}
+		filter.From = &from
+	}
+	if value := query.Get("to"); value != "" {
+		to, err := parseTimeParam(value, true)
+		if err != nil {
+			http.Error(w, "Invalid to", http.StatusBadRequest)
+			return
+		}
+		filter.To = &to
+	}
+	if value := query.Get("limit"); value != "" {
+		if filter.Limit, err = strconv.Atoi(value); err != nil {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+	}
+	if value := query.Get("offset"); value != "" {
+		if filter.Offset, err = strconv.Atoi(value); err != nil || filter.Offset < 0 {
+			http.Error(w, "Invalid offset", http.StatusBadRequest)
+			return
+		}
+	}
+
+	entries, err := h.auditService.GetEntries(filter)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
+
+// GetCandidateAuditLog возвращает историю изменений кандидата, его оценок и ответов
+func (h *Handlers) GetCandidateAuditLog(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	candidateID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid candidate ID", http.StatusBadRequest)
+		return
+	}
+
+	entries, err := h.auditService.GetEntries(models.AuditFilter{CandidateID: candidateID, Limit: 1000})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
+
+// parseTimeParam разбирает время в формате RFC 3339 или дату YYYY-MM-DD.
+// Для конца диапазона дата включает весь день
+func parseTimeParam(value string, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return t, nil
+}
+
+// Search handlers
+
+// Search выполняет полнотекстовый поиск по вакансиям, кандидатам, вопросам и ответам
+func (h *Handlers) Search(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := models.SearchFilter{
+		Query: strings.TrimSpace(query.Get("q")),
+	}
+	if filter.Query == "" {
+		http.Error(w, "Query parameter q is required", http.StatusBadRequest)
+		return
+	}
+
+	if value := query.Get("type"); value != "" {
+		for _, t := range strings.Split(value, ",") {
+			t = strings.TrimSpace(t)
+			if !slices.Contains(services.SearchTypes, t) {
+				http.Error(w, "Invalid type: "+t, http.StatusBadRequest)
+				return
+			}
+			filter.Types = append(filter.Types, t)
+		}
+	}
+
+	var err error
+	if value := query.Get("job_id"); value != "" {
+		if filter.JobID, err = strconv.ParseInt(value, 10, 64); err != nil {
+			http.Error(w, "Invalid job_id", http.StatusBadRequest)
+			return
+		}
+	}
+	if value := query.Get("limit"); value != "" {
+		if filter.Limit, err = strconv.Atoi(value); err != nil {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+	}
+
+	results, err := h.searchService.Search(filter)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+// Pipeline handlers
+
+// GetJobStages возвращает этапы воронки вакансии
+func (h *Handlers) GetJobStages(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	jobID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid job ID", http.StatusBadRequest)
+		return
+	}
+
+	stages, err := h.pipelineService.GetJobStages(jobID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stages)
+}
+
+// CreateJobStage добавляет этап в воронку вакансии
+func (h *Handlers) CreateJobStage(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	jobID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid job ID", http.StatusBadRequest)
+		return
+	}
+
+	var stage models.JobStage
+	if err := json.NewDecoder(r.Body).Decode(&stage); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	created, err := h.pipelineService.CreateStage(jobID, stage, actorFromRequest(r))
+	if err != nil {
+		writePipelineError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(created)
+}
+
+// UpdateJobStage переименовывает этап
+func (h *Handlers) UpdateJobStage(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid stage ID", http.StatusBadRequest)
+		return
+	}
+
+	var update models.JobStageUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	stage, err := h.pipelineService.UpdateStage(id, update, actorFromRequest(r))
+	if err != nil {
+		writePipelineError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stage)
+}
+
+// DeleteJobStage удаляет этап без кандидатов
+func (h *Handlers) DeleteJobStage(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid stage ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.pipelineService.DeleteStage(id, actorFromRequest(r)); err != nil {
+		writePipelineError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ReorderJobStages обновляет порядок этапов воронки
+func (h *Handlers) ReorderJobStages(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	jobID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid job ID", http.StatusBadRequest)
+		return
+	}
+
+	var stageIDs []int64
+	if err := json.NewDecoder(r.Body).Decode(&stageIDs); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.pipelineService.ReorderStages(jobID, stageIDs, actorFromRequest(r)); err != nil {
+		writePipelineError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "success"})
+}
+
+// GetJobBoard возвращает канбан-доску вакансии
+func (h *Handlers) GetJobBoard(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	jobID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid job ID", http.StatusBadRequest)
+		return
+	}
+
+	board, err := h.pipelineService.GetBoard(jobID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(board)
+}
+
+// MoveCandidate перемещает карточку отклика кандидата на доске
+func (h *Handlers) MoveCandidate(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.applicationIDFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var request models.CandidateMoveRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	application, err := h.pipelineService.MoveApplication(id, &request, actorFromRequest(r))
+	if err != nil {
+		writePipelineError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(application)
+}
+
+// GetScoringScales возвращает предустановленные шкалы оценок
+func (h *Handlers) GetScoringScales(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(services.ScoringScales())
+}
+
+// writeScoringError отвечает 400 на некорректную шкалу, оценку вне шкалы, рубрику или вес
+// критерия, порог отсеивающего критерия, оценку по критерию другой вакансии или параметры
+// ранжирования и 500 на остальные ошибки
+func writeScoringError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidScale), errors.Is(err, services.ErrInvalidScore), errors.Is(err, services.ErrInvalidRubric),
+		errors.Is(err, services.ErrInvalidKnockout), errors.Is(err, services.ErrInvalidRanking), errors.Is(err, services.ErrInvalidCriterion),
+		errors.Is(err, services.ErrInvalidWeight):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// writePipelineError отвечает 400 на некорректное изменение воронки,
+// 409 на недопустимый переход и 500 на остальные ошибки
+func writePipelineError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidPipeline):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, services.ErrInvalidStageTransition):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
 }
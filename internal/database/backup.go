@@ -0,0 +1,155 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	sqlite3 "github.com/mattn/go-sqlite3"
+)
+
+const (
+	// backupStepPages количество страниц, копируемых за один шаг backup API
+	backupStepPages = 256
+	// backupBusyTimeout время ожидания снятия блокировки при копировании
+	backupBusyTimeout = 30 * time.Second
+)
+
+// BackupTo создает консистентный снимок базы в файле destPath через
+// SQLite Online Backup API, не останавливая работу с базой
+func (db *DB) BackupTo(destPath string) error {
+	dest, err := sql.Open(driverName, destPath)
+	if err != nil {
+		return fmt.Errorf("failed to open backup file: %w", err)
+	}
+	defer dest.Close()
+
+	return copyDatabase(dest, db.DB)
+}
+
+// RestoreFrom заменяет содержимое базы содержимым снимка srcPath.
+// Открытые подключения продолжают работать уже с восстановленными данными
+func (db *DB) RestoreFrom(srcPath string) error {
+	src, err := sql.Open(driverName, "file:"+srcPath+"?mode=ro")
+	if err != nil {
+		return fmt.Errorf("failed to open backup file: %w", err)
+	}
+	defer src.Close()
+
+	return copyDatabase(db.DB, src)
+}
+
+// ValidateBackup проверяет, что файл является целостной базой Choizee,
+// схема которой не новее известных приложению миграций
+func ValidateBackup(path string) error {
+	snapshot, err := sql.Open(driverName, "file:"+path+"?mode=ro")
+	if err != nil {
+		return fmt.Errorf("failed to open backup file: %w", err)
+	}
+	defer snapshot.Close()
+
+	var integrity string
+	if err := snapshot.QueryRow("PRAGMA integrity_check").Scan(&integrity); err != nil {
+		return fmt.Errorf("failed to check backup integrity: %w", err)
+	}
+	if integrity != "ok" {
+		return fmt.Errorf("backup is corrupted: %s", integrity)
+	}
+
+	var jobsTable int
+	err = snapshot.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'jobs'").Scan(&jobsTable)
+	if err != nil {
+		return fmt.Errorf("failed to inspect backup schema: %w", err)
+	}
+	if jobsTable == 0 {
+		return fmt.Errorf("backup does not contain a Choizee database")
+	}
+
+	var migrationsTable int
+	err = snapshot.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations'").Scan(&migrationsTable)
+	if err != nil {
+		return fmt.Errorf("failed to inspect backup schema: %w", err)
+	}
+	if migrationsTable == 0 {
+		// Снимок сделан до появления версионирования, миграции обновят его после восстановления
+		return nil
+	}
+
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+	latest := 0
+	if len(migrations) > 0 {
+		latest = migrations[len(migrations)-1].Version
+	}
+
+	var version int
+	if err := snapshot.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version); err != nil {
+		return fmt.Errorf("failed to read backup schema version: %w", err)
+	}
+	if version > latest {
+		return fmt.Errorf("backup schema version %d is newer than supported version %d", version, latest)
+	}
+
+	return nil
+}
+
+// copyDatabase копирует основную базу src в основную базу dest
+func copyDatabase(dest, src *sql.DB) error {
+	ctx := context.Background()
+
+	destConn, err := dest.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get destination connection: %w", err)
+	}
+	defer destConn.Close()
+
+	srcConn, err := src.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get source connection: %w", err)
+	}
+	defer srcConn.Close()
+
+	return destConn.Raw(func(destDriverConn any) error {
+		return srcConn.Raw(func(srcDriverConn any) error {
+			destSQLite, ok := destDriverConn.(*sqlite3.SQLiteConn)
+			if !ok {
+				return fmt.Errorf("unexpected destination driver connection %T", destDriverConn)
+			}
+			srcSQLite, ok := srcDriverConn.(*sqlite3.SQLiteConn)
+			if !ok {
+				return fmt.Errorf("unexpected source driver connection %T", srcDriverConn)
+			}
+
+			backup, err := destSQLite.Backup("main", srcSQLite, "main")
+			if err != nil {
+				return fmt.Errorf("failed to start backup: %w", err)
+			}
+
+			deadline := time.Now().Add(backupBusyTimeout)
+			for {
+				done, err := backup.Step(backupStepPages)
+				if err != nil {
+					backup.Finish()
+					return fmt.Errorf("failed to copy database pages: %w", err)
+				}
+				if done {
+					break
+				}
+				if time.Now().After(deadline) {
+					backup.Finish()
+					return fmt.Errorf("backup timed out waiting for database lock")
+				}
+				// Между шагами отпускаем блокировку, чтобы не задерживать запись
+				time.Sleep(10 * time.Millisecond)
+			}
+
+			if err := backup.Finish(); err != nil {
+				return fmt.Errorf("failed to finish backup: %w", err)
+			}
+			return nil
+		})
+	})
+}
@@ -0,0 +1,340 @@
+package database
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Migration представляет одну версионированную миграцию схемы
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus представляет состояние миграции в базе данных. Legacy отмечает
+// миграцию исходной схемы в базе без schema_migrations: migrate up отметит ее
+// примененной, не выполняя
+type MigrationStatus struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+	Legacy    bool       `json:"legacy,omitempty"`
+}
+
+// legacyMigration миграция, которая в базах до версионирования считается
+// примененной, если в схеме есть ее таблица
+type legacyMigration struct {
+	version int
+	name    string
+	table   string
+}
+
+// Базы до версионирования: исходная схема создавалась при старте,
+// а таблица criteria появлялась после ручного запуска migrate_criteria.sql
+var legacyMigrations = []legacyMigration{
+	{1, "initial_schema", "jobs"},
+	{2, "criteria_table", "criteria"},
+}
+
+// loadMigrations читает встроенные файлы вида NNNN_name.up.sql / NNNN_name.down.sql
+func loadMigrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		fileName := entry.Name()
+
+		var direction string
+		switch {
+		case strings.HasSuffix(fileName, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(fileName, ".down.sql"):
+			direction = "down"
+		default:
+			continue
+		}
+
+		base := strings.TrimSuffix(fileName, "."+direction+".sql")
+		versionPart, name, found := strings.Cut(base, "_")
+		if !found {
+			return nil, fmt.Errorf("invalid migration file name: %s", fileName)
+		}
+		version, err := strconv.Atoi(versionPart)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s: %w", fileName, err)
+		}
+
+		content, err := migrationFiles.ReadFile(path.Join("migrations", fileName))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", fileName, err)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: name}
+			byVersion[version] = migration
+		}
+		if direction == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	var migrations []Migration
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %04d_%s has no up script", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// ensureMigrationsTable создает таблицу schema_migrations и отмечает
+// миграции, уже примененные к базам, созданным до появления версионирования
+func (db *DB) ensureMigrationsTable() error {
+	exists, err := db.migrationsTableExists()
+	if err != nil || exists {
+		return err
+	}
+
+	legacy, err := db.detectLegacyMigrations()
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		CREATE TABLE schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	for _, m := range legacy {
+		if _, err := tx.Exec("INSERT INTO schema_migrations (version, name) VALUES (?, ?)", m.version, m.name); err != nil {
+			return fmt.Errorf("failed to record legacy migration: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// migrationsTableExists сообщает, есть ли в базе таблица schema_migrations
+func (db *DB) migrationsTableExists() (bool, error) {
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations'").Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("failed to check schema_migrations table: %w", err)
+	}
+	return count > 0, nil
+}
+
+// detectLegacyMigrations возвращает миграции, уже примененные к базе без schema_migrations:
+// подряд идущие миграции, таблицы которых есть в схеме
+func (db *DB) detectLegacyMigrations() ([]legacyMigration, error) {
+	var detected []legacyMigration
+	for _, m := range legacyMigrations {
+		var count int
+		err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", m.table).Scan(&count)
+		if err != nil {
+			return nil, fmt.Errorf("failed to inspect legacy schema: %w", err)
+		}
+		if count == 0 {
+			break
+		}
+		detected = append(detected, m)
+	}
+	return detected, nil
+}
+
+// appliedVersions возвращает примененные версии и время их применения
+func (db *DB) appliedVersions() (map[int]time.Time, error) {
+	rows, err := db.Query("SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan migration: %w", err)
+		}
+		applied[version] = appliedAt
+	}
+
+	return applied, rows.Err()
+}
+
+// MigrateUp применяет все непримененные миграции и возвращает их список
+func (db *DB) MigrateUp() ([]Migration, error) {
+	if err := db.ensureMigrationsTable(); err != nil {
+		return nil, err
+	}
+
+	migrations, err := loadMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := db.appliedVersions()
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, migration := range migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+
+		err := db.runMigration(migration.Up, func(tx *sql.Tx) error {
+			_, err := tx.Exec("INSERT INTO schema_migrations (version, name) VALUES (?, ?)", migration.Version, migration.Name)
+			return err
+		})
+		if err != nil {
+			return done, fmt.Errorf("migration %04d_%s failed: %w", migration.Version, migration.Name, err)
+		}
+		done = append(done, migration)
+	}
+
+	return done, nil
+}
+
+// MigrateDown откатывает последние steps примененных миграций
+func (db *DB) MigrateDown(steps int) ([]Migration, error) {
+	if err := db.ensureMigrationsTable(); err != nil {
+		return nil, err
+	}
+
+	migrations, err := loadMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := db.appliedVersions()
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for i := len(migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		migration := migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+		if migration.Down == "" {
+			return done, fmt.Errorf("migration %04d_%s cannot be rolled back", migration.Version, migration.Name)
+		}
+
+		err := db.runMigration(migration.Down, func(tx *sql.Tx) error {
+			_, err := tx.Exec("DELETE FROM schema_migrations WHERE version = ?", migration.Version)
+			return err
+		})
+		if err != nil {
+			return done, fmt.Errorf("rollback of %04d_%s failed: %w", migration.Version, migration.Name, err)
+		}
+		done = append(done, migration)
+	}
+
+	return done, nil
+}
+
+// MigrationStatus возвращает состояние всех известных миграций, ничего не меняя в базе.
+// Если schema_migrations еще нет, миграции исходной схемы отмечаются как Legacy
+func (db *DB) MigrationStatus() ([]MigrationStatus, error) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	exists, err := db.migrationsTableExists()
+	if err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int]time.Time)
+	legacy := make(map[int]bool)
+	if exists {
+		applied, err = db.appliedVersions()
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		detected, err := db.detectLegacyMigrations()
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range detected {
+			legacy[m.version] = true
+		}
+	}
+
+	var statuses []MigrationStatus
+	for _, migration := range migrations {
+		status := MigrationStatus{Version: migration.Version, Name: migration.Name, Legacy: legacy[migration.Version]}
+		if appliedAt, ok := applied[migration.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// runMigration выполняет скрипт миграции и запись в schema_migrations одной транзакцией
+func (db *DB) runMigration(script string, record func(tx *sql.Tx) error) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(script); err != nil {
+		return err
+	}
+
+	if err := record(tx); err != nil {
+		return fmt.Errorf("failed to record migration: %w", err)
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,7 @@
+-- Откат: Удаление исходной схемы базы данных
+
+DROP TABLE IF EXISTS answers;
+DROP TABLE IF EXISTS evaluations;
+DROP TABLE IF EXISTS questions;
+DROP TABLE IF EXISTS candidates;
+DROP TABLE IF EXISTS jobs;
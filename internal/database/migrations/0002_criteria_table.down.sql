@@ -0,0 +1,68 @@
+-- Откат: Возврат к строковым критериям в questions и evaluations
+
+-- 1. Восстанавливаем JSON-поле jobs.criteria из таблицы критериев
+UPDATE jobs
+SET criteria = (
+    SELECT json_group_array(name)
+    FROM (SELECT name FROM criteria WHERE job_id = jobs.id ORDER BY display_order, id)
+)
+WHERE id IN (SELECT DISTINCT job_id FROM criteria);
+
+-- 2. Пересоздаем questions со строковым критерием
+CREATE TABLE questions_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    job_id INTEGER NOT NULL,
+    text TEXT NOT NULL,
+    criterion TEXT NOT NULL, -- Критерий, который проверяет вопрос
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (job_id) REFERENCES jobs(id) ON DELETE CASCADE
+);
+
+INSERT INTO questions_old (id, job_id, text, criterion, created_at, updated_at)
+SELECT q.id, q.job_id, q.text, c.name, q.created_at, q.updated_at
+FROM questions q
+INNER JOIN criteria c ON q.criterion_id = c.id;
+
+-- 3. Пересоздаем evaluations со строковым критерием
+CREATE TABLE evaluations_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    candidate_id INTEGER NOT NULL,
+    criterion TEXT NOT NULL,
+    score INTEGER NOT NULL CHECK (score >= 1 AND score <= 10),
+    comments TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (candidate_id) REFERENCES candidates(id) ON DELETE CASCADE,
+    UNIQUE(candidate_id, criterion) -- Один критерий - одна оценка
+);
+
+INSERT INTO evaluations_old (id, candidate_id, criterion, score, comments, created_at, updated_at)
+SELECT e.id, e.candidate_id, c.name, e.score, e.comments, e.created_at, e.updated_at
+FROM evaluations e
+INNER JOIN criteria c ON e.criterion_id = c.id;
+
+-- 4. Заменяем таблицы и удаляем критерии
+DROP TABLE questions;
+ALTER TABLE questions_old RENAME TO questions;
+
+DROP TABLE evaluations;
+ALTER TABLE evaluations_old RENAME TO evaluations;
+
+DROP TABLE criteria;
+
+-- 5. Восстанавливаем индексы и триггеры исходной схемы
+CREATE INDEX IF NOT EXISTS idx_questions_job_id ON questions(job_id);
+CREATE INDEX IF NOT EXISTS idx_evaluations_candidate_id ON evaluations(candidate_id);
+
+CREATE TRIGGER IF NOT EXISTS update_questions_updated_at 
+    AFTER UPDATE ON questions
+    BEGIN
+        UPDATE questions SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id;
+    END;
+
+CREATE TRIGGER IF NOT EXISTS update_evaluations_updated_at 
+    AFTER UPDATE ON evaluations
+    BEGIN
+        UPDATE evaluations SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id;
+    END;
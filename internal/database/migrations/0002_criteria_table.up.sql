@@ -2,8 +2,6 @@
 -- Дата: 2025-06-19
 -- Описание: Создаем отдельную таблицу критериев с уникальными ID
 
-BEGIN TRANSACTION;
-
 -- 1. Создаем новую таблицу критериев
 CREATE TABLE IF NOT EXISTS criteria (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -27,6 +25,26 @@ FROM questions
 GROUP BY job_id, criterion
 ORDER BY job_id, MIN(created_at);
 
+-- Добавляем критерии из JSON-поля jobs.criteria и из оценок, по которым
+-- еще не было вопросов, чтобы не потерять оценки при переносе
+INSERT INTO criteria (job_id, name, display_order)
+SELECT
+    src.job_id,
+    src.name,
+    (SELECT COUNT(*) FROM criteria c WHERE c.job_id = src.job_id)
+        + ROW_NUMBER() OVER (PARTITION BY src.job_id ORDER BY MIN(src.position)) - 1 as display_order
+FROM (
+    SELECT j.id as job_id, TRIM(je.value) as name, je.key as position
+    FROM jobs j, json_each(CASE WHEN json_valid(j.criteria) THEN j.criteria ELSE '[]' END) je
+    WHERE je.type = 'text' AND TRIM(je.value) <> ''
+    UNION ALL
+    SELECT cand.job_id, e.criterion, 1000000 + e.id
+    FROM evaluations e
+    INNER JOIN candidates cand ON e.candidate_id = cand.id
+) src
+WHERE NOT EXISTS (SELECT 1 FROM criteria c WHERE c.job_id = src.job_id AND c.name = src.name)
+GROUP BY src.job_id, src.name;
+
 -- 3. Создаем новую таблицу questions с criterion_id
 CREATE TABLE questions_new (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -94,6 +112,8 @@ WHERE id IN (SELECT DISTINCT job_id FROM criteria);
 
 -- 9. Создаем индексы для производительности
 CREATE INDEX IF NOT EXISTS idx_criteria_job_id ON criteria(job_id);
+CREATE INDEX IF NOT EXISTS idx_questions_job_id ON questions(job_id);
+CREATE INDEX IF NOT EXISTS idx_evaluations_candidate_id ON evaluations(candidate_id);
 CREATE INDEX IF NOT EXISTS idx_questions_criterion_id ON questions(criterion_id);
 CREATE INDEX IF NOT EXISTS idx_evaluations_criterion_id ON evaluations(criterion_id);
 
@@ -118,5 +138,3 @@ CREATE TRIGGER update_evaluations_updated_at
     BEGIN
         UPDATE evaluations SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id;
     END;
-
-COMMIT; 
\ No newline at end of file
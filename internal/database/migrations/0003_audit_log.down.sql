@@ -0,0 +1,3 @@
+-- Откат: Удаление журнала аудита
+
+DROP TABLE IF EXISTS audit_log;
@@ -0,0 +1,20 @@
+-- Миграция: Журнал аудита изменений
+-- Описание: Каждое создание, изменение и удаление сущностей сохраняется
+-- вместе с автором изменения и состоянием до и после в JSON
+
+CREATE TABLE IF NOT EXISTS audit_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    entity TEXT NOT NULL,          -- Тип сущности: job, candidate, question, ...
+    entity_id INTEGER NOT NULL,
+    candidate_id INTEGER,          -- Кандидат, к которому относится изменение (если есть)
+    action TEXT NOT NULL CHECK (action IN ('create', 'update', 'delete')),
+    actor TEXT NOT NULL,
+    before_json TEXT,
+    after_json TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_entity ON audit_log(entity, entity_id);
+CREATE INDEX IF NOT EXISTS idx_audit_log_candidate_id ON audit_log(candidate_id);
+CREATE INDEX IF NOT EXISTS idx_audit_log_actor ON audit_log(actor);
+CREATE INDEX IF NOT EXISTS idx_audit_log_created_at ON audit_log(created_at);
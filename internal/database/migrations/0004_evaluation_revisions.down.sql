@@ -0,0 +1,4 @@
+-- Откат: Удаление истории ревизий оценок
+
+DROP TABLE IF EXISTS evaluation_revision_items;
+DROP TABLE IF EXISTS evaluation_revisions;
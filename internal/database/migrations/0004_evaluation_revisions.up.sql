@@ -0,0 +1,36 @@
+-- Миграция: История ревизий оценок
+-- Описание: Каждое сохранение оценок кандидата фиксируется как ревизия
+-- со снимком всех оценок, чтобы прежние баллы не терялись
+
+CREATE TABLE IF NOT EXISTS evaluation_revisions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    candidate_id INTEGER NOT NULL,
+    revision INTEGER NOT NULL, -- Порядковый номер ревизии в рамках кандидата
+    actor TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (candidate_id) REFERENCES candidates(id) ON DELETE CASCADE,
+    UNIQUE(candidate_id, revision)
+);
+
+CREATE TABLE IF NOT EXISTS evaluation_revision_items (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    revision_id INTEGER NOT NULL,
+    criterion_id INTEGER NOT NULL,
+    score INTEGER NOT NULL,
+    comments TEXT,
+    FOREIGN KEY (revision_id) REFERENCES evaluation_revisions(id) ON DELETE CASCADE,
+    UNIQUE(revision_id, criterion_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_evaluation_revision_items_revision_id ON evaluation_revision_items(revision_id);
+
+-- Текущие оценки становятся первой ревизией
+INSERT INTO evaluation_revisions (candidate_id, revision, actor, created_at)
+SELECT candidate_id, 1, 'migration', MAX(updated_at)
+FROM evaluations
+GROUP BY candidate_id;
+
+INSERT INTO evaluation_revision_items (revision_id, criterion_id, score, comments)
+SELECT r.id, e.criterion_id, e.score, e.comments
+FROM evaluations e
+INNER JOIN evaluation_revisions r ON r.candidate_id = e.candidate_id AND r.revision = 1;
@@ -0,0 +1,19 @@
+-- Откат: Удаление полнотекстовых индексов
+
+DROP TRIGGER IF EXISTS jobs_fts_insert;
+DROP TRIGGER IF EXISTS jobs_fts_update;
+DROP TRIGGER IF EXISTS jobs_fts_delete;
+DROP TRIGGER IF EXISTS candidates_fts_insert;
+DROP TRIGGER IF EXISTS candidates_fts_update;
+DROP TRIGGER IF EXISTS candidates_fts_delete;
+DROP TRIGGER IF EXISTS questions_fts_insert;
+DROP TRIGGER IF EXISTS questions_fts_update;
+DROP TRIGGER IF EXISTS questions_fts_delete;
+DROP TRIGGER IF EXISTS answers_fts_insert;
+DROP TRIGGER IF EXISTS answers_fts_update;
+DROP TRIGGER IF EXISTS answers_fts_delete;
+
+DROP TABLE IF EXISTS jobs_fts;
+DROP TABLE IF EXISTS candidates_fts;
+DROP TABLE IF EXISTS questions_fts;
+DROP TABLE IF EXISTS answers_fts;
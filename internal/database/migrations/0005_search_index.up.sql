@@ -0,0 +1,109 @@
+-- Миграция: Полнотекстовый поиск
+-- Описание: FTS5-индексы по вакансиям, кандидатам, вопросам и ответам.
+-- В индекс попадают основы слов (функция stem_text), поэтому поиск находит
+-- разные словоформы. rowid индекса совпадает с id исходной записи,
+-- актуальность индекса поддерживается триггерами
+
+CREATE VIRTUAL TABLE IF NOT EXISTS jobs_fts USING fts5(title, description, requirements);
+CREATE VIRTUAL TABLE IF NOT EXISTS candidates_fts USING fts5(name, email, description);
+CREATE VIRTUAL TABLE IF NOT EXISTS questions_fts USING fts5(text);
+CREATE VIRTUAL TABLE IF NOT EXISTS answers_fts USING fts5(answer_text);
+
+-- Вакансии
+CREATE TRIGGER IF NOT EXISTS jobs_fts_insert
+    AFTER INSERT ON jobs
+    BEGIN
+        INSERT INTO jobs_fts (rowid, title, description, requirements)
+        VALUES (NEW.id, stem_text(COALESCE(NEW.title, '')), stem_text(COALESCE(NEW.description, '')), stem_text(COALESCE(NEW.requirements, '')));
+    END;
+
+CREATE TRIGGER IF NOT EXISTS jobs_fts_update
+    AFTER UPDATE OF title, description, requirements ON jobs
+    BEGIN
+        DELETE FROM jobs_fts WHERE rowid = OLD.id;
+        INSERT INTO jobs_fts (rowid, title, description, requirements)
+        VALUES (NEW.id, stem_text(COALESCE(NEW.title, '')), stem_text(COALESCE(NEW.description, '')), stem_text(COALESCE(NEW.requirements, '')));
+    END;
+
+CREATE TRIGGER IF NOT EXISTS jobs_fts_delete
+    AFTER DELETE ON jobs
+    BEGIN
+        DELETE FROM jobs_fts WHERE rowid = OLD.id;
+    END;
+
+-- Кандидаты
+CREATE TRIGGER IF NOT EXISTS candidates_fts_insert
+    AFTER INSERT ON candidates
+    BEGIN
+        INSERT INTO candidates_fts (rowid, name, email, description)
+        VALUES (NEW.id, stem_text(COALESCE(NEW.name, '')), stem_text(COALESCE(NEW.email, '')), stem_text(COALESCE(NEW.description, '')));
+    END;
+
+CREATE TRIGGER IF NOT EXISTS candidates_fts_update
+    AFTER UPDATE OF name, email, description ON candidates
+    BEGIN
+        DELETE FROM candidates_fts WHERE rowid = OLD.id;
+        INSERT INTO candidates_fts (rowid, name, email, description)
+        VALUES (NEW.id, stem_text(COALESCE(NEW.name, '')), stem_text(COALESCE(NEW.email, '')), stem_text(COALESCE(NEW.description, '')));
+    END;
+
+CREATE TRIGGER IF NOT EXISTS candidates_fts_delete
+    AFTER DELETE ON candidates
+    BEGIN
+        DELETE FROM candidates_fts WHERE rowid = OLD.id;
+    END;
+
+-- Вопросы
+CREATE TRIGGER IF NOT EXISTS questions_fts_insert
+    AFTER INSERT ON questions
+    BEGIN
+        INSERT INTO questions_fts (rowid, text) VALUES (NEW.id, stem_text(COALESCE(NEW.text, '')));
+    END;
+
+CREATE TRIGGER IF NOT EXISTS questions_fts_update
+    AFTER UPDATE OF text ON questions
+    BEGIN
+        DELETE FROM questions_fts WHERE rowid = OLD.id;
+        INSERT INTO questions_fts (rowid, text) VALUES (NEW.id, stem_text(COALESCE(NEW.text, '')));
+    END;
+
+CREATE TRIGGER IF NOT EXISTS questions_fts_delete
+    AFTER DELETE ON questions
+    BEGIN
+        DELETE FROM questions_fts WHERE rowid = OLD.id;
+    END;
+
+-- Ответы
+CREATE TRIGGER IF NOT EXISTS answers_fts_insert
+    AFTER INSERT ON answers
+    BEGIN
+        INSERT INTO answers_fts (rowid, answer_text) VALUES (NEW.id, stem_text(COALESCE(NEW.answer_text, '')));
+    END;
+
+CREATE TRIGGER IF NOT EXISTS answers_fts_update
+    AFTER UPDATE OF answer_text ON answers
+    BEGIN
+        DELETE FROM answers_fts WHERE rowid = OLD.id;
+        INSERT INTO answers_fts (rowid, answer_text) VALUES (NEW.id, stem_text(COALESCE(NEW.answer_text, '')));
+    END;
+
+CREATE TRIGGER IF NOT EXISTS answers_fts_delete
+    AFTER DELETE ON answers
+    BEGIN
+        DELETE FROM answers_fts WHERE rowid = OLD.id;
+    END;
+
+-- Индексируем существующие данные
+INSERT INTO jobs_fts (rowid, title, description, requirements)
+SELECT id, stem_text(COALESCE(title, '')), stem_text(COALESCE(description, '')), stem_text(COALESCE(requirements, ''))
+FROM jobs;
+
+INSERT INTO candidates_fts (rowid, name, email, description)
+SELECT id, stem_text(COALESCE(name, '')), stem_text(COALESCE(email, '')), stem_text(COALESCE(description, ''))
+FROM candidates;
+
+INSERT INTO questions_fts (rowid, text)
+SELECT id, stem_text(COALESCE(text, '')) FROM questions;
+
+INSERT INTO answers_fts (rowid, answer_text)
+SELECT id, stem_text(COALESCE(answer_text, '')) FROM answers;
@@ -0,0 +1,7 @@
+-- Откат: Удаление этапов найма
+
+DROP INDEX IF EXISTS idx_stage_transitions_candidate_id;
+DROP INDEX IF EXISTS idx_candidates_job_stage;
+DROP TABLE IF EXISTS candidate_stage_transitions;
+
+ALTER TABLE candidates DROP COLUMN stage;
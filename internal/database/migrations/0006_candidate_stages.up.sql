@@ -0,0 +1,19 @@
+-- Миграция: Этапы найма кандидатов
+-- Описание: Текущий этап кандидата хранится в candidates.stage,
+-- каждый переход между этапами записывается с автором и причиной
+
+ALTER TABLE candidates ADD COLUMN stage TEXT NOT NULL DEFAULT 'new';
+
+CREATE TABLE IF NOT EXISTS candidate_stage_transitions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    candidate_id INTEGER NOT NULL,
+    from_stage TEXT NOT NULL,
+    to_stage TEXT NOT NULL,
+    actor TEXT NOT NULL,
+    reason TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (candidate_id) REFERENCES candidates(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_candidates_job_stage ON candidates(job_id, stage);
+CREATE INDEX IF NOT EXISTS idx_stage_transitions_candidate_id ON candidate_stage_transitions(candidate_id);
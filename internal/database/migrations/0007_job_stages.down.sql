@@ -0,0 +1,22 @@
+-- Откат: Удаление настраиваемых этапов найма
+-- Кандидаты на нестандартных этапах возвращаются на этап new
+
+-- Триггер updated_at временно снимается, чтобы откат не менял даты изменения кандидатов
+DROP TRIGGER IF EXISTS update_candidates_updated_at;
+
+UPDATE candidates SET stage = 'new'
+WHERE stage NOT IN ('new', 'screening', 'interview', 'offer', 'hired', 'rejected');
+
+CREATE TRIGGER IF NOT EXISTS update_candidates_updated_at 
+    AFTER UPDATE ON candidates
+    BEGIN
+        UPDATE candidates SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id;
+    END;
+
+ALTER TABLE candidates DROP COLUMN stage_position;
+
+DROP TRIGGER IF EXISTS job_stages_delete;
+DROP TRIGGER IF EXISTS job_stages_defaults;
+DROP TRIGGER IF EXISTS update_job_stages_updated_at;
+DROP INDEX IF EXISTS idx_job_stages_job_id;
+DROP TABLE IF EXISTS job_stages;
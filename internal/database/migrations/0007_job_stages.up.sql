@@ -0,0 +1,76 @@
+-- Миграция: Настраиваемые этапы найма для вакансий
+-- Описание: Каждая вакансия получает собственный упорядоченный список этапов.
+-- Этапы new, hired и rejected системные и есть у каждой вакансии.
+-- stage_position задает порядок кандидатов внутри колонки канбан-доски
+
+CREATE TABLE IF NOT EXISTS job_stages (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    job_id INTEGER NOT NULL,
+    key TEXT NOT NULL,
+    name TEXT NOT NULL,
+    display_order INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (job_id) REFERENCES jobs(id) ON DELETE CASCADE,
+    UNIQUE(job_id, key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_job_stages_job_id ON job_stages(job_id);
+
+CREATE TRIGGER IF NOT EXISTS update_job_stages_updated_at
+    AFTER UPDATE ON job_stages
+    BEGIN
+        UPDATE job_stages SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id;
+    END;
+
+-- Новая вакансия получает стандартную воронку
+CREATE TRIGGER IF NOT EXISTS job_stages_defaults
+    AFTER INSERT ON jobs
+    BEGIN
+        INSERT INTO job_stages (job_id, key, name, display_order) VALUES
+            (NEW.id, 'new', 'Новый', 0),
+            (NEW.id, 'screening', 'Скрининг', 1),
+            (NEW.id, 'interview', 'Интервью', 2),
+            (NEW.id, 'offer', 'Оффер', 3),
+            (NEW.id, 'hired', 'Принят', 4),
+            (NEW.id, 'rejected', 'Отказ', 5);
+    END;
+
+CREATE TRIGGER IF NOT EXISTS job_stages_delete
+    AFTER DELETE ON jobs
+    BEGIN
+        DELETE FROM job_stages WHERE job_id = OLD.id;
+    END;
+
+-- Стандартная воронка для существующих вакансий
+INSERT INTO job_stages (job_id, key, name, display_order)
+SELECT j.id, s.key, s.name, s.display_order
+FROM jobs j
+CROSS JOIN (
+    SELECT 'new' AS key, 'Новый' AS name, 0 AS display_order
+    UNION ALL SELECT 'screening', 'Скрининг', 1
+    UNION ALL SELECT 'interview', 'Интервью', 2
+    UNION ALL SELECT 'offer', 'Оффер', 3
+    UNION ALL SELECT 'hired', 'Принят', 4
+    UNION ALL SELECT 'rejected', 'Отказ', 5
+) s;
+
+-- Порядок внутри колонки: по дате добавления кандидата
+ALTER TABLE candidates ADD COLUMN stage_position INTEGER NOT NULL DEFAULT 0;
+
+-- Триггер updated_at временно снимается, чтобы миграция не меняла даты изменения кандидатов
+DROP TRIGGER IF EXISTS update_candidates_updated_at;
+
+UPDATE candidates SET stage_position = (
+    SELECT COUNT(*) FROM candidates c
+    WHERE c.job_id = candidates.job_id
+      AND c.stage = candidates.stage
+      AND (c.created_at < candidates.created_at OR (c.created_at = candidates.created_at AND c.id < candidates.id))
+);
+
+CREATE TRIGGER IF NOT EXISTS update_candidates_updated_at 
+    AFTER UPDATE ON candidates
+    BEGIN
+        UPDATE candidates SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id;
+    END;
+
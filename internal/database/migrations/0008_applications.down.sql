@@ -0,0 +1,64 @@
+-- Откат: Возврат этапа, оценок и ответов к кандидату
+-- Сохраняются только данные отклика на вакансию candidates.job_id,
+-- данные остальных откликов удаляются
+
+ALTER TABLE candidates ADD COLUMN stage TEXT NOT NULL DEFAULT 'new';
+ALTER TABLE candidates ADD COLUMN stage_position INTEGER NOT NULL DEFAULT 0;
+
+-- Триггер updated_at временно снимается, чтобы откат не менял даты изменения кандидатов
+DROP TRIGGER IF EXISTS update_candidates_updated_at;
+
+UPDATE candidates SET
+    stage = COALESCE((SELECT a.stage FROM applications a WHERE a.candidate_id = candidates.id AND a.job_id = candidates.job_id), 'new'),
+    stage_position = COALESCE((SELECT a.stage_position FROM applications a WHERE a.candidate_id = candidates.id AND a.job_id = candidates.job_id), 0);
+
+CREATE TRIGGER IF NOT EXISTS update_candidates_updated_at 
+    AFTER UPDATE ON candidates
+    BEGIN
+        UPDATE candidates SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id;
+    END;
+
+CREATE INDEX IF NOT EXISTS idx_candidates_job_stage ON candidates(job_id, stage);
+
+CREATE TEMP TABLE primary_applications AS
+SELECT a.id FROM applications a
+JOIN candidates c ON c.id = a.candidate_id AND c.job_id = a.job_id;
+
+DELETE FROM evaluations WHERE application_id IS NULL OR application_id NOT IN (SELECT id FROM primary_applications);
+DELETE FROM answers WHERE application_id IS NULL OR application_id NOT IN (SELECT id FROM primary_applications);
+DELETE FROM candidate_stage_transitions WHERE application_id IS NULL OR application_id NOT IN (SELECT id FROM primary_applications);
+DELETE FROM evaluation_revision_items WHERE revision_id IN (
+    SELECT id FROM evaluation_revisions WHERE application_id NOT IN (SELECT id FROM primary_applications)
+);
+DELETE FROM evaluation_revisions WHERE application_id NOT IN (SELECT id FROM primary_applications);
+
+DROP TABLE primary_applications;
+
+CREATE TABLE evaluation_revisions_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    candidate_id INTEGER NOT NULL,
+    revision INTEGER NOT NULL, -- Порядковый номер ревизии в рамках кандидата
+    actor TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (candidate_id) REFERENCES candidates(id) ON DELETE CASCADE,
+    UNIQUE(candidate_id, revision)
+);
+
+INSERT INTO evaluation_revisions_old (id, candidate_id, revision, actor, created_at)
+SELECT id, candidate_id, revision, actor, created_at FROM evaluation_revisions;
+
+DROP TABLE evaluation_revisions;
+ALTER TABLE evaluation_revisions_old RENAME TO evaluation_revisions;
+
+DROP INDEX IF EXISTS idx_stage_transitions_application_id;
+ALTER TABLE candidate_stage_transitions DROP COLUMN application_id;
+
+DROP INDEX IF EXISTS idx_answers_application_id;
+ALTER TABLE answers DROP COLUMN application_id;
+
+DROP INDEX IF EXISTS idx_evaluations_application_id;
+ALTER TABLE evaluations DROP COLUMN application_id;
+
+DROP TRIGGER IF EXISTS update_applications_updated_at;
+DROP INDEX IF EXISTS idx_applications_job_stage;
+DROP TABLE IF EXISTS applications;
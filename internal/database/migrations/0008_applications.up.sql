@@ -0,0 +1,94 @@
+-- Миграция: Отклики кандидатов на вакансии
+-- Описание: Один человек может откликаться на несколько вакансий. Этап найма,
+-- оценки, ответы и история переходов теперь относятся к отклику (applications),
+-- а candidates хранит только данные человека. candidates.job_id остается
+-- вакансией, для которой кандидат был добавлен. Каждый существующий кандидат
+-- получает один отклик на свою вакансию. Кандидат удаленной вакансии отклика
+-- не получает и остается в базе без откликов
+
+CREATE TABLE IF NOT EXISTS applications (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    candidate_id INTEGER NOT NULL,
+    job_id INTEGER NOT NULL,
+    stage TEXT NOT NULL DEFAULT 'new',
+    stage_position INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (candidate_id) REFERENCES candidates(id) ON DELETE CASCADE,
+    FOREIGN KEY (job_id) REFERENCES jobs(id) ON DELETE CASCADE,
+    UNIQUE(candidate_id, job_id) -- Один отклик кандидата на вакансию
+);
+
+CREATE INDEX IF NOT EXISTS idx_applications_job_stage ON applications(job_id, stage);
+
+CREATE TRIGGER IF NOT EXISTS update_applications_updated_at
+    AFTER UPDATE ON applications
+    BEGIN
+        UPDATE applications SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id;
+    END;
+
+INSERT INTO applications (candidate_id, job_id, stage, stage_position, created_at, updated_at)
+SELECT id, job_id, stage, stage_position, created_at, updated_at
+FROM candidates
+WHERE job_id IN (SELECT id FROM jobs);
+
+-- Оценки, ответы и переходы привязываются к отклику.
+-- candidate_id сохраняется, чтобы выбирать все данные человека.
+-- Триггеры updated_at временно снимаются, чтобы миграция не меняла даты изменения оценок и ответов
+DROP TRIGGER IF EXISTS update_evaluations_updated_at;
+DROP TRIGGER IF EXISTS update_answers_updated_at;
+
+ALTER TABLE evaluations ADD COLUMN application_id INTEGER;
+UPDATE evaluations SET application_id = (
+    SELECT a.id FROM applications a WHERE a.candidate_id = evaluations.candidate_id
+);
+CREATE INDEX IF NOT EXISTS idx_evaluations_application_id ON evaluations(application_id);
+
+ALTER TABLE answers ADD COLUMN application_id INTEGER;
+UPDATE answers SET application_id = (
+    SELECT a.id FROM applications a WHERE a.candidate_id = answers.candidate_id
+);
+CREATE INDEX IF NOT EXISTS idx_answers_application_id ON answers(application_id);
+
+CREATE TRIGGER IF NOT EXISTS update_evaluations_updated_at 
+    AFTER UPDATE ON evaluations
+    BEGIN
+        UPDATE evaluations SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id;
+    END;
+
+CREATE TRIGGER IF NOT EXISTS update_answers_updated_at 
+    AFTER UPDATE ON answers
+    BEGIN
+        UPDATE answers SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id;
+    END;
+
+ALTER TABLE candidate_stage_transitions ADD COLUMN application_id INTEGER;
+UPDATE candidate_stage_transitions SET application_id = (
+    SELECT a.id FROM applications a WHERE a.candidate_id = candidate_stage_transitions.candidate_id
+);
+CREATE INDEX IF NOT EXISTS idx_stage_transitions_application_id ON candidate_stage_transitions(application_id);
+
+-- Ревизии оценок нумеруются в рамках отклика
+CREATE TABLE evaluation_revisions_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    application_id INTEGER NOT NULL,
+    candidate_id INTEGER NOT NULL,
+    revision INTEGER NOT NULL, -- Порядковый номер ревизии в рамках отклика
+    actor TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (application_id) REFERENCES applications(id) ON DELETE CASCADE,
+    UNIQUE(application_id, revision)
+);
+
+INSERT INTO evaluation_revisions_new (id, application_id, candidate_id, revision, actor, created_at)
+SELECT r.id, a.id, r.candidate_id, r.revision, r.actor, r.created_at
+FROM evaluation_revisions r
+JOIN applications a ON a.candidate_id = r.candidate_id;
+
+DROP TABLE evaluation_revisions;
+ALTER TABLE evaluation_revisions_new RENAME TO evaluation_revisions;
+
+-- Этап найма больше не хранится у кандидата
+DROP INDEX IF EXISTS idx_candidates_job_stage;
+ALTER TABLE candidates DROP COLUMN stage_position;
+ALTER TABLE candidates DROP COLUMN stage;
@@ -0,0 +1,6 @@
+-- Откат: Удаление вложений кандидатов
+-- Файлы в data/attachments не удаляются
+
+DROP INDEX IF EXISTS idx_candidate_attachments_sha256;
+DROP INDEX IF EXISTS idx_candidate_attachments_candidate_id;
+DROP TABLE IF EXISTS candidate_attachments;
@@ -0,0 +1,18 @@
+-- Миграция: Вложения кандидатов
+-- Описание: Резюме и другие документы кандидата. Содержимое хранится на диске
+-- в data/attachments под своим SHA-256, одинаковые файлы хранятся один раз
+
+CREATE TABLE IF NOT EXISTS candidate_attachments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    candidate_id INTEGER NOT NULL,
+    file_name TEXT NOT NULL,     -- Имя файла при загрузке
+    content_type TEXT NOT NULL,  -- MIME-тип, определенный по содержимому
+    size INTEGER NOT NULL,
+    sha256 TEXT NOT NULL,        -- Хеш содержимого, он же путь к файлу
+    actor TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (candidate_id) REFERENCES candidates(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_candidate_attachments_candidate_id ON candidate_attachments(candidate_id);
+CREATE INDEX IF NOT EXISTS idx_candidate_attachments_sha256 ON candidate_attachments(sha256);
@@ -0,0 +1,37 @@
+-- Откат миграции: Текст резюме
+
+DROP TRIGGER IF EXISTS candidates_fts_insert;
+DROP TRIGGER IF EXISTS candidates_fts_update;
+DROP TRIGGER IF EXISTS candidates_fts_delete;
+DROP TABLE IF EXISTS candidates_fts;
+
+CREATE VIRTUAL TABLE IF NOT EXISTS candidates_fts USING fts5(name, email, description);
+
+CREATE TRIGGER IF NOT EXISTS candidates_fts_insert
+    AFTER INSERT ON candidates
+    BEGIN
+        INSERT INTO candidates_fts (rowid, name, email, description)
+        VALUES (NEW.id, stem_text(COALESCE(NEW.name, '')), stem_text(COALESCE(NEW.email, '')), stem_text(COALESCE(NEW.description, '')));
+    END;
+
+CREATE TRIGGER IF NOT EXISTS candidates_fts_update
+    AFTER UPDATE OF name, email, description ON candidates
+    BEGIN
+        DELETE FROM candidates_fts WHERE rowid = OLD.id;
+        INSERT INTO candidates_fts (rowid, name, email, description)
+        VALUES (NEW.id, stem_text(COALESCE(NEW.name, '')), stem_text(COALESCE(NEW.email, '')), stem_text(COALESCE(NEW.description, '')));
+    END;
+
+CREATE TRIGGER IF NOT EXISTS candidates_fts_delete
+    AFTER DELETE ON candidates
+    BEGIN
+        DELETE FROM candidates_fts WHERE rowid = OLD.id;
+    END;
+
+INSERT INTO candidates_fts (rowid, name, email, description)
+SELECT id, stem_text(COALESCE(name, '')), stem_text(COALESCE(email, '')), stem_text(COALESCE(description, ''))
+FROM candidates;
+
+ALTER TABLE candidates DROP COLUMN resume_text;
+ALTER TABLE candidate_attachments DROP COLUMN text_error;
+ALTER TABLE candidate_attachments DROP COLUMN text_content;
@@ -0,0 +1,40 @@
+-- Миграция: Текст резюме
+-- Описание: Текст, извлеченный из вложений кандидата (PDF, DOCX, TXT), хранится
+-- у вложения, а объединенный текст всех вложений - у кандидата и попадает в поиск
+
+ALTER TABLE candidate_attachments ADD COLUMN text_content TEXT; -- Извлеченный текст
+ALTER TABLE candidate_attachments ADD COLUMN text_error TEXT;   -- Причина, по которой текст не извлечен
+ALTER TABLE candidates ADD COLUMN resume_text TEXT;
+
+-- Пересоздаем индекс кандидатов с полем resume_text
+DROP TRIGGER IF EXISTS candidates_fts_insert;
+DROP TRIGGER IF EXISTS candidates_fts_update;
+DROP TRIGGER IF EXISTS candidates_fts_delete;
+DROP TABLE IF EXISTS candidates_fts;
+
+CREATE VIRTUAL TABLE IF NOT EXISTS candidates_fts USING fts5(name, email, description, resume_text);
+
+CREATE TRIGGER IF NOT EXISTS candidates_fts_insert
+    AFTER INSERT ON candidates
+    BEGIN
+        INSERT INTO candidates_fts (rowid, name, email, description, resume_text)
+        VALUES (NEW.id, stem_text(COALESCE(NEW.name, '')), stem_text(COALESCE(NEW.email, '')), stem_text(COALESCE(NEW.description, '')), stem_text(COALESCE(NEW.resume_text, '')));
+    END;
+
+CREATE TRIGGER IF NOT EXISTS candidates_fts_update
+    AFTER UPDATE OF name, email, description, resume_text ON candidates
+    BEGIN
+        DELETE FROM candidates_fts WHERE rowid = OLD.id;
+        INSERT INTO candidates_fts (rowid, name, email, description, resume_text)
+        VALUES (NEW.id, stem_text(COALESCE(NEW.name, '')), stem_text(COALESCE(NEW.email, '')), stem_text(COALESCE(NEW.description, '')), stem_text(COALESCE(NEW.resume_text, '')));
+    END;
+
+CREATE TRIGGER IF NOT EXISTS candidates_fts_delete
+    AFTER DELETE ON candidates
+    BEGIN
+        DELETE FROM candidates_fts WHERE rowid = OLD.id;
+    END;
+
+INSERT INTO candidates_fts (rowid, name, email, description, resume_text)
+SELECT id, stem_text(COALESCE(name, '')), stem_text(COALESCE(email, '')), stem_text(COALESCE(description, '')), ''
+FROM candidates;
@@ -0,0 +1,62 @@
+-- Откат миграции: Несколько интервьюеров
+-- По каждому критерию кандидата остается последняя измененная оценка
+
+CREATE TABLE evaluations_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    candidate_id INTEGER NOT NULL,
+    criterion_id INTEGER NOT NULL,
+    score INTEGER NOT NULL CHECK (score >= 1 AND score <= 10),
+    comments TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    application_id INTEGER,
+    FOREIGN KEY (candidate_id) REFERENCES candidates(id) ON DELETE CASCADE,
+    FOREIGN KEY (criterion_id) REFERENCES criteria(id) ON DELETE CASCADE,
+    UNIQUE(candidate_id, criterion_id) -- Один критерий - одна оценка
+);
+
+INSERT INTO evaluations_old (id, candidate_id, criterion_id, score, comments, created_at, updated_at, application_id)
+SELECT e.id, e.candidate_id, e.criterion_id, e.score, e.comments, e.created_at, e.updated_at, e.application_id
+FROM evaluations e
+WHERE e.id = (
+    SELECT latest.id FROM evaluations latest
+    WHERE latest.candidate_id = e.candidate_id AND latest.criterion_id = e.criterion_id
+    ORDER BY latest.updated_at DESC, latest.id DESC
+    LIMIT 1
+);
+
+DROP TABLE evaluations;
+ALTER TABLE evaluations_old RENAME TO evaluations;
+
+CREATE INDEX IF NOT EXISTS idx_evaluations_candidate_id ON evaluations(candidate_id);
+CREATE INDEX IF NOT EXISTS idx_evaluations_criterion_id ON evaluations(criterion_id);
+CREATE INDEX IF NOT EXISTS idx_evaluations_application_id ON evaluations(application_id);
+
+CREATE TRIGGER IF NOT EXISTS update_evaluations_updated_at 
+    AFTER UPDATE ON evaluations
+    BEGIN
+        UPDATE evaluations SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id;
+    END;
+
+CREATE TABLE evaluation_revision_items_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    revision_id INTEGER NOT NULL,
+    criterion_id INTEGER NOT NULL,
+    score INTEGER NOT NULL,
+    comments TEXT,
+    FOREIGN KEY (revision_id) REFERENCES evaluation_revisions(id) ON DELETE CASCADE,
+    UNIQUE(revision_id, criterion_id)
+);
+
+INSERT INTO evaluation_revision_items_old (id, revision_id, criterion_id, score, comments)
+SELECT i.id, i.revision_id, i.criterion_id, i.score, i.comments
+FROM evaluation_revision_items i
+WHERE i.id = (
+    SELECT MAX(other.id) FROM evaluation_revision_items other
+    WHERE other.revision_id = i.revision_id AND other.criterion_id = i.criterion_id
+);
+
+DROP TABLE evaluation_revision_items;
+ALTER TABLE evaluation_revision_items_old RENAME TO evaluation_revision_items;
+
+CREATE INDEX IF NOT EXISTS idx_evaluation_revision_items_revision_id ON evaluation_revision_items(revision_id);
@@ -0,0 +1,76 @@
+-- Миграция: Несколько интервьюеров
+-- Описание: Оценка принадлежит интервьюеру (evaluator): по каждому критерию отклика
+-- интервьюеры ставят собственные оценки, и они не перезаписывают друг друга.
+-- Существующие оценки приписываются автору последней ревизии оценок отклика
+
+CREATE TEMP TABLE application_evaluators AS
+SELECT a.id AS application_id,
+       COALESCE((
+           SELECT NULLIF(r.actor, 'migration') FROM evaluation_revisions r
+           WHERE r.application_id = a.id
+           ORDER BY r.revision DESC
+           LIMIT 1
+       ), 'anonymous') AS evaluator
+FROM applications a;
+
+CREATE TABLE evaluations_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    application_id INTEGER NOT NULL,
+    candidate_id INTEGER NOT NULL,
+    criterion_id INTEGER NOT NULL,
+    evaluator TEXT NOT NULL, -- Интервьюер, поставивший оценку
+    score INTEGER NOT NULL CHECK (score >= 1 AND score <= 10),
+    comments TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (application_id) REFERENCES applications(id) ON DELETE CASCADE,
+    FOREIGN KEY (candidate_id) REFERENCES candidates(id) ON DELETE CASCADE,
+    FOREIGN KEY (criterion_id) REFERENCES criteria(id) ON DELETE CASCADE,
+    UNIQUE(application_id, criterion_id, evaluator) -- Один интервьюер - одна оценка по критерию
+);
+
+INSERT INTO evaluations_new (id, application_id, candidate_id, criterion_id, evaluator, score, comments, created_at, updated_at)
+SELECT e.id, e.application_id, e.candidate_id, e.criterion_id, COALESCE(ae.evaluator, 'anonymous'),
+       e.score, e.comments, e.created_at, e.updated_at
+FROM evaluations e
+LEFT JOIN application_evaluators ae ON ae.application_id = e.application_id
+WHERE e.application_id IS NOT NULL;
+
+DROP TABLE evaluations;
+ALTER TABLE evaluations_new RENAME TO evaluations;
+
+CREATE INDEX IF NOT EXISTS idx_evaluations_candidate_id ON evaluations(candidate_id);
+CREATE INDEX IF NOT EXISTS idx_evaluations_criterion_id ON evaluations(criterion_id);
+CREATE INDEX IF NOT EXISTS idx_evaluations_application_id ON evaluations(application_id);
+CREATE INDEX IF NOT EXISTS idx_evaluations_evaluator ON evaluations(evaluator);
+
+CREATE TRIGGER IF NOT EXISTS update_evaluations_updated_at 
+    AFTER UPDATE ON evaluations
+    BEGIN
+        UPDATE evaluations SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id;
+    END;
+
+-- Снимки ревизий тоже хранят оценки каждого интервьюера
+CREATE TABLE evaluation_revision_items_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    revision_id INTEGER NOT NULL,
+    criterion_id INTEGER NOT NULL,
+    evaluator TEXT NOT NULL,
+    score INTEGER NOT NULL,
+    comments TEXT,
+    FOREIGN KEY (revision_id) REFERENCES evaluation_revisions(id) ON DELETE CASCADE,
+    UNIQUE(revision_id, criterion_id, evaluator)
+);
+
+INSERT INTO evaluation_revision_items_new (id, revision_id, criterion_id, evaluator, score, comments)
+SELECT i.id, i.revision_id, i.criterion_id, COALESCE(ae.evaluator, 'anonymous'), i.score, i.comments
+FROM evaluation_revision_items i
+LEFT JOIN evaluation_revisions r ON r.id = i.revision_id
+LEFT JOIN application_evaluators ae ON ae.application_id = r.application_id;
+
+DROP TABLE evaluation_revision_items;
+ALTER TABLE evaluation_revision_items_new RENAME TO evaluation_revision_items;
+
+CREATE INDEX IF NOT EXISTS idx_evaluation_revision_items_revision_id ON evaluation_revision_items(revision_id);
+
+DROP TABLE application_evaluators;
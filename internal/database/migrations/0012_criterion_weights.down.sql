@@ -0,0 +1,3 @@
+-- Откат миграции: Веса критериев
+
+ALTER TABLE criteria DROP COLUMN weight;
@@ -0,0 +1,5 @@
+-- Миграция: Веса критериев
+-- Описание: Вес определяет вклад критерия во взвешенную итоговую оценку кандидата.
+-- Существующие критерии получают вес 1, и взвешенная оценка совпадает с простым средним
+
+ALTER TABLE criteria ADD COLUMN weight REAL NOT NULL DEFAULT 1;
@@ -0,0 +1,64 @@
+-- Откат миграции: Шкалы оценок
+-- Оценки по другим шкалам пересчитываются в шкалу 1-10 с сохранением доли шкалы
+
+CREATE TEMP TABLE job_scale_bounds AS
+SELECT id AS job_id,
+       CASE scale_type
+           WHEN 'pass_fail' THEN 0
+           WHEN 'custom' THEN (SELECT MIN(json_extract(value, '$.value')) FROM json_each(scale_levels))
+           ELSE 1
+       END AS scale_min,
+       CASE scale_type
+           WHEN 'five_point' THEN 5
+           WHEN 'four_point' THEN 4
+           WHEN 'pass_fail' THEN 1
+           WHEN 'custom' THEN (SELECT MAX(json_extract(value, '$.value')) FROM json_each(scale_levels))
+           ELSE 10
+       END AS scale_max
+FROM jobs;
+
+CREATE TABLE evaluations_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    application_id INTEGER NOT NULL,
+    candidate_id INTEGER NOT NULL,
+    criterion_id INTEGER NOT NULL,
+    evaluator TEXT NOT NULL, -- Интервьюер, поставивший оценку
+    score INTEGER NOT NULL CHECK (score >= 1 AND score <= 10),
+    comments TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (application_id) REFERENCES applications(id) ON DELETE CASCADE,
+    FOREIGN KEY (candidate_id) REFERENCES candidates(id) ON DELETE CASCADE,
+    FOREIGN KEY (criterion_id) REFERENCES criteria(id) ON DELETE CASCADE,
+    UNIQUE(application_id, criterion_id, evaluator) -- Один интервьюер - одна оценка по критерию
+);
+
+INSERT INTO evaluations_new (id, application_id, candidate_id, criterion_id, evaluator, score, comments, created_at, updated_at)
+SELECT e.id, e.application_id, e.candidate_id, e.criterion_id, e.evaluator,
+       CASE
+           WHEN b.scale_max > b.scale_min
+               THEN 1 + CAST(ROUND((e.score - b.scale_min) * 9.0 / (b.scale_max - b.scale_min)) AS INTEGER)
+           ELSE MIN(MAX(e.score, 1), 10)
+       END,
+       e.comments, e.created_at, e.updated_at
+FROM evaluations e
+JOIN applications a ON a.id = e.application_id
+LEFT JOIN job_scale_bounds b ON b.job_id = a.job_id;
+
+DROP TABLE evaluations;
+ALTER TABLE evaluations_new RENAME TO evaluations;
+DROP TABLE job_scale_bounds;
+
+CREATE INDEX IF NOT EXISTS idx_evaluations_candidate_id ON evaluations(candidate_id);
+CREATE INDEX IF NOT EXISTS idx_evaluations_criterion_id ON evaluations(criterion_id);
+CREATE INDEX IF NOT EXISTS idx_evaluations_application_id ON evaluations(application_id);
+CREATE INDEX IF NOT EXISTS idx_evaluations_evaluator ON evaluations(evaluator);
+
+CREATE TRIGGER IF NOT EXISTS update_evaluations_updated_at 
+    AFTER UPDATE ON evaluations
+    BEGIN
+        UPDATE evaluations SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id;
+    END;
+
+ALTER TABLE jobs DROP COLUMN scale_levels;
+ALTER TABLE jobs DROP COLUMN scale_type;
@@ -0,0 +1,42 @@
+-- Миграция: Шкалы оценок
+-- Описание: Каждая вакансия выбирает шкалу оценок: 1-5, 1-10, четырехбалльную без середины,
+-- зачет/незачет или собственную шкалу с подписями уровней. Допустимость оценки теперь
+-- проверяется по шкале вакансии, поэтому ограничение 1-10 снимается с таблицы оценок.
+-- Существующие вакансии получают шкалу 1-10
+
+ALTER TABLE jobs ADD COLUMN scale_type TEXT NOT NULL DEFAULT 'ten_point';
+ALTER TABLE jobs ADD COLUMN scale_levels TEXT; -- JSON с уровнями собственной шкалы
+
+CREATE TABLE evaluations_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    application_id INTEGER NOT NULL,
+    candidate_id INTEGER NOT NULL,
+    criterion_id INTEGER NOT NULL,
+    evaluator TEXT NOT NULL, -- Интервьюер, поставивший оценку
+    score INTEGER NOT NULL,  -- Значение по шкале вакансии
+    comments TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (application_id) REFERENCES applications(id) ON DELETE CASCADE,
+    FOREIGN KEY (candidate_id) REFERENCES candidates(id) ON DELETE CASCADE,
+    FOREIGN KEY (criterion_id) REFERENCES criteria(id) ON DELETE CASCADE,
+    UNIQUE(application_id, criterion_id, evaluator) -- Один интервьюер - одна оценка по критерию
+);
+
+INSERT INTO evaluations_new (id, application_id, candidate_id, criterion_id, evaluator, score, comments, created_at, updated_at)
+SELECT id, application_id, candidate_id, criterion_id, evaluator, score, comments, created_at, updated_at
+FROM evaluations;
+
+DROP TABLE evaluations;
+ALTER TABLE evaluations_new RENAME TO evaluations;
+
+CREATE INDEX IF NOT EXISTS idx_evaluations_candidate_id ON evaluations(candidate_id);
+CREATE INDEX IF NOT EXISTS idx_evaluations_criterion_id ON evaluations(criterion_id);
+CREATE INDEX IF NOT EXISTS idx_evaluations_application_id ON evaluations(application_id);
+CREATE INDEX IF NOT EXISTS idx_evaluations_evaluator ON evaluations(evaluator);
+
+CREATE TRIGGER IF NOT EXISTS update_evaluations_updated_at 
+    AFTER UPDATE ON evaluations
+    BEGIN
+        UPDATE evaluations SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id;
+    END;
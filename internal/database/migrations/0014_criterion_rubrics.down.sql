@@ -0,0 +1,3 @@
+-- Откат миграции: Рубрики критериев
+
+ALTER TABLE criteria DROP COLUMN rubric;
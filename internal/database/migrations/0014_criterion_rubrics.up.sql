@@ -0,0 +1,5 @@
+-- Миграция: Рубрики критериев
+-- Описание: Рубрика описывает, как выглядит ответ кандидата на опорных уровнях шкалы,
+-- чтобы интервьюеры одинаково понимали, что значит, например, "7" по критерию
+
+ALTER TABLE criteria ADD COLUMN rubric TEXT; -- JSON: [{"score": 1, "description": "..."}, ...]
@@ -0,0 +1,5 @@
+-- Откат миграции: Отсеивающие критерии
+
+ALTER TABLE jobs DROP COLUMN knockout_action;
+
+ALTER TABLE criteria DROP COLUMN min_score;
@@ -0,0 +1,8 @@
+-- Миграция: Отсеивающие критерии
+-- Описание: Минимальная оценка делает критерий отсеивающим (NULL - обычный критерий).
+-- Вакансия выбирает, что делать с кандидатом ниже порога: только отмечать (flag)
+-- или сразу переводить отклик в отказ (reject)
+
+ALTER TABLE criteria ADD COLUMN min_score INTEGER;
+
+ALTER TABLE jobs ADD COLUMN knockout_action TEXT NOT NULL DEFAULT 'flag';
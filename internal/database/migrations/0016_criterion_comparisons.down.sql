@@ -0,0 +1,4 @@
+-- Откат миграции: Попарные сравнения критериев
+
+DROP INDEX IF EXISTS idx_criterion_comparisons_job_id;
+DROP TABLE IF EXISTS criterion_comparisons;
@@ -0,0 +1,20 @@
+-- Миграция: Попарные сравнения критериев
+-- Описание: Оценки важности критериев вакансии друг относительно друга по шкале Саати
+-- (от 1/9 до 9) для вывода весов методом анализа иерархий. Каждая пара хранится один раз:
+-- criterion_a_id < criterion_b_id, value - во сколько раз A важнее B
+
+CREATE TABLE IF NOT EXISTS criterion_comparisons (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    job_id INTEGER NOT NULL,
+    criterion_a_id INTEGER NOT NULL,
+    criterion_b_id INTEGER NOT NULL,
+    value REAL NOT NULL CHECK (value > 0),
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (job_id) REFERENCES jobs(id) ON DELETE CASCADE,
+    FOREIGN KEY (criterion_a_id) REFERENCES criteria(id) ON DELETE CASCADE,
+    FOREIGN KEY (criterion_b_id) REFERENCES criteria(id) ON DELETE CASCADE,
+    UNIQUE(job_id, criterion_a_id, criterion_b_id),
+    CHECK (criterion_a_id < criterion_b_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_criterion_comparisons_job_id ON criterion_comparisons(job_id);
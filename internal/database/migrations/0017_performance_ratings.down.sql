@@ -0,0 +1,3 @@
+-- Откат миграции: Оценка работы принятых кандидатов
+
+ALTER TABLE applications DROP COLUMN performance_rating;
@@ -0,0 +1,5 @@
+-- Миграция: Оценка работы принятых кандидатов
+-- Описание: После выхода на работу принятому кандидату ставится оценка работы от 1 до 5
+-- (NULL - не оценен). По ней проверяется, предсказывают ли критерии успех в должности
+
+ALTER TABLE applications ADD COLUMN performance_rating INTEGER;
@@ -0,0 +1,310 @@
+package database
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// openTestDB открывает пустую базу во временном каталоге без применения миграций
+func openTestDB(t *testing.T) *DB {
+	t.Helper()
+	t.Chdir(t.TempDir())
+
+	db, err := Open()
+	if errors.Is(err, ErrNoFTS5) {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// schema возвращает определения всех объектов схемы, кроме служебных таблиц SQLite
+func schema(t *testing.T, db *DB) map[string]string {
+	t.Helper()
+
+	rows, err := db.Query("SELECT type || ' ' || name, COALESCE(sql, '') FROM sqlite_master WHERE name NOT LIKE 'sqlite_%'")
+	if err != nil {
+		t.Fatalf("failed to read schema: %v", err)
+	}
+	defer rows.Close()
+
+	objects := make(map[string]string)
+	for rows.Next() {
+		var name, sql string
+		if err := rows.Scan(&name, &sql); err != nil {
+			t.Fatalf("failed to scan schema: %v", err)
+		}
+		objects[name] = sql
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("failed to read schema: %v", err)
+	}
+	return objects
+}
+
+func mustExec(t *testing.T, db *DB, query string, args ...any) {
+	t.Helper()
+	if _, err := db.Exec(query, args...); err != nil {
+		t.Fatalf("failed to exec %q: %v", query, err)
+	}
+}
+
+func TestLoadMigrations(t *testing.T) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		t.Fatalf("loadMigrations() error = %v", err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("loadMigrations() returned no migrations")
+	}
+	for i, migration := range migrations {
+		if migration.Version != i+1 {
+			t.Errorf("migration #%d has version %d, want %d", i+1, migration.Version, i+1)
+		}
+		if migration.Name == "" || migration.Down == "" {
+			t.Errorf("migration %04d_%s has no name or down script", migration.Version, migration.Name)
+		}
+	}
+}
+
+// TestMigrateRoundTrip проверяет, что откат любого числа последних миграций
+// и повторное применение возвращают ту же схему
+func TestMigrateRoundTrip(t *testing.T) {
+	db := openTestDB(t)
+
+	migrations, err := loadMigrations()
+	if err != nil {
+		t.Fatalf("loadMigrations() error = %v", err)
+	}
+	done, err := db.MigrateUp()
+	if err != nil {
+		t.Fatalf("MigrateUp() error = %v", err)
+	}
+	if len(done) != len(migrations) {
+		t.Fatalf("MigrateUp() applied %d migrations, want %d", len(done), len(migrations))
+	}
+	want := schema(t, db)
+
+	for steps := 1; steps <= len(migrations); steps++ {
+		done, err := db.MigrateDown(steps)
+		if err != nil {
+			t.Fatalf("MigrateDown(%d) error = %v", steps, err)
+		}
+		if len(done) != steps || done[0].Version != len(migrations) {
+			t.Fatalf("MigrateDown(%d) rolled back %d migrations starting at %d", steps, len(done), done[0].Version)
+		}
+		if steps == len(migrations) {
+			if got := schema(t, db); !reflect.DeepEqual(got, map[string]string{"table schema_migrations": want["table schema_migrations"]}) {
+				t.Fatalf("schema after full rollback has %d objects, want only schema_migrations", len(got))
+			}
+		}
+
+		done, err = db.MigrateUp()
+		if err != nil {
+			t.Fatalf("MigrateUp() after MigrateDown(%d) error = %v", steps, err)
+		}
+		if len(done) != steps {
+			t.Fatalf("MigrateUp() after MigrateDown(%d) applied %d migrations", steps, len(done))
+		}
+		if got := schema(t, db); !reflect.DeepEqual(got, want) {
+			for name, sql := range want {
+				if got[name] != sql {
+					t.Errorf("%s after MigrateDown(%d) and MigrateUp():\n%s\nwant:\n%s", name, steps, got[name], sql)
+				}
+			}
+			t.Fatalf("schema after MigrateDown(%d) and MigrateUp() differs", steps)
+		}
+	}
+
+	if done, err := db.MigrateUp(); err != nil || len(done) != 0 {
+		t.Errorf("repeated MigrateUp() = %d migrations, %v; want none", len(done), err)
+	}
+}
+
+// TestMigrateDataRoundTrip заполняет базу в схеме второй миграции, обновляет ее до последней
+// версии и откатывает обратно: данные переносятся в новые таблицы и возвращаются без потерь
+func TestMigrateDataRoundTrip(t *testing.T) {
+	db := openTestDB(t)
+
+	migrations, err := loadMigrations()
+	if err != nil {
+		t.Fatalf("loadMigrations() error = %v", err)
+	}
+	if _, err := db.MigrateUp(); err != nil {
+		t.Fatalf("MigrateUp() error = %v", err)
+	}
+	if _, err := db.MigrateDown(len(migrations) - 2); err != nil {
+		t.Fatalf("MigrateDown() error = %v", err)
+	}
+
+	mustExec(t, db, `INSERT INTO jobs (id, title, description, requirements, criteria) VALUES (1, 'Go-разработчик', 'Бэкенд', 'Опыт с SQL', '["Опыт"]')`)
+	mustExec(t, db, `INSERT INTO criteria (id, job_id, name) VALUES (1, 1, 'Опыт')`)
+	mustExec(t, db, `INSERT INTO candidates (id, job_id, name, email, phone, description) VALUES (1, 1, 'Анна', 'anna@example.com', '+7 900 000-00-00', 'Писала микросервисы')`)
+	mustExec(t, db, `INSERT INTO questions (id, job_id, criterion_id, text) VALUES (1, 1, 1, 'Расскажите о проектах')`)
+	mustExec(t, db, `INSERT INTO evaluations (id, candidate_id, criterion_id, score, comments) VALUES (1, 1, 1, 8, 'Сильный опыт')`)
+	mustExec(t, db, `INSERT INTO answers (id, candidate_id, question_id, answer_text) VALUES (1, 1, 1, 'Платежный сервис')`)
+
+	const snapshot = `
+		SELECT j.title, j.description, j.requirements, c.name, c.email, c.phone, c.description,
+			cr.name, q.text, e.score, e.comments, a.answer_text
+		FROM jobs j
+		JOIN candidates c ON c.job_id = j.id
+		JOIN criteria cr ON cr.job_id = j.id
+		JOIN questions q ON q.criterion_id = cr.id
+		JOIN evaluations e ON e.candidate_id = c.id AND e.criterion_id = cr.id
+		JOIN answers a ON a.candidate_id = c.id AND a.question_id = q.id`
+	read := func() []string {
+		t.Helper()
+		values := make([]string, 12)
+		pointers := make([]any, len(values))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := db.QueryRow(snapshot).Scan(pointers...); err != nil {
+			t.Fatalf("failed to read data: %v", err)
+		}
+		return values
+	}
+	want := read()
+
+	if _, err := db.MigrateUp(); err != nil {
+		t.Fatalf("MigrateUp() error = %v", err)
+	}
+	var applications int
+	if err := db.QueryRow("SELECT COUNT(*) FROM applications WHERE job_id = 1 AND candidate_id = 1").Scan(&applications); err != nil || applications != 1 {
+		t.Errorf("applications = %d, %v; want 1", applications, err)
+	}
+	var found int64
+	if err := db.QueryRow("SELECT rowid FROM candidates_fts WHERE candidates_fts MATCH 'микросервис*'").Scan(&found); err != nil || found != 1 {
+		t.Errorf("search found candidate %d, %v; want 1", found, err)
+	}
+
+	if _, err := db.MigrateDown(len(migrations) - 2); err != nil {
+		t.Fatalf("MigrateDown() error = %v", err)
+	}
+	if got := read(); !reflect.DeepEqual(got, want) {
+		t.Errorf("data after round trip = %q, want %q", got, want)
+	}
+}
+
+func TestMigrationStatusLegacy(t *testing.T) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		t.Fatalf("loadMigrations() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		legacy int // Число миграций, скрипты которых выполнены до версионирования
+	}{
+		{name: "empty database", legacy: 0},
+		{name: "initial schema", legacy: 1},
+		{name: "with criteria table", legacy: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openTestDB(t)
+			for _, migration := range migrations[:tt.legacy] {
+				mustExec(t, db, migration.Up)
+			}
+			before := schema(t, db)
+
+			statuses, err := db.MigrationStatus()
+			if err != nil {
+				t.Fatalf("MigrationStatus() error = %v", err)
+			}
+			if len(statuses) != len(migrations) {
+				t.Fatalf("MigrationStatus() returned %d migrations, want %d", len(statuses), len(migrations))
+			}
+			for i, status := range statuses {
+				if status.Applied || status.Legacy != (i < tt.legacy) {
+					t.Errorf("%04d_%s: applied = %v, legacy = %v; want false, %v", status.Version, status.Name, status.Applied, status.Legacy, i < tt.legacy)
+				}
+			}
+			if after := schema(t, db); !reflect.DeepEqual(after, before) {
+				t.Fatal("MigrationStatus() changed the schema")
+			}
+
+			done, err := db.MigrateUp()
+			if err != nil {
+				t.Fatalf("MigrateUp() error = %v", err)
+			}
+			if len(done) != len(migrations)-tt.legacy {
+				t.Errorf("MigrateUp() applied %d migrations, want %d", len(done), len(migrations)-tt.legacy)
+			}
+			statuses, err = db.MigrationStatus()
+			if err != nil {
+				t.Fatalf("MigrationStatus() error = %v", err)
+			}
+			for _, status := range statuses {
+				if !status.Applied || status.Legacy {
+					t.Errorf("%04d_%s after MigrateUp(): applied = %v, legacy = %v", status.Version, status.Name, status.Applied, status.Legacy)
+				}
+			}
+		})
+	}
+}
+
+// TestMigrateShippedDatabase обновляет копию базы из репозитория, созданную до версионирования
+func TestMigrateShippedDatabase(t *testing.T) {
+	source, err := os.Open(filepath.Join("..", "..", DataDir, "choizee.db"))
+	if err != nil {
+		t.Skipf("shipped database is not available: %v", err)
+	}
+	defer source.Close()
+
+	db := openTestDB(t)
+	db.Close()
+	target, err := os.Create(filepath.Join(DataDir, "choizee.db"))
+	if err != nil {
+		t.Fatalf("failed to copy database: %v", err)
+	}
+	if _, err := io.Copy(target, source); err != nil {
+		t.Fatalf("failed to copy database: %v", err)
+	}
+	target.Close()
+
+	db, err = New()
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer db.Close()
+
+	statuses, err := db.MigrationStatus()
+	if err != nil {
+		t.Fatalf("MigrationStatus() error = %v", err)
+	}
+	for _, status := range statuses {
+		if !status.Applied {
+			t.Errorf("%04d_%s is not applied", status.Version, status.Name)
+		}
+	}
+
+	// Каждый кандидат существующей вакансии получает отклик на нее
+	var candidates, applications int
+	err = db.QueryRow(`
+		SELECT
+			(SELECT COUNT(*) FROM candidates WHERE job_id IN (SELECT id FROM jobs)),
+			(SELECT COUNT(*) FROM applications)
+	`).Scan(&candidates, &applications)
+	if err != nil {
+		t.Fatalf("failed to count applications: %v", err)
+	}
+	if applications != candidates || applications == 0 {
+		t.Errorf("applications = %d, want %d", applications, candidates)
+	}
+
+	var integrity string
+	if err := db.QueryRow("PRAGMA integrity_check").Scan(&integrity); err != nil || !strings.EqualFold(integrity, "ok") {
+		t.Errorf("integrity_check = %q, %v", integrity, err)
+	}
+}
@@ -0,0 +1,263 @@
+package export
+
+import (
+	"bytes"
+	"choizee/internal/models"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Виды диаграмм оценок
+const (
+	ChartRadar = "radar" // Радар: ось на каждый критерий, многоугольник на кандидата
+	ChartBar   = "bar"   // Горизонтальные столбцы, сгруппированные по критериям
+)
+
+// Размеры SVG-диаграмм в пикселях
+const (
+	svgWidth        = 640
+	svgPadding      = 20
+	svgHeaderHeight = 56 // Заголовок и подзаголовок
+	svgLegendLine   = 20
+	svgRadius       = 130
+	svgTitleRunes   = 56 // Длиннее заголовок обрезается
+	svgLabelRunes   = 24 // Длиннее подписи осей и критериев обрезаются
+	svgBarLabel     = 200
+	svgBarHeight    = 12
+	svgBarGap       = 4
+)
+
+const svgFont = "Helvetica, Arial, sans-serif"
+
+// svgPalette цвета кандидатов по порядку; первый совпадает с цветом диаграммы в PDF
+var svgPalette = []string{
+	"#3465a4", "#e15759", "#59a14f", "#f28e2b", "#b07aa1",
+	"#76b7b2", "#edc948", "#ff9da7", "#9c755f", "#bab0ac",
+}
+
+// ComparisonSVG рисует средние оценки кандидатов из сравнения самостоятельной SVG-картинкой
+// без скриптов и внешних стилей, чтобы ее можно было вставить в письмо, PDF или Markdown.
+// Кандидаты накладываются друг на друга разными цветами с легендой. Радару нужно
+// хотя бы три критерия, при меньшем числе рисуются столбцы
+func ComparisonSVG(w io.Writer, comparison *models.CandidateComparison, kind string) error {
+	var b bytes.Buffer
+	if kind == ChartRadar && len(comparison.Criteria) < 3 {
+		kind = ChartBar
+	}
+
+	var height int
+	switch {
+	case len(comparison.Criteria) == 0 || len(comparison.Candidates) == 0:
+		height = svgHeaderHeight + 40
+	case kind == ChartRadar:
+		height = svgHeaderHeight + 2*svgRadius + 80 + len(comparison.Candidates)*svgLegendLine
+	default:
+		height = svgHeaderHeight + 44 + len(comparison.Criteria)*barGroupHeight(comparison) + len(comparison.Candidates)*svgLegendLine
+	}
+
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="%s">`+"\n",
+		svgWidth, height, svgWidth, height, svgFont)
+	fmt.Fprintf(&b, `<rect width="100%%" height="100%%" fill="#ffffff"/>`+"\n")
+	svgHeader(&b, comparison)
+
+	switch {
+	case len(comparison.Criteria) == 0:
+		svgNote(&b, "У вакансии нет критериев оценки")
+	case len(comparison.Candidates) == 0:
+		svgNote(&b, "На вакансию еще нет откликов")
+	case kind == ChartRadar:
+		svgRadar(&b, comparison)
+		svgLegend(&b, comparison, svgHeaderHeight+2*svgRadius+70)
+	default:
+		bottom := svgBars(&b, comparison)
+		svgLegend(&b, comparison, bottom+10)
+	}
+
+	b.WriteString("</svg>\n")
+	_, err := b.WriteTo(w)
+	return err
+}
+
+// svgHeader выводит заголовок: имя кандидата и вакансию или только вакансию для нескольких кандидатов
+func svgHeader(b *bytes.Buffer, comparison *models.CandidateComparison) {
+	title := comparison.JobTitle
+	if len(comparison.Candidates) == 1 {
+		title = comparison.Candidates[0].CandidateName + " — " + comparison.JobTitle
+	}
+	fmt.Fprintf(b, `<title>%s</title>`+"\n", escapeXML(title))
+	fmt.Fprintf(b, `<text x="%d" y="28" font-size="18" font-weight="bold" fill="#222222">%s</text>`+"\n",
+		svgPadding, escapeXML(shorten(title, svgTitleRunes)))
+	fmt.Fprintf(b, `<text x="%d" y="46" font-size="12" fill="#787878">Средние оценки интервьюеров, шкала %d–%d</text>`+"\n",
+		svgPadding, comparison.Scale.Min, comparison.Scale.Max)
+}
+
+// svgNote выводит пояснение вместо диаграммы, когда рисовать нечего
+func svgNote(b *bytes.Buffer, text string) {
+	fmt.Fprintf(b, `<text x="%d" y="%d" font-size="13" fill="#222222">%s</text>`+"\n",
+		svgPadding, svgHeaderHeight+24, escapeXML(text))
+}
+
+// svgRadar рисует кольца уровней шкалы, оси критериев и многоугольник каждого кандидата.
+// Неоцененный критерий откладывается в центр, а точка на нем не ставится
+func svgRadar(b *bytes.Buffer, comparison *models.CandidateComparison) {
+	scale := comparison.Scale
+	span := float64(scale.Max - scale.Min)
+	n := len(comparison.Criteria)
+	cx, cy := float64(svgWidth)/2, float64(svgHeaderHeight+svgRadius+30)
+
+	point := func(i int, r float64) (float64, float64) {
+		angle := -math.Pi/2 + 2*math.Pi*float64(i)/float64(n)
+		return cx + r*math.Cos(angle), cy + r*math.Sin(angle)
+	}
+	polygon := func(ratio func(i int) float64) string {
+		var points bytes.Buffer
+		for i := 0; i < n; i++ {
+			x, y := point(i, svgRadius*ratio(i))
+			fmt.Fprintf(&points, "%.1f,%.1f ", x, y)
+		}
+		return string(bytes.TrimSpace(points.Bytes()))
+	}
+
+	// Кольца уровней шкалы: не больше пяти, как в PDF
+	step := 1
+	for (scale.Max-scale.Min)/step > 5 {
+		step++
+	}
+	for value := scale.Max; value > scale.Min && span > 0; value -= step {
+		ratio := float64(value-scale.Min) / span
+		fmt.Fprintf(b, `<polygon points="%s" fill="none" stroke="#cccccc" stroke-width="1"/>`+"\n",
+			polygon(func(int) float64 { return ratio }))
+		fmt.Fprintf(b, `<text x="%.1f" y="%.1f" font-size="10" fill="#787878">%d</text>`+"\n",
+			cx+3, cy-svgRadius*ratio-2, value)
+	}
+
+	for i, criterion := range comparison.Criteria {
+		x, y := point(i, svgRadius)
+		fmt.Fprintf(b, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="#cccccc" stroke-width="1"/>`+"\n", cx, cy, x, y)
+
+		// Подпись снаружи кольца, выровненная от центра
+		lx, ly := point(i, svgRadius+10)
+		anchor := "middle"
+		switch {
+		case lx-cx > 1:
+			anchor = "start"
+		case lx-cx < -1:
+			anchor = "end"
+		}
+		switch {
+		case ly-cy > 1:
+			ly += 10
+		case ly-cy < -1:
+			ly -= 2
+		default:
+			ly += 4
+		}
+		fmt.Fprintf(b, `<text x="%.1f" y="%.1f" font-size="12" fill="#222222" text-anchor="%s"><title>%s</title>%s</text>`+"\n",
+			lx, ly, anchor, escapeXML(criterion.CriterionName), escapeXML(shorten(criterion.CriterionName, svgLabelRunes)))
+	}
+
+	for j := range comparison.Candidates {
+		if !evaluated(comparison, j) || span <= 0 {
+			continue
+		}
+		color := svgPalette[j%len(svgPalette)]
+		ratio := func(i int) float64 {
+			mean := comparison.Criteria[i].Cells[j].Mean
+			if mean == nil {
+				return 0
+			}
+			return math.Max(0, math.Min(1, (*mean-float64(scale.Min))/span))
+		}
+		fmt.Fprintf(b, `<polygon points="%s" fill="%s" fill-opacity="0.2" stroke="%s" stroke-width="2"/>`+"\n",
+			polygon(ratio), color, color)
+		for i := range comparison.Criteria {
+			if comparison.Criteria[i].Cells[j].Mean == nil {
+				continue
+			}
+			x, y := point(i, svgRadius*ratio(i))
+			fmt.Fprintf(b, `<circle cx="%.1f" cy="%.1f" r="3" fill="%s"/>`+"\n", x, y, color)
+		}
+	}
+}
+
+// barGroupHeight высота группы столбцов одного критерия: подпись и столбец на кандидата
+func barGroupHeight(comparison *models.CandidateComparison) int {
+	return len(comparison.Candidates)*(svgBarHeight+svgBarGap) + 12
+}
+
+// svgBars рисует столбцы кандидатов по каждому критерию от нижней границы шкалы
+// с сеткой уровней шкалы. Возвращает координату под последней группой
+func svgBars(b *bytes.Buffer, comparison *models.CandidateComparison) int {
+	scale := comparison.Scale
+	span := float64(scale.Max - scale.Min)
+	left := svgPadding + svgBarLabel
+	width := float64(svgWidth - left - svgPadding - 40) // Справа место под значение
+	top := svgHeaderHeight + 24
+	bottom := top + len(comparison.Criteria)*barGroupHeight(comparison)
+
+	step := 1
+	for (scale.Max-scale.Min)/step > 10 {
+		step++
+	}
+	for value := scale.Min; value <= scale.Max && span > 0; value += step {
+		x := float64(left) + width*float64(value-scale.Min)/span
+		fmt.Fprintf(b, `<line x1="%.1f" y1="%d" x2="%.1f" y2="%d" stroke="#e5e5e5" stroke-width="1"/>`+"\n", x, top-6, x, bottom)
+		fmt.Fprintf(b, `<text x="%.1f" y="%d" font-size="10" fill="#787878" text-anchor="middle">%d</text>`+"\n", x, top-10, value)
+	}
+
+	y := top
+	for _, criterion := range comparison.Criteria {
+		fmt.Fprintf(b, `<text x="%d" y="%d" font-size="12" fill="#222222"><title>%s</title>%s</text>`+"\n",
+			svgPadding, y+svgBarHeight, escapeXML(criterion.CriterionName), escapeXML(shorten(criterion.CriterionName, svgLabelRunes)))
+		for j, cell := range criterion.Cells {
+			color := svgPalette[j%len(svgPalette)]
+			if cell.Mean == nil || span <= 0 {
+				fmt.Fprintf(b, `<text x="%d" y="%d" font-size="10" fill="#787878">нет оценки</text>`+"\n", left+4, y+svgBarHeight-2)
+			} else {
+				ratio := math.Max(0, math.Min(1, (*cell.Mean-float64(scale.Min))/span))
+				fmt.Fprintf(b, `<rect x="%d" y="%d" width="%.1f" height="%d" fill="%s"/>`+"\n", left, y, width*ratio, svgBarHeight, color)
+				fmt.Fprintf(b, `<text x="%.1f" y="%d" font-size="10" fill="#222222">%.1f</text>`+"\n",
+					float64(left)+width*ratio+4, y+svgBarHeight-2, *cell.Mean)
+			}
+			y += svgBarHeight + svgBarGap
+		}
+		y += 12
+	}
+	return y
+}
+
+// svgLegend выводит цвет, имя и итоговую оценку каждого кандидата начиная с top
+func svgLegend(b *bytes.Buffer, comparison *models.CandidateComparison, top int) {
+	for j, candidate := range comparison.Candidates {
+		y := top + j*svgLegendLine
+		total := "нет оценок"
+		if evaluated(comparison, j) {
+			total = fmt.Sprintf("%.2f (%.0f%%)", candidate.WeightedScore, candidate.NormalizedScore)
+		}
+		if candidate.KnockoutFailed {
+			total += ", не прошел отсев"
+		}
+		fmt.Fprintf(b, `<rect x="%d" y="%d" width="12" height="12" fill="%s"/>`+"\n", svgPadding, y, svgPalette[j%len(svgPalette)])
+		fmt.Fprintf(b, `<text x="%d" y="%d" font-size="12" fill="#222222">%s <tspan fill="#787878">— %s</tspan></text>`+"\n",
+			svgPadding+18, y+11, escapeXML(shorten(candidate.CandidateName, svgTitleRunes)), escapeXML(total))
+	}
+}
+
+// evaluated сообщает, есть ли у j-го кандидата сравнения хотя бы одна оценка
+func evaluated(comparison *models.CandidateComparison, j int) bool {
+	for _, criterion := range comparison.Criteria {
+		if criterion.Cells[j].Mean != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// shorten обрезает подпись до limit символов: ширину текста в SVG без шрифта не измерить
+func shorten(s string, limit int) string {
+	if runes := []rune(s); len(runes) > limit {
+		return string(runes[:limit-1]) + "…"
+	}
+	return s
+}
@@ -0,0 +1,12 @@
+package export
+
+import (
+	"choizee/internal/models"
+	"io"
+)
+
+// CompareHTML выводит сравнение кандидатов одной HTML-страницей, готовой к печати
+// или сохранению в PDF из браузера
+func CompareHTML(w io.Writer, comparison *models.CandidateComparison) error {
+	return templates.ExecuteTemplate(w, "compare.html", comparison)
+}
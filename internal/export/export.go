@@ -0,0 +1,39 @@
+// Package export формирует документы для печати и выгрузки из данных сервисов
+package export
+
+import (
+	"choizee/internal/models"
+	"embed"
+	"fmt"
+	"html/template"
+)
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+var templates = template.Must(template.New("").Funcs(template.FuncMap{
+	"score": formatScore,
+	"delta": formatDelta,
+	"best":  isBest,
+}).ParseFS(templateFS, "templates/*.html"))
+
+// formatScore выводит среднюю оценку или прочерк, если оценки нет
+func formatScore(v *float64) string {
+	if v == nil {
+		return "—"
+	}
+	return fmt.Sprintf("%.1f", *v)
+}
+
+// formatDelta выводит отставание от лучшей оценки; у лучшего кандидата и без оценки - пусто
+func formatDelta(v *float64) string {
+	if v == nil || *v == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%+.1f", *v)
+}
+
+// isBest сообщает, что у кандидата лучшая средняя оценка по критерию
+func isBest(cell models.ComparisonCell) bool {
+	return cell.DeltaFromBest != nil && *cell.DeltaFromBest == 0
+}
@@ -0,0 +1,188 @@
+package export
+
+import (
+	"choizee/internal/models"
+	"fmt"
+	"math"
+
+	"github.com/go-pdf/fpdf"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+// Шрифты Go встроены в бинарник, поэтому кириллица в PDF не зависит от шрифтов системы
+const (
+	pdfFont   = "go"
+	pdfMargin = 15.0 // Поля страницы, мм
+	pdfLine   = 5.0  // Высота строки основного текста, мм
+)
+
+type pdfColor struct{ r, g, b int }
+
+var (
+	colorText     = pdfColor{34, 34, 34}
+	colorMuted    = pdfColor{120, 120, 120}
+	colorBorder   = pdfColor{204, 204, 204}
+	colorHeader   = pdfColor{243, 243, 243}
+	colorBest     = pdfColor{227, 244, 225}
+	colorKnockout = pdfColor{179, 51, 51}
+	colorChart    = pdfColor{52, 101, 164}
+)
+
+// newPDF создает документ A4 со встроенными шрифтами и нумерацией страниц в подвале
+func newPDF(title string) *fpdf.Fpdf {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.AddUTF8FontFromBytes(pdfFont, "", goregular.TTF)
+	pdf.AddUTF8FontFromBytes(pdfFont, "B", gobold.TTF)
+	pdf.SetMargins(pdfMargin, pdfMargin, pdfMargin)
+	pdf.SetAutoPageBreak(true, pdfMargin)
+	pdf.SetTitle(title, true)
+	pdf.SetCreator("Choizee", true)
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-10)
+		pdf.SetFont(pdfFont, "", 8)
+		setTextColor(pdf, colorMuted)
+		footer := fmt.Sprintf("стр. %d", pdf.PageNo())
+		footer = truncate(pdf, title, contentWidth(pdf)-pdf.GetStringWidth(footer)-10) + " · " + footer
+		pdf.CellFormat(0, 4, footer, "", 0, "C", false, 0, "")
+	})
+	return pdf
+}
+
+func setTextColor(pdf *fpdf.Fpdf, c pdfColor) { pdf.SetTextColor(c.r, c.g, c.b) }
+func setFillColor(pdf *fpdf.Fpdf, c pdfColor) { pdf.SetFillColor(c.r, c.g, c.b) }
+func setDrawColor(pdf *fpdf.Fpdf, c pdfColor) { pdf.SetDrawColor(c.r, c.g, c.b) }
+
+// contentWidth возвращает ширину страницы между полями
+func contentWidth(pdf *fpdf.Fpdf) float64 {
+	width, _ := pdf.GetPageSize()
+	left, _, right, _ := pdf.GetMargins()
+	return width - left - right
+}
+
+// ensureSpace переносит вывод на новую страницу, если до нижнего поля осталось меньше height.
+// Возвращает true, если страница добавлена
+func ensureSpace(pdf *fpdf.Fpdf, height float64) bool {
+	_, pageHeight := pdf.GetPageSize()
+	if pdf.GetY()+height <= pageHeight-pdfMargin {
+		return false
+	}
+	orientation := "P"
+	if width, _ := pdf.GetPageSize(); width > pageHeight {
+		orientation = "L"
+	}
+	pdf.AddPageFormat(orientation, pdf.GetPageSizeStr("A4"))
+	return true
+}
+
+// truncate укорачивает строку с многоточием, чтобы она поместилась в width текущим шрифтом
+func truncate(pdf *fpdf.Fpdf, s string, width float64) string {
+	if pdf.GetStringWidth(s) <= width {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && pdf.GetStringWidth(string(runes)+"…") > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "…"
+}
+
+// heading выводит заголовок раздела, не отрывая его от начала раздела
+func heading(pdf *fpdf.Fpdf, text string) {
+	ensureSpace(pdf, 20)
+	pdf.Ln(4)
+	pdf.SetFont(pdfFont, "B", 12)
+	setTextColor(pdf, colorText)
+	pdf.CellFormat(0, 7, text, "", 1, "L", false, 0, "")
+	pdf.Ln(1)
+}
+
+// radarChart рисует радар-диаграмму средних оценок из summary.ChartData:
+// ось на каждый оцененный критерий, кольца - уровни шкалы вакансии
+func radarChart(pdf *fpdf.Fpdf, summary models.EvaluationSummary) {
+	const radius = 32.0
+
+	n := len(summary.Criteria)
+	span := float64(summary.Scale.Max - summary.Scale.Min)
+	if n < 3 || span <= 0 {
+		return
+	}
+
+	ensureSpace(pdf, 2*radius+20)
+	left, _, _, _ := pdf.GetMargins()
+	cx := left + contentWidth(pdf)/2
+	cy := pdf.GetY() + radius + 8
+
+	point := func(i int, r float64) fpdf.PointType {
+		angle := -math.Pi/2 + 2*math.Pi*float64(i)/float64(n)
+		return fpdf.PointType{X: cx + r*math.Cos(angle), Y: cy + r*math.Sin(angle)}
+	}
+	polygon := func(ratio func(i int) float64) []fpdf.PointType {
+		points := make([]fpdf.PointType, n)
+		for i := range points {
+			points[i] = point(i, radius*ratio(i))
+		}
+		return points
+	}
+
+	// Кольца уровней шкалы: не больше пяти, чтобы сетка не сливалась
+	step := 1
+	for (summary.Scale.Max-summary.Scale.Min)/step > 5 {
+		step++
+	}
+	pdf.SetLineWidth(0.2)
+	setDrawColor(pdf, colorBorder)
+	pdf.SetFont(pdfFont, "", 7)
+	setTextColor(pdf, colorMuted)
+	for value := summary.Scale.Max; value > summary.Scale.Min; value -= step {
+		ratio := float64(value-summary.Scale.Min) / span
+		pdf.Polygon(polygon(func(int) float64 { return ratio }), "D")
+		pdf.Text(cx+1, cy-radius*ratio-0.5, fmt.Sprintf("%d", value))
+	}
+	for i := 0; i < n; i++ {
+		end := point(i, radius)
+		pdf.Line(cx, cy, end.X, end.Y)
+	}
+
+	// Подписи осей снаружи кольца, выровненные от центра
+	pdf.SetFont(pdfFont, "", 8)
+	setTextColor(pdf, colorText)
+	for i, criterion := range summary.Criteria {
+		label := truncate(pdf, criterion.CriterionName, 45)
+		width := pdf.GetStringWidth(label)
+		at := point(i, radius+3)
+		dx, dy := at.X-cx, at.Y-cy
+		x, y := at.X-width/2, at.Y+1
+		switch {
+		case dx > 1:
+			x = at.X
+		case dx < -1:
+			x = at.X - width
+		}
+		switch {
+		case dy < -1:
+			y = at.Y
+		case dy > 1:
+			y = at.Y + 2.5
+		}
+		pdf.Text(x, y, label)
+	}
+
+	scores := polygon(func(i int) float64 {
+		ratio := (summary.ChartData[summary.Criteria[i].CriterionName] - float64(summary.Scale.Min)) / span
+		return math.Max(0, math.Min(1, ratio))
+	})
+	setFillColor(pdf, colorChart)
+	setDrawColor(pdf, colorChart)
+	pdf.SetAlpha(0.25, "Normal")
+	pdf.Polygon(scores, "F")
+	pdf.SetAlpha(1, "Normal")
+	pdf.SetLineWidth(0.6)
+	pdf.Polygon(scores, "D")
+	for _, p := range scores {
+		pdf.Circle(p.X, p.Y, 0.8, "F")
+	}
+
+	pdf.SetLineWidth(0.2)
+	pdf.SetY(cy + radius + 10)
+}
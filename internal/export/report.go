@@ -0,0 +1,263 @@
+package export
+
+import (
+	"choizee/internal/models"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// CandidatePDF выводит карточку кандидата PDF-документом: данные кандидата,
+// радар-диаграмму, оценки по критериям с комментариями и ответы на вопросы
+func CandidatePDF(w io.Writer, report *models.CandidateReport) error {
+	pdf := newPDF(fmt.Sprintf("%s — %s", report.Candidate.Name, report.Summary.JobTitle))
+	pdf.AddPage()
+	scorecard(pdf, report)
+	return pdf.Output(w)
+}
+
+// JobPDF выводит отчет по вакансии: таблицу сравнения всех кандидатов
+// на альбомной странице и карточку каждого кандидата с новой страницы
+func JobPDF(w io.Writer, report *models.JobReport) error {
+	pdf := newPDF(report.Comparison.JobTitle)
+	pdf.AddPageFormat("L", pdf.GetPageSizeStr("A4"))
+	comparisonTable(pdf, &report.Comparison)
+	for i := range report.Candidates {
+		pdf.AddPageFormat("P", pdf.GetPageSizeStr("A4"))
+		scorecard(pdf, &report.Candidates[i])
+	}
+	return pdf.Output(w)
+}
+
+// scorecard выводит карточку кандидата с текущей позиции
+func scorecard(pdf *fpdf.Fpdf, report *models.CandidateReport) {
+	summary := report.Summary
+
+	pdf.SetFont(pdfFont, "B", 16)
+	setTextColor(pdf, colorText)
+	pdf.MultiCell(0, 8, report.Candidate.Name, "", "L", false)
+	pdf.Ln(1)
+
+	field(pdf, "Вакансия", summary.JobTitle)
+	field(pdf, "Этап", report.StageName)
+	field(pdf, "Email", report.Candidate.Email)
+	field(pdf, "Телефон", report.Candidate.Phone)
+	field(pdf, "Отклик", report.Application.CreatedAt.Format("02.01.2006"))
+	if report.Candidate.Description != "" {
+		pdf.Ln(1)
+		pdf.SetFont(pdfFont, "", 10)
+		pdf.MultiCell(0, pdfLine, report.Candidate.Description, "", "L", false)
+	}
+
+	heading(pdf, "Оценки по критериям")
+	if len(summary.Criteria) == 0 {
+		pdf.SetFont(pdfFont, "", 10)
+		setTextColor(pdf, colorMuted)
+		pdf.CellFormat(0, pdfLine, "Кандидата еще не оценивали", "", 1, "L", false, 0, "")
+	} else {
+		pdf.SetFont(pdfFont, "B", 10)
+		pdf.CellFormat(0, pdfLine, fmt.Sprintf("Взвешенная оценка: %.2f из %d (%.0f%%)",
+			summary.WeightedScore, summary.Scale.Max, summary.NormalizedScore), "", 1, "L", false, 0, "")
+		pdf.SetFont(pdfFont, "", 10)
+		pdf.MultiCell(0, pdfLine, "Интервьюеры: "+strings.Join(summary.Evaluators, ", "), "", "L", false)
+		if summary.KnockoutFailed {
+			failures := make([]string, len(summary.Knockouts))
+			for i, knockout := range summary.Knockouts {
+				failures[i] = fmt.Sprintf("%s (%.1f при минимуме %d)", knockout.CriterionName, knockout.Score, knockout.MinScore)
+			}
+			setTextColor(pdf, colorKnockout)
+			pdf.MultiCell(0, pdfLine, "Не пройден отсев: "+strings.Join(failures, ", "), "", "L", false)
+			setTextColor(pdf, colorText)
+		}
+
+		pdf.Ln(2)
+		radarChart(pdf, summary)
+		for _, criterion := range summary.Criteria {
+			criterionScores(pdf, criterion, summary.Scale)
+		}
+	}
+
+	if len(report.Questions) > 0 {
+		heading(pdf, "Ответы на вопросы")
+		for _, question := range report.Questions {
+			ensureSpace(pdf, 3*pdfLine)
+			pdf.SetFont(pdfFont, "B", 10)
+			setTextColor(pdf, colorText)
+			pdf.MultiCell(0, pdfLine, question.Text, "", "L", false)
+			pdf.SetFont(pdfFont, "", 8)
+			setTextColor(pdf, colorMuted)
+			pdf.CellFormat(0, 4, question.CriterionName, "", 1, "L", false, 0, "")
+			pdf.SetFont(pdfFont, "", 10)
+			if question.AnswerText == "" {
+				pdf.CellFormat(0, pdfLine, "нет ответа", "", 1, "L", false, 0, "")
+			} else {
+				setTextColor(pdf, colorText)
+				pdf.MultiCell(0, pdfLine, question.AnswerText, "", "L", false)
+			}
+			pdf.Ln(2)
+		}
+	}
+}
+
+// field выводит строку "подпись: значение"; пустые значения пропускаются
+func field(pdf *fpdf.Fpdf, label, value string) {
+	if value == "" {
+		return
+	}
+	pdf.SetFont(pdfFont, "", 10)
+	setTextColor(pdf, colorMuted)
+	pdf.CellFormat(25, pdfLine, label, "", 0, "L", false, 0, "")
+	setTextColor(pdf, colorText)
+	pdf.MultiCell(0, pdfLine, value, "", "L", false)
+}
+
+// criterionScores выводит среднюю оценку по критерию и оценки интервьюеров с комментариями
+func criterionScores(pdf *fpdf.Fpdf, criterion models.CriterionScoreSummary, scale models.ScoringScale) {
+	const scoreWidth = 40.0
+
+	ensureSpace(pdf, 6+2*pdfLine)
+	left, _, _, _ := pdf.GetMargins()
+	width := contentWidth(pdf)
+
+	pdf.SetFont(pdfFont, "B", 10)
+	setTextColor(pdf, colorText)
+	setFillColor(pdf, colorHeader)
+	name := truncate(pdf, fmt.Sprintf("%s ×%g", criterion.CriterionName, criterion.Weight), width-scoreWidth-4)
+	pdf.CellFormat(width-scoreWidth, 6, name, "", 0, "L", true, 0, "")
+	pdf.CellFormat(scoreWidth, 6, fmt.Sprintf("%.1f (%.0f%%)", criterion.Mean, criterion.Normalized), "", 1, "R", true, 0, "")
+
+	pdf.SetFont(pdfFont, "", 9)
+	if criterion.Disagreement {
+		setTextColor(pdf, colorKnockout)
+		pdf.SetX(left + 4)
+		pdf.CellFormat(0, pdfLine, fmt.Sprintf("Интервьюеры расходятся: оценки от %d до %d", criterion.Min, criterion.Max), "", 1, "L", false, 0, "")
+	}
+	setTextColor(pdf, colorText)
+	for _, score := range criterion.Scores {
+		line := fmt.Sprintf("%s: %s", score.Evaluator, scoreLabel(scale, score.Score))
+		if score.Comments != "" {
+			line += " — " + score.Comments
+		}
+		pdf.SetX(left + 4)
+		pdf.MultiCell(width-4, pdfLine, line, "", "L", false)
+	}
+	pdf.Ln(2)
+}
+
+// scoreLabel выводит оценку с подписью уровня шкалы, если она у шкалы есть
+func scoreLabel(scale models.ScoringScale, score int) string {
+	for _, level := range scale.Levels {
+		if level.Value == score && level.Label != "" {
+			return fmt.Sprintf("%d (%s)", score, level.Label)
+		}
+	}
+	return fmt.Sprintf("%d", score)
+}
+
+// comparisonTable выводит кандидатов строками, а средние оценки по критериям - столбцами;
+// лучшая оценка по критерию выделена. Шапка повторяется на каждой странице таблицы
+func comparisonTable(pdf *fpdf.Fpdf, comparison *models.CandidateComparison) {
+	const (
+		rankWidth   = 8.0
+		nameWidth   = 50.0
+		stageWidth  = 30.0
+		totalWidth  = 28.0
+		winsWidth   = 18.0
+		headerLine  = 4.0
+		rowHeight   = 6.0
+		cellPadding = 1.0
+	)
+
+	pdf.SetFont(pdfFont, "B", 16)
+	setTextColor(pdf, colorText)
+	pdf.MultiCell(0, 8, "Сравнение кандидатов", "", "L", false)
+	pdf.SetFont(pdfFont, "", 10)
+	setTextColor(pdf, colorMuted)
+	pdf.MultiCell(0, pdfLine, fmt.Sprintf("Вакансия: %s · шкала %d–%d · кандидатов: %d",
+		comparison.JobTitle, comparison.Scale.Min, comparison.Scale.Max, len(comparison.Candidates)), "", "L", false)
+	pdf.Ln(3)
+
+	if len(comparison.Candidates) == 0 {
+		setTextColor(pdf, colorText)
+		pdf.CellFormat(0, pdfLine, "На вакансию еще нет откликов", "", 1, "L", false, 0, "")
+		return
+	}
+
+	left, _, _, _ := pdf.GetMargins()
+	headers := []string{"#", "Кандидат", "Этап"}
+	widths := []float64{rankWidth, nameWidth, stageWidth}
+	criterionWidth := 0.0
+	if len(comparison.Criteria) > 0 {
+		criterionWidth = (contentWidth(pdf) - rankWidth - nameWidth - stageWidth - totalWidth - winsWidth) / float64(len(comparison.Criteria))
+	}
+	for _, criterion := range comparison.Criteria {
+		headers = append(headers, fmt.Sprintf("%s ×%g", criterion.CriterionName, criterion.Weight))
+		widths = append(widths, criterionWidth)
+	}
+	headers = append(headers, "Взвешенная", "Лучший по")
+	widths = append(widths, totalWidth, winsWidth)
+
+	setDrawColor(pdf, colorBorder)
+	pdf.SetLineWidth(0.2)
+
+	// Шапка высотой в самый длинный перенесенный заголовок
+	header := func() {
+		pdf.SetFont(pdfFont, "B", 8)
+		setTextColor(pdf, colorText)
+		setFillColor(pdf, colorHeader)
+		lines := make([][]string, len(headers))
+		height := 0.0
+		for i, text := range headers {
+			lines[i] = pdf.SplitText(text, widths[i]-2*cellPadding)
+			height = max(height, float64(len(lines[i]))*headerLine+2*cellPadding)
+		}
+		x, y := left, pdf.GetY()
+		for i := range headers {
+			pdf.Rect(x, y, widths[i], height, "FD")
+			for j, line := range lines[i] {
+				pdf.Text(x+cellPadding, y+cellPadding+float64(j+1)*headerLine-1, line)
+			}
+			x += widths[i]
+		}
+		pdf.SetXY(left, y+height)
+	}
+
+	header()
+	for i, candidate := range comparison.Candidates {
+		if ensureSpace(pdf, rowHeight) {
+			header()
+		}
+
+		pdf.SetFont(pdfFont, "", 9)
+		setTextColor(pdf, colorText)
+		pdf.CellFormat(rankWidth, rowHeight, fmt.Sprintf("%d", i+1), "1", 0, "R", false, 0, "")
+		if candidate.KnockoutFailed {
+			setTextColor(pdf, colorKnockout)
+		}
+		pdf.CellFormat(nameWidth, rowHeight, truncate(pdf, candidate.CandidateName, nameWidth-2*cellPadding), "1", 0, "L", false, 0, "")
+		setTextColor(pdf, colorText)
+		pdf.CellFormat(stageWidth, rowHeight, truncate(pdf, candidate.Stage, stageWidth-2*cellPadding), "1", 0, "L", false, 0, "")
+
+		setFillColor(pdf, colorBest)
+		for _, criterion := range comparison.Criteria {
+			cell := criterion.Cells[i]
+			if isBest(cell) {
+				pdf.SetFont(pdfFont, "B", 9)
+			}
+			pdf.CellFormat(criterionWidth, rowHeight, formatScore(cell.Mean), "1", 0, "R", isBest(cell), 0, "")
+			pdf.SetFont(pdfFont, "", 9)
+		}
+		pdf.CellFormat(totalWidth, rowHeight, fmt.Sprintf("%.2f (%.0f%%)", candidate.WeightedScore, candidate.NormalizedScore), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(winsWidth, rowHeight, fmt.Sprintf("%d", candidate.Wins), "1", 1, "R", false, 0, "")
+	}
+
+	if len(comparison.Criteria) > 0 {
+		pdf.Ln(2)
+		pdf.SetFont(pdfFont, "", 8)
+		setTextColor(pdf, colorMuted)
+		pdf.MultiCell(0, 4, "Кандидаты идут в порядке сводки; в столбцах критериев - средние оценки интервьюеров, лучшая выделена. "+
+			"«Лучший по» - число критериев, по которым у кандидата лучшая оценка. Красным отмечены не прошедшие отсев.", "", "L", false)
+	}
+}
@@ -0,0 +1,154 @@
+package export
+
+import (
+	"choizee/internal/models"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// JobXLSX выгружает оценки вакансии книгой Excel из трех листов: матрица кандидатов
+// по критериям в порядке сводки, все оценки интервьюеров с комментариями и ответы на вопросы.
+// Оценки подсвечены от красного (низ шкалы) до зеленого (верх шкалы)
+func JobXLSX(w io.Writer, report *models.JobReport) error {
+	return writeXLSX(w, []*xlsxSheet{
+		summarySheet(report),
+		evaluationsSheet(report),
+		answersSheet(report),
+	})
+}
+
+// summarySheet - матрица "кандидат × критерий" со средними оценками и итогами сводки
+func summarySheet(report *models.JobReport) *xlsxSheet {
+	comparison := report.Comparison
+	scale := comparison.Scale
+	sheet := &xlsxSheet{name: "Сводка", frozenRows: 1, frozenCols: 2, filter: true}
+
+	header := []xlsxCell{cell("Место", xlsxStyleHeader), cell("Кандидат", xlsxStyleHeader), cell("Этап", xlsxStyleHeader)}
+	sheet.widths = []float64{7, 28, 16}
+	for _, criterion := range comparison.Criteria {
+		header = append(header, cell(fmt.Sprintf("%s ×%g", criterion.CriterionName, criterion.Weight), xlsxStyleHeader))
+		sheet.widths = append(sheet.widths, 14)
+	}
+	header = append(header,
+		cell("Средняя", xlsxStyleHeader),
+		cell("Взвешенная", xlsxStyleHeader),
+		cell("% шкалы", xlsxStyleHeader),
+		cell("Лучший по критериям", xlsxStyleHeader),
+		cell("Отсев", xlsxStyleHeader),
+	)
+	sheet.widths = append(sheet.widths, 11, 12, 10, 12, 36)
+	sheet.addRow(header...)
+
+	for i, candidate := range comparison.Candidates {
+		summary := report.Candidates[i].Summary
+
+		nameStyle := xlsxStyleDefault
+		if candidate.KnockoutFailed {
+			nameStyle = xlsxStyleKnockout
+		}
+		row := []xlsxCell{
+			cell(i+1, xlsxStyleInteger),
+			cell(candidate.CandidateName, nameStyle),
+			cell(candidate.Stage, xlsxStyleDefault),
+		}
+		for _, criterion := range comparison.Criteria {
+			if mean := criterion.Cells[i].Mean; mean != nil {
+				row = append(row, cell(*mean, xlsxStyleScore))
+			} else {
+				row = append(row, cell(nil, xlsxStyleScore))
+			}
+		}
+
+		if len(summary.Criteria) == 0 {
+			row = append(row, cell(nil, xlsxStyleScore), cell(nil, xlsxStyleScore), cell(nil, xlsxStyleInteger))
+		} else {
+			row = append(row,
+				cell(summary.AverageScore, xlsxStyleScore),
+				cell(summary.WeightedScore, xlsxStyleScore),
+				cell(summary.NormalizedScore, xlsxStyleInteger),
+			)
+		}
+		row = append(row, cell(candidate.Wins, xlsxStyleInteger))
+
+		failures := make([]string, len(summary.Knockouts))
+		for j, knockout := range summary.Knockouts {
+			failures[j] = fmt.Sprintf("%s (%.1f при минимуме %d)", knockout.CriterionName, knockout.Score, knockout.MinScore)
+		}
+		row = append(row, cell(strings.Join(failures, ", "), xlsxStyleKnockout))
+		sheet.addRow(row...)
+	}
+
+	scores := 3 + len(comparison.Criteria)
+	sheet.colorScale(3, scores+1, float64(scale.Min), float64(scale.Max))
+	sheet.colorScale(scores+2, scores+2, 0, 100)
+	return sheet
+}
+
+// evaluationsSheet - каждая оценка каждого интервьюера с комментарием
+func evaluationsSheet(report *models.JobReport) *xlsxSheet {
+	scale := report.Comparison.Scale
+	sheet := &xlsxSheet{
+		name:       "Оценки интервьюеров",
+		widths:     []float64{28, 28, 20, 9, 60, 17},
+		frozenRows: 1,
+		filter:     true,
+	}
+	sheet.addRow(
+		cell("Кандидат", xlsxStyleHeader),
+		cell("Критерий", xlsxStyleHeader),
+		cell("Интервьюер", xlsxStyleHeader),
+		cell("Оценка", xlsxStyleHeader),
+		cell("Комментарий", xlsxStyleHeader),
+		cell("Изменена", xlsxStyleHeader),
+	)
+
+	for _, candidate := range report.Candidates {
+		for _, evaluation := range candidate.Summary.Evaluations {
+			sheet.addRow(
+				cell(candidate.Candidate.Name, xlsxStyleDefault),
+				cell(evaluation.CriterionName, xlsxStyleDefault),
+				cell(evaluation.Evaluator, xlsxStyleDefault),
+				cell(evaluation.Score, xlsxStyleInteger),
+				cell(evaluation.Comments, xlsxStyleText),
+				cell(evaluation.UpdatedAt, xlsxStyleDate),
+			)
+		}
+	}
+
+	sheet.colorScale(3, 3, float64(scale.Min), float64(scale.Max))
+	return sheet
+}
+
+// answersSheet - ответы кандидатов на вопросы интервью, включая вопросы без ответа
+func answersSheet(report *models.JobReport) *xlsxSheet {
+	sheet := &xlsxSheet{
+		name:       "Ответы",
+		widths:     []float64{28, 24, 60, 80},
+		frozenRows: 1,
+		filter:     true,
+	}
+	sheet.addRow(
+		cell("Кандидат", xlsxStyleHeader),
+		cell("Критерий", xlsxStyleHeader),
+		cell("Вопрос", xlsxStyleHeader),
+		cell("Ответ", xlsxStyleHeader),
+	)
+
+	for _, candidate := range report.Candidates {
+		for _, question := range candidate.Questions {
+			answer := cell(nil, xlsxStyleText)
+			if question.AnswerText != "" {
+				answer = cell(question.AnswerText, xlsxStyleText)
+			}
+			sheet.addRow(
+				cell(candidate.Candidate.Name, xlsxStyleDefault),
+				cell(question.CriterionName, xlsxStyleDefault),
+				cell(question.Text, xlsxStyleText),
+				answer,
+			)
+		}
+	}
+
+	return sheet
+}
@@ -0,0 +1,306 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Книга Excel пишется напрямую в формате Office Open XML: строки хранятся в ячейках (inlineStr),
+// стили заданы фиксированным набором xlsxStyle*, подсветка оценок - условным форматированием
+
+// Индексы стилей ячеек в styles.xml (cellXfs)
+const (
+	xlsxStyleDefault  = iota
+	xlsxStyleHeader   // Жирный текст на сером фоне с переносом
+	xlsxStyleScore    // Число с одним знаком после запятой
+	xlsxStyleInteger  // Целое число
+	xlsxStyleText     // Текст с переносом по словам
+	xlsxStyleDate     // Дата и время
+	xlsxStyleKnockout // Красный текст
+)
+
+const xlsxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<numFmts count="2"><numFmt numFmtId="164" formatCode="0.0"/><numFmt numFmtId="165" formatCode="dd.mm.yyyy hh:mm"/></numFmts>
+<fonts count="3">
+<font><sz val="11"/><name val="Calibri"/><family val="2"/></font>
+<font><b/><sz val="11"/><name val="Calibri"/><family val="2"/></font>
+<font><sz val="11"/><color rgb="FFB33333"/><name val="Calibri"/><family val="2"/></font>
+</fonts>
+<fills count="3">
+<fill><patternFill patternType="none"/></fill>
+<fill><patternFill patternType="gray125"/></fill>
+<fill><patternFill patternType="solid"><fgColor rgb="FFF3F3F3"/><bgColor indexed="64"/></patternFill></fill>
+</fills>
+<borders count="2">
+<border><left/><right/><top/><bottom/><diagonal/></border>
+<border><left/><right/><top/><bottom style="thin"><color rgb="FFAAAAAA"/></bottom><diagonal/></border>
+</borders>
+<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>
+<cellXfs count="7">
+<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>
+<xf numFmtId="0" fontId="1" fillId="2" borderId="1" xfId="0" applyFont="1" applyFill="1" applyBorder="1" applyAlignment="1"><alignment vertical="top" wrapText="1"/></xf>
+<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>
+<xf numFmtId="1" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>
+<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0" applyAlignment="1"><alignment vertical="top" wrapText="1"/></xf>
+<xf numFmtId="165" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>
+<xf numFmtId="0" fontId="2" fillId="0" borderId="0" xfId="0" applyFont="1"/>
+</cellXfs>
+<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>
+</styleSheet>`
+
+// Цвета шкалы подсветки: худшая оценка - красный, середина шкалы - желтый, лучшая - зеленый
+const (
+	xlsxColorLow  = "FFF8696B"
+	xlsxColorMid  = "FFFFEB84"
+	xlsxColorHigh = "FF63BE7B"
+)
+
+// xlsxCell значение ячейки: string, int, float64, time.Time или nil для пустой ячейки
+type xlsxCell struct {
+	value any
+	style int
+}
+
+func cell(value any, style int) xlsxCell {
+	return xlsxCell{value: value, style: style}
+}
+
+// xlsxColorScale подсветка диапазона ячеек трехцветной шкалой от min до max
+type xlsxColorScale struct {
+	ref      string
+	min, max float64
+}
+
+// xlsxSheet лист книги
+type xlsxSheet struct {
+	name       string
+	widths     []float64 // Ширина столбцов в символах
+	rows       [][]xlsxCell
+	frozenRows int  // Закрепленные строки шапки
+	frozenCols int  // Закрепленные столбцы слева
+	filter     bool // Автофильтр по шапке
+	scales     []xlsxColorScale
+}
+
+func (s *xlsxSheet) addRow(cells ...xlsxCell) {
+	s.rows = append(s.rows, cells)
+}
+
+// colorScale подсвечивает столбцы с firstCol по lastCol (с нуля) во всех строках после шапки
+func (s *xlsxSheet) colorScale(firstCol, lastCol int, min, max float64) {
+	if len(s.rows) <= s.frozenRows || lastCol < firstCol {
+		return
+	}
+	ref := cellRef(firstCol, s.frozenRows+1) + ":" + cellRef(lastCol, len(s.rows))
+	s.scales = append(s.scales, xlsxColorScale{ref: ref, min: min, max: max})
+}
+
+// dimension возвращает диапазон заполненных ячеек листа
+func (s *xlsxSheet) dimension() string {
+	cols := 1
+	for _, row := range s.rows {
+		cols = max(cols, len(row))
+	}
+	return "A1:" + cellRef(cols-1, max(len(s.rows), 1))
+}
+
+// cellRef возвращает адрес ячейки вида "AB12": col с нуля, row с единицы
+func cellRef(col, row int) string {
+	name := ""
+	for col++; col > 0; col = (col - 1) / 26 {
+		name = string(rune('A'+(col-1)%26)) + name
+	}
+	return name + strconv.Itoa(row)
+}
+
+// excelEpoch - нулевой день дат Excel (с учетом ошибки 1900 года в Excel)
+var excelEpoch = time.Date(1899, time.December, 30, 0, 0, 0, 0, time.UTC)
+
+// excelDate переводит время в дату Excel: дни от excelEpoch с долей суток
+func excelDate(t time.Time) float64 {
+	local := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+	return local.Sub(excelEpoch).Hours() / 24
+}
+
+// writeXLSX записывает книгу из листов sheets
+func writeXLSX(w io.Writer, sheets []*xlsxSheet) error {
+	archive := zip.NewWriter(w)
+
+	var contentTypes, workbook, relationships bytes.Buffer
+	contentTypes.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>
+`)
+	workbook.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets>
+`)
+	relationships.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+`)
+
+	var filters bytes.Buffer
+	for i, sheet := range sheets {
+		fmt.Fprintf(&contentTypes, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`+"\n", i+1)
+		fmt.Fprintf(&workbook, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`+"\n", escapeXML(sheet.name), i+1, i+1)
+		fmt.Fprintf(&relationships, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`+"\n", i+1, i+1)
+		if sheet.filter {
+			// Excel хранит диапазон автофильтра еще и скрытым именем листа
+			fmt.Fprintf(&filters, `<definedName name="_xlnm._FilterDatabase" localSheetId="%d" hidden="1">%s</definedName>`+"\n",
+				i, escapeXML(absoluteRef(sheet)))
+		}
+	}
+	contentTypes.WriteString("</Types>")
+	workbook.WriteString("</sheets>\n")
+	if filters.Len() > 0 {
+		workbook.WriteString("<definedNames>\n")
+		filters.WriteTo(&workbook)
+		workbook.WriteString("</definedNames>\n")
+	}
+	workbook.WriteString("</workbook>")
+	fmt.Fprintf(&relationships, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`+"\n", len(sheets)+1)
+	relationships.WriteString("</Relationships>")
+
+	parts := map[string][]byte{
+		"[Content_Types].xml": contentTypes.Bytes(),
+		"_rels/.rels": []byte(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`),
+		"xl/workbook.xml":            workbook.Bytes(),
+		"xl/_rels/workbook.xml.rels": relationships.Bytes(),
+		"xl/styles.xml":              []byte(xlsxStyles),
+	}
+	names := []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml"}
+	for i, sheet := range sheets {
+		name := fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1)
+		parts[name] = sheet.xml()
+		names = append(names, name)
+	}
+
+	for _, name := range names {
+		f, err := archive.Create(name)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", name, err)
+		}
+		if _, err := f.Write(parts[name]); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return fmt.Errorf("failed to write xlsx: %w", err)
+	}
+	return nil
+}
+
+// absoluteRef возвращает диапазон листа в виде 'Лист'!$A$1:$F$20
+func absoluteRef(sheet *xlsxSheet) string {
+	from, to, _ := strings.Cut(sheet.dimension(), ":")
+	return fmt.Sprintf("'%s'!%s:%s", strings.ReplaceAll(sheet.name, "'", "''"), absoluteCell(from), absoluteCell(to))
+}
+
+// absoluteCell превращает "AB12" в "$AB$12"
+func absoluteCell(ref string) string {
+	for i := range ref {
+		if ref[i] >= '0' && ref[i] <= '9' {
+			return "$" + ref[:i] + "$" + ref[i:]
+		}
+	}
+	return ref
+}
+
+// xml возвращает разметку листа
+func (s *xlsxSheet) xml() []byte {
+	var b bytes.Buffer
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+`)
+	fmt.Fprintf(&b, `<dimension ref="%s"/>`+"\n", s.dimension())
+
+	b.WriteString(`<sheetViews><sheetView workbookViewId="0">`)
+	if s.frozenRows > 0 || s.frozenCols > 0 {
+		pane := "bottomRight"
+		switch {
+		case s.frozenCols == 0:
+			pane = "bottomLeft"
+		case s.frozenRows == 0:
+			pane = "topRight"
+		}
+		b.WriteString("<pane")
+		if s.frozenCols > 0 {
+			fmt.Fprintf(&b, ` xSplit="%d"`, s.frozenCols)
+		}
+		if s.frozenRows > 0 {
+			fmt.Fprintf(&b, ` ySplit="%d"`, s.frozenRows)
+		}
+		fmt.Fprintf(&b, ` topLeftCell="%s" activePane="%s" state="frozen"/>`, cellRef(s.frozenCols, s.frozenRows+1), pane)
+	}
+	b.WriteString("</sheetView></sheetViews>\n")
+
+	if len(s.widths) > 0 {
+		b.WriteString("<cols>")
+		for i, width := range s.widths {
+			fmt.Fprintf(&b, `<col min="%d" max="%d" width="%g" customWidth="1"/>`, i+1, i+1, width)
+		}
+		b.WriteString("</cols>\n")
+	}
+
+	b.WriteString("<sheetData>\n")
+	for i, row := range s.rows {
+		fmt.Fprintf(&b, `<row r="%d">`, i+1)
+		for j, c := range row {
+			writeXLSXCell(&b, cellRef(j, i+1), c)
+		}
+		b.WriteString("</row>\n")
+	}
+	b.WriteString("</sheetData>\n")
+
+	if s.filter && len(s.rows) > 0 {
+		fmt.Fprintf(&b, `<autoFilter ref="%s"/>`+"\n", s.dimension())
+	}
+	for i, scale := range s.scales {
+		fmt.Fprintf(&b, `<conditionalFormatting sqref="%s"><cfRule type="colorScale" priority="%d"><colorScale>`+
+			`<cfvo type="num" val="%g"/><cfvo type="num" val="%g"/><cfvo type="num" val="%g"/>`+
+			`<color rgb="%s"/><color rgb="%s"/><color rgb="%s"/></colorScale></cfRule></conditionalFormatting>`+"\n",
+			scale.ref, i+1, scale.min, (scale.min+scale.max)/2, scale.max, xlsxColorLow, xlsxColorMid, xlsxColorHigh)
+	}
+
+	b.WriteString(`<pageMargins left="0.5" right="0.5" top="0.75" bottom="0.75" header="0.3" footer="0.3"/>` + "\n")
+	b.WriteString("</worksheet>")
+	return b.Bytes()
+}
+
+// writeXLSXCell пишет ячейку; пустая ячейка сохраняет только стиль
+func writeXLSXCell(b *bytes.Buffer, ref string, c xlsxCell) {
+	switch v := c.value.(type) {
+	case nil:
+		fmt.Fprintf(b, `<c r="%s" s="%d"/>`, ref, c.style)
+	case string:
+		fmt.Fprintf(b, `<c r="%s" s="%d" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, c.style, escapeXML(v))
+	case int:
+		fmt.Fprintf(b, `<c r="%s" s="%d"><v>%d</v></c>`, ref, c.style, v)
+	case float64:
+		fmt.Fprintf(b, `<c r="%s" s="%d"><v>%s</v></c>`, ref, c.style, strconv.FormatFloat(v, 'f', -1, 64))
+	case time.Time:
+		fmt.Fprintf(b, `<c r="%s" s="%d"><v>%s</v></c>`, ref, c.style, strconv.FormatFloat(excelDate(v), 'f', -1, 64))
+	default:
+		fmt.Fprintf(b, `<c r="%s" s="%d" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, c.style, escapeXML(fmt.Sprint(v)))
+	}
+}
+
+// escapeXML экранирует текст для XML; недопустимые в XML символы заменяются на U+FFFD
+func escapeXML(s string) string {
+	var b bytes.Buffer
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
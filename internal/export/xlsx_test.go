@@ -0,0 +1,214 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCellRef(t *testing.T) {
+	tests := []struct {
+		col, row int
+		want     string
+	}{
+		{0, 1, "A1"},
+		{25, 2, "Z2"},
+		{26, 3, "AA3"},
+		{27, 10, "AB10"},
+		{701, 1, "ZZ1"},
+		{702, 1, "AAA1"},
+	}
+
+	for _, tt := range tests {
+		if got := cellRef(tt.col, tt.row); got != tt.want {
+			t.Errorf("cellRef(%d, %d) = %q, want %q", tt.col, tt.row, got, tt.want)
+		}
+	}
+}
+
+func TestExcelDate(t *testing.T) {
+	tests := []struct {
+		time time.Time
+		want float64
+	}{
+		{time.Date(1900, time.March, 1, 0, 0, 0, 0, time.UTC), 61},
+		{time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC), 45292},
+		{time.Date(2024, time.January, 1, 18, 0, 0, 0, time.UTC), 45292.75},
+		// Дата берется в часовом поясе значения, как ее видит пользователь
+		{time.Date(2024, time.January, 1, 6, 0, 0, 0, time.FixedZone("MSK", 3*60*60)), 45292.25},
+	}
+
+	for _, tt := range tests {
+		if got := excelDate(tt.time); got != tt.want {
+			t.Errorf("excelDate(%v) = %v, want %v", tt.time, got, tt.want)
+		}
+	}
+}
+
+// xlsxTestCell ячейка, прочитанная из книги
+type xlsxTestCell struct {
+	Ref   string `xml:"r,attr"`
+	Style int    `xml:"s,attr"`
+	Type  string `xml:"t,attr"`
+	Value string `xml:"v"`
+	Text  string `xml:"is>t"`
+}
+
+// readXLSX читает книгу: имена листов и ячейки каждого листа по строкам
+func readXLSX(t *testing.T, data []byte) (names []string, sheets [][][]xlsxTestCell) {
+	t.Helper()
+
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("failed to open xlsx: %v", err)
+	}
+	parts := make(map[string][]byte)
+	for _, f := range archive.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatalf("failed to open %s: %v", f.Name, err)
+		}
+		parts[f.Name], _ = io.ReadAll(r)
+		r.Close()
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/_rels/workbook.xml.rels", "xl/styles.xml"} {
+		if err := xml.Unmarshal(parts[name], new(any)); err != nil {
+			t.Errorf("%s is not valid XML: %v", name, err)
+		}
+	}
+
+	var workbook struct {
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := xml.Unmarshal(parts["xl/workbook.xml"], &workbook); err != nil {
+		t.Fatalf("failed to parse workbook: %v", err)
+	}
+
+	for i, sheet := range workbook.Sheets {
+		var worksheet struct {
+			Rows []struct {
+				Cells []xlsxTestCell `xml:"c"`
+			} `xml:"sheetData>row"`
+		}
+		part := fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1)
+		if err := xml.Unmarshal(parts[part], &worksheet); err != nil {
+			t.Fatalf("failed to parse %s: %v", part, err)
+		}
+		var rows [][]xlsxTestCell
+		for _, row := range worksheet.Rows {
+			rows = append(rows, row.Cells)
+		}
+		names = append(names, sheet.Name)
+		sheets = append(sheets, rows)
+	}
+	return names, sheets
+}
+
+func TestWriteXLSXRoundTrip(t *testing.T) {
+	updated := time.Date(2024, time.January, 1, 18, 0, 0, 0, time.UTC)
+
+	first := &xlsxSheet{name: "Сводка <1>", frozenRows: 1, frozenCols: 1, filter: true}
+	first.addRow(cell("Кандидат", xlsxStyleHeader), cell("Оценка", xlsxStyleHeader))
+	first.addRow(cell("Иван & Co", xlsxStyleDefault), cell(4.5, xlsxStyleScore))
+	first.addRow(cell("  пробелы  ", xlsxStyleText), cell(nil, xlsxStyleScore))
+	first.colorScale(1, 1, 1, 5)
+
+	second := &xlsxSheet{name: "O'Brien"}
+	second.addRow(cell(3, xlsxStyleInteger), cell(updated, xlsxStyleDate), cell("\x01управляющий", xlsxStyleDefault))
+
+	var buf bytes.Buffer
+	if err := writeXLSX(&buf, []*xlsxSheet{first, second}); err != nil {
+		t.Fatalf("writeXLSX() error = %v", err)
+	}
+
+	names, sheets := readXLSX(t, buf.Bytes())
+	if want := []string{"Сводка <1>", "O'Brien"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("sheet names = %q, want %q", names, want)
+	}
+
+	want := [][][]xlsxTestCell{
+		{
+			{
+				{Ref: "A1", Style: xlsxStyleHeader, Type: "inlineStr", Text: "Кандидат"},
+				{Ref: "B1", Style: xlsxStyleHeader, Type: "inlineStr", Text: "Оценка"},
+			},
+			{
+				{Ref: "A2", Style: xlsxStyleDefault, Type: "inlineStr", Text: "Иван & Co"},
+				{Ref: "B2", Style: xlsxStyleScore, Value: "4.5"},
+			},
+			{
+				{Ref: "A3", Style: xlsxStyleText, Type: "inlineStr", Text: "  пробелы  "},
+				{Ref: "B3", Style: xlsxStyleScore},
+			},
+		},
+		{
+			{
+				{Ref: "A1", Style: xlsxStyleInteger, Value: "3"},
+				{Ref: "B1", Style: xlsxStyleDate, Value: "45292.75"},
+				{Ref: "C1", Style: xlsxStyleDefault, Type: "inlineStr", Text: "�управляющий"},
+			},
+		},
+	}
+	if !reflect.DeepEqual(sheets, want) {
+		t.Errorf("cells = %+v, want %+v", sheets, want)
+	}
+}
+
+func TestXLSXSheetLayout(t *testing.T) {
+	sheet := &xlsxSheet{name: "Оценки", widths: []float64{28, 9.5}, frozenRows: 1, filter: true}
+	sheet.addRow(cell("Кандидат", xlsxStyleHeader), cell("Оценка", xlsxStyleHeader))
+	sheet.addRow(cell("Анна", xlsxStyleDefault), cell(5, xlsxStyleInteger))
+	sheet.colorScale(1, 1, 1, 5)
+	// Без строк после шапки подсвечивать нечего
+	(&xlsxSheet{frozenRows: 1}).colorScale(0, 0, 1, 5)
+
+	var layout struct {
+		Dimension struct {
+			Ref string `xml:"ref,attr"`
+		} `xml:"dimension"`
+		Pane struct {
+			YSplit      int    `xml:"ySplit,attr"`
+			TopLeftCell string `xml:"topLeftCell,attr"`
+			ActivePane  string `xml:"activePane,attr"`
+		} `xml:"sheetViews>sheetView>pane"`
+		Cols []struct {
+			Width string `xml:"width,attr"`
+		} `xml:"cols>col"`
+		AutoFilter struct {
+			Ref string `xml:"ref,attr"`
+		} `xml:"autoFilter"`
+		Formatting []struct {
+			Ref    string `xml:"sqref,attr"`
+			Values []struct {
+				Val string `xml:"val,attr"`
+			} `xml:"cfRule>colorScale>cfvo"`
+		} `xml:"conditionalFormatting"`
+	}
+	if err := xml.Unmarshal(sheet.xml(), &layout); err != nil {
+		t.Fatalf("failed to parse sheet: %v", err)
+	}
+
+	if layout.Dimension.Ref != "A1:B2" || layout.AutoFilter.Ref != "A1:B2" {
+		t.Errorf("dimension = %q, autoFilter = %q; want A1:B2", layout.Dimension.Ref, layout.AutoFilter.Ref)
+	}
+	if layout.Pane.YSplit != 1 || layout.Pane.TopLeftCell != "A2" || layout.Pane.ActivePane != "bottomLeft" {
+		t.Errorf("pane = %+v, want ySplit 1 at A2, bottomLeft", layout.Pane)
+	}
+	if len(layout.Cols) != 2 || layout.Cols[1].Width != "9.5" {
+		t.Errorf("cols = %+v, want widths 28 and 9.5", layout.Cols)
+	}
+	if len(layout.Formatting) != 1 || layout.Formatting[0].Ref != "B2:B2" || len(layout.Formatting[0].Values) != 3 ||
+		layout.Formatting[0].Values[1].Val != "3" {
+		t.Errorf("conditional formatting = %+v, want B2:B2 scale 1..3..5", layout.Formatting)
+	}
+	if got, want := absoluteRef(sheet), "'Оценки'!$A$1:$B$2"; got != want {
+		t.Errorf("absoluteRef() = %q, want %q", got, want)
+	}
+}
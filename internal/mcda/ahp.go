@@ -0,0 +1,111 @@
+package mcda
+
+import "math"
+
+// Метод анализа иерархий (AHP). Веса критериев выводятся из матрицы попарных сравнений
+// по шкале Саати: a[i][j] - во сколько раз критерий i важнее критерия j (от 1/9 до 9),
+// a[j][i] = 1 / a[i][j]. Вектор приоритетов - главный собственный вектор матрицы
+
+// randomIndex случайный индекс согласованности Саати для матриц порядка n (индекс - n)
+var randomIndex = []float64{0, 0, 0, 0.58, 0.90, 1.12, 1.24, 1.32, 1.41, 1.45, 1.49, 1.51, 1.48, 1.56, 1.57, 1.59}
+
+const (
+	eigenIterations = 1000
+	eigenTolerance  = 1e-12
+)
+
+// PriorityVector возвращает вектор приоритетов матрицы попарных сравнений (сумма 1)
+// и ее главное собственное значение. Вектор находится степенным методом
+func PriorityVector(matrix [][]float64) (priorities []float64, lambdaMax float64) {
+	n := len(matrix)
+	if n == 0 {
+		return []float64{}, 0
+	}
+
+	priorities = make([]float64, n)
+	for i := range priorities {
+		priorities[i] = 1 / float64(n)
+	}
+
+	product := make([]float64, n)
+	for iteration := 0; iteration < eigenIterations; iteration++ {
+		sum := multiply(matrix, priorities, product)
+		change := 0.0
+		for i := range priorities {
+			next := product[i] / sum
+			change = math.Max(change, math.Abs(next-priorities[i]))
+			priorities[i] = next
+		}
+		if change < eigenTolerance {
+			break
+		}
+	}
+
+	// λmax - среднее отношение (Aw)_i / w_i
+	multiply(matrix, priorities, product)
+	for i := range priorities {
+		lambdaMax += product[i] / priorities[i]
+	}
+	lambdaMax /= float64(n)
+
+	return priorities, lambdaMax
+}
+
+// multiply записывает в result произведение матрицы на вектор и возвращает сумму его элементов
+func multiply(matrix [][]float64, vector, result []float64) float64 {
+	sum := 0.0
+	for i, row := range matrix {
+		result[i] = 0
+		for j, a := range row {
+			result[i] += a * vector[j]
+		}
+		sum += result[i]
+	}
+	return sum
+}
+
+// ConsistencyRatio возвращает отношение согласованности CR = CI / RI, где
+// CI = (λmax - n) / (n - 1). Матрицы порядка до 2 согласованы всегда; CR до 0.1
+// считается приемлемым
+func ConsistencyRatio(n int, lambdaMax float64) float64 {
+	if n < 3 {
+		return 0
+	}
+	ri := randomIndex[len(randomIndex)-1]
+	if n < len(randomIndex) {
+		ri = randomIndex[n]
+	}
+	return math.Max(0, (lambdaMax-float64(n))/float64(n-1)/ri)
+}
+
+// AHPSynthesis сводит оценки альтернатив в глобальные приоритеты. matrix[i][j] - неотрицательное
+// значение альтернативы i по критерию j; локальные приоритеты по критерию - доли значений
+// в сумме столбца (при нулевой сумме - поровну). Глобальный приоритет - сумма локальных,
+// умноженных на веса критериев; contributions[i][j] - слагаемое критерия j.
+// Приоритеты всех альтернатив в сумме дают 1
+func AHPSynthesis(matrix [][]float64, weights []float64) (priorities []float64, contributions [][]float64) {
+	weights = NormalizeWeights(weights)
+
+	sums := make([]float64, len(weights))
+	for _, row := range matrix {
+		for j, v := range row {
+			sums[j] += v
+		}
+	}
+
+	priorities = make([]float64, len(matrix))
+	contributions = make([][]float64, len(matrix))
+	for i, row := range matrix {
+		contributions[i] = make([]float64, len(weights))
+		for j, v := range row {
+			local := 1 / float64(len(matrix))
+			if sums[j] > 0 {
+				local = v / sums[j]
+			}
+			contributions[i][j] = weights[j] * local
+			priorities[i] += contributions[i][j]
+		}
+	}
+
+	return priorities, contributions
+}
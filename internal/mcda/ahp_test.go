@@ -0,0 +1,125 @@
+package mcda
+
+import (
+	"math"
+	"testing"
+)
+
+const tolerance = 1e-9
+
+func TestPriorityVector(t *testing.T) {
+	tests := []struct {
+		name       string
+		matrix     [][]float64
+		priorities []float64
+		lambdaMax  float64
+		cr         float64
+	}{
+		{
+			name:       "empty",
+			matrix:     [][]float64{},
+			priorities: []float64{},
+		},
+		{
+			name:       "consistent",
+			matrix:     [][]float64{{1, 2, 4}, {0.5, 1, 2}, {0.25, 0.5, 1}},
+			priorities: []float64{4.0 / 7, 2.0 / 7, 1.0 / 7},
+			lambdaMax:  3,
+		},
+		{
+			// Пример Саати: λmax = 1 + c^(1/3) + c^(-1/3), c = a12·a23 / a13
+			name:       "Saaty",
+			matrix:     [][]float64{{1, 3, 5}, {1.0 / 3, 1, 3}, {1.0 / 5, 1.0 / 3, 1}},
+			priorities: []float64{0.636985571744757, 0.258284994374495, 0.104729433880748},
+			lambdaMax:  3.03851109055817,
+			cr:         0.0331992159984224,
+		},
+		{
+			name:       "two criteria",
+			matrix:     [][]float64{{1, 3}, {1.0 / 3, 1}},
+			priorities: []float64{0.75, 0.25},
+			lambdaMax:  2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			priorities, lambdaMax := PriorityVector(tt.matrix)
+			if len(priorities) != len(tt.priorities) {
+				t.Fatalf("PriorityVector() returned %d priorities, want %d", len(priorities), len(tt.priorities))
+			}
+			for i := range priorities {
+				if math.Abs(priorities[i]-tt.priorities[i]) > tolerance {
+					t.Errorf("priorities[%d] = %v, want %v", i, priorities[i], tt.priorities[i])
+				}
+			}
+			if math.Abs(lambdaMax-tt.lambdaMax) > tolerance {
+				t.Errorf("lambdaMax = %v, want %v", lambdaMax, tt.lambdaMax)
+			}
+			if cr := ConsistencyRatio(len(tt.matrix), lambdaMax); math.Abs(cr-tt.cr) > tolerance {
+				t.Errorf("ConsistencyRatio() = %v, want %v", cr, tt.cr)
+			}
+		})
+	}
+}
+
+func TestConsistencyRatio(t *testing.T) {
+	tests := []struct {
+		n         int
+		lambdaMax float64
+		want      float64
+	}{
+		{n: 2, lambdaMax: 2.5, want: 0},
+		{n: 4, lambdaMax: 4.27, want: 0.1},
+		{n: 3, lambdaMax: 2.9999999, want: 0},
+		// Для больших матриц берется последний случайный индекс
+		{n: 20, lambdaMax: 21.9, want: 0.1 / 1.59},
+	}
+
+	for _, tt := range tests {
+		if got := ConsistencyRatio(tt.n, tt.lambdaMax); math.Abs(got-tt.want) > tolerance {
+			t.Errorf("ConsistencyRatio(%d, %v) = %v, want %v", tt.n, tt.lambdaMax, got, tt.want)
+		}
+	}
+}
+
+func TestAHPSynthesis(t *testing.T) {
+	tests := []struct {
+		name          string
+		matrix        [][]float64
+		weights       []float64
+		priorities    []float64
+		contributions [][]float64
+	}{
+		{
+			name:          "weighted shares",
+			matrix:        [][]float64{{2, 1}, {2, 3}},
+			weights:       []float64{1, 3},
+			priorities:    []float64{0.3125, 0.6875},
+			contributions: [][]float64{{0.125, 0.1875}, {0.125, 0.5625}},
+		},
+		{
+			name:          "zero column split equally",
+			matrix:        [][]float64{{0, 1}, {0, 3}},
+			weights:       []float64{0.5, 0.5},
+			priorities:    []float64{0.375, 0.625},
+			contributions: [][]float64{{0.25, 0.125}, {0.25, 0.375}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			priorities, contributions := AHPSynthesis(tt.matrix, tt.weights)
+			for i := range tt.priorities {
+				if math.Abs(priorities[i]-tt.priorities[i]) > tolerance {
+					t.Errorf("priorities[%d] = %v, want %v", i, priorities[i], tt.priorities[i])
+				}
+				for j := range tt.contributions[i] {
+					if math.Abs(contributions[i][j]-tt.contributions[i][j]) > tolerance {
+						t.Errorf("contributions[%d][%d] = %v, want %v", i, j, contributions[i][j], tt.contributions[i][j])
+					}
+				}
+			}
+		})
+	}
+}
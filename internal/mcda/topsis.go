@@ -0,0 +1,109 @@
+// Package mcda содержит методы многокритериального принятия решений для ранжирования кандидатов
+package mcda
+
+import "math"
+
+// TOPSISAlternative результат TOPSIS для одной альтернативы (кандидата)
+type TOPSISAlternative struct {
+	Closeness         float64   // Коэффициент близости к идеалу от 0 до 1
+	IdealDistance     float64   // Расстояние до идеальной альтернативы
+	AntiIdealDistance float64   // Расстояние до антиидеальной альтернативы
+	Weighted          []float64 // Взвешенные нормированные значения по критериям
+	Contributions     []float64 // Вклад критериев: доля в разности квадратов расстояний, от -1 до 1
+}
+
+// TOPSISSolution результат TOPSIS по всем альтернативам
+type TOPSISSolution struct {
+	Weights      []float64 // Веса критериев, приведенные к сумме 1
+	Ideal        []float64 // Лучшие взвешенные значения по критериям
+	AntiIdeal    []float64 // Худшие взвешенные значения по критериям
+	Alternatives []TOPSISAlternative
+}
+
+// TOPSIS ранжирует альтернативы по близости к идеальному решению. matrix[i][j] - значение
+// альтернативы i по критерию j, все критерии - на максимум. Столбцы нормируются по евклидовой
+// норме и умножаются на веса; идеал и антиидеал - лучшие и худшие значения по каждому критерию.
+// Вклад критерия j у альтернативы - (d-_j² - d+_j²) / (D+² + D-²): положительный вклад
+// приближает альтернативу к идеалу, отрицательный - к антиидеалу. Если альтернативы
+// не различаются, коэффициент близости у всех равен 1
+func TOPSIS(matrix [][]float64, weights []float64) TOPSISSolution {
+	criteria := len(weights)
+	solution := TOPSISSolution{
+		Weights:      NormalizeWeights(weights),
+		Ideal:        make([]float64, criteria),
+		AntiIdeal:    make([]float64, criteria),
+		Alternatives: make([]TOPSISAlternative, len(matrix)),
+	}
+
+	norms := make([]float64, criteria)
+	for _, row := range matrix {
+		for j, v := range row {
+			norms[j] += v * v
+		}
+	}
+	for j := range norms {
+		norms[j] = math.Sqrt(norms[j])
+	}
+
+	for i, row := range matrix {
+		weighted := make([]float64, criteria)
+		for j, v := range row {
+			if norms[j] > 0 {
+				weighted[j] = solution.Weights[j] * v / norms[j]
+			}
+			if i == 0 || weighted[j] > solution.Ideal[j] {
+				solution.Ideal[j] = weighted[j]
+			}
+			if i == 0 || weighted[j] < solution.AntiIdeal[j] {
+				solution.AntiIdeal[j] = weighted[j]
+			}
+		}
+		solution.Alternatives[i].Weighted = weighted
+	}
+
+	for i := range solution.Alternatives {
+		alternative := &solution.Alternatives[i]
+		toIdeal := make([]float64, criteria)
+		toAntiIdeal := make([]float64, criteria)
+		idealSquares, antiIdealSquares := 0.0, 0.0
+		for j, v := range alternative.Weighted {
+			toIdeal[j] = (v - solution.Ideal[j]) * (v - solution.Ideal[j])
+			toAntiIdeal[j] = (v - solution.AntiIdeal[j]) * (v - solution.AntiIdeal[j])
+			idealSquares += toIdeal[j]
+			antiIdealSquares += toAntiIdeal[j]
+		}
+		alternative.IdealDistance = math.Sqrt(idealSquares)
+		alternative.AntiIdealDistance = math.Sqrt(antiIdealSquares)
+
+		alternative.Closeness = 1
+		if total := alternative.IdealDistance + alternative.AntiIdealDistance; total > 0 {
+			alternative.Closeness = alternative.AntiIdealDistance / total
+		}
+
+		alternative.Contributions = make([]float64, criteria)
+		if squares := idealSquares + antiIdealSquares; squares > 0 {
+			for j := range alternative.Contributions {
+				alternative.Contributions[j] = (toAntiIdeal[j] - toIdeal[j]) / squares
+			}
+		}
+	}
+
+	return solution
+}
+
+// NormalizeWeights приводит неотрицательные веса к сумме 1; при нулевой сумме веса равные
+func NormalizeWeights(weights []float64) []float64 {
+	sum := 0.0
+	for _, w := range weights {
+		sum += w
+	}
+	normalized := make([]float64, len(weights))
+	for i, w := range weights {
+		if sum > 0 {
+			normalized[i] = w / sum
+		} else {
+			normalized[i] = 1 / float64(len(weights))
+		}
+	}
+	return normalized
+}
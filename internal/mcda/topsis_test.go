@@ -0,0 +1,101 @@
+package mcda
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTOPSIS(t *testing.T) {
+	tests := []struct {
+		name      string
+		matrix    [][]float64
+		weights   []float64
+		closeness []float64
+		ideal     []float64
+	}{
+		{
+			name:      "four alternatives",
+			matrix:    [][]float64{{7, 9, 9, 8}, {8, 7, 8, 7}, {9, 6, 8, 9}, {6, 7, 8, 6}},
+			weights:   []float64{0.1, 0.4, 0.3, 0.2},
+			closeness: []float64{0.825337167356751, 0.341909329045858, 0.345400196143416, 0.273273584276111},
+		},
+		{
+			// Нормы столбцов √2: идеал (0.5/√2, 0.5/√2), антиидеал - ноль
+			name:      "exact",
+			matrix:    [][]float64{{1, 0}, {0, 1}, {1, 1}},
+			weights:   []float64{1, 1},
+			closeness: []float64{0.5, 0.5, 1},
+			ideal:     []float64{0.5 / math.Sqrt2, 0.5 / math.Sqrt2},
+		},
+		{
+			name:      "identical alternatives",
+			matrix:    [][]float64{{3, 4}, {3, 4}},
+			weights:   []float64{2, 1},
+			closeness: []float64{1, 1},
+		},
+		{
+			name:      "zero column",
+			matrix:    [][]float64{{0, 2}, {0, 1}},
+			weights:   []float64{0, 0},
+			closeness: []float64{1, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			solution := TOPSIS(tt.matrix, tt.weights)
+			if len(solution.Alternatives) != len(tt.closeness) {
+				t.Fatalf("TOPSIS() returned %d alternatives, want %d", len(solution.Alternatives), len(tt.closeness))
+			}
+			for j, want := range tt.ideal {
+				if math.Abs(solution.Ideal[j]-want) > tolerance {
+					t.Errorf("Ideal[%d] = %v, want %v", j, solution.Ideal[j], want)
+				}
+			}
+
+			for i, alternative := range solution.Alternatives {
+				if math.Abs(alternative.Closeness-tt.closeness[i]) > tolerance {
+					t.Errorf("alternative %d: Closeness = %v, want %v", i, alternative.Closeness, tt.closeness[i])
+				}
+
+				// Вклады критериев в сумме дают (D-² - D+²) / (D+² + D-²)
+				plus, minus := alternative.IdealDistance, alternative.AntiIdealDistance
+				want := 0.0
+				if squares := plus*plus + minus*minus; squares > 0 {
+					want = (minus*minus - plus*plus) / squares
+				}
+				sum := 0.0
+				for _, c := range alternative.Contributions {
+					sum += c
+				}
+				if math.Abs(sum-want) > tolerance {
+					t.Errorf("alternative %d: contributions sum = %v, want %v", i, sum, want)
+				}
+			}
+		})
+	}
+}
+
+func TestNormalizeWeights(t *testing.T) {
+	tests := []struct {
+		weights []float64
+		want    []float64
+	}{
+		{[]float64{1, 3}, []float64{0.25, 0.75}},
+		{[]float64{0, 0, 0, 0}, []float64{0.25, 0.25, 0.25, 0.25}},
+		{[]float64{}, []float64{}},
+	}
+
+	for _, tt := range tests {
+		got := NormalizeWeights(tt.weights)
+		if len(got) != len(tt.want) {
+			t.Fatalf("NormalizeWeights(%v) = %v, want %v", tt.weights, got, tt.want)
+		}
+		for i := range got {
+			if math.Abs(got[i]-tt.want[i]) > tolerance {
+				t.Errorf("NormalizeWeights(%v) = %v, want %v", tt.weights, got, tt.want)
+				break
+			}
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 )
 
@@ -13,28 +14,145 @@ type Job struct {
 	Criteria     string    `json:"criteria" db:"criteria"` // Deprecated: Теперь используется таблица criteria
 	CreatedAt    time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+
+	ScoringScale   *ScoringScale `json:"scoring_scale"`   // При создании и обновлении необязательна: по умолчанию 1-10, без нее не меняется
+	KnockoutAction string        `json:"knockout_action"` // "flag" или "reject": что делать с кандидатом ниже порога отсеивающего критерия; пустое значение не меняет его
+}
+
+// ScoringScale шкала оценок вакансии
+type ScoringScale struct {
+	Type   string       `json:"type"` // "five_point", "ten_point", "four_point", "pass_fail" или "custom"
+	Min    int          `json:"min"`
+	Max    int          `json:"max"`
+	Levels []ScaleLevel `json:"levels,omitempty"` // Допустимые значения с подписями; у 1-5 и 1-10 - любое целое от min до max
+}
+
+// ScaleLevel значение шкалы оценок с подписью
+type ScaleLevel struct {
+	Value int    `json:"value"`
+	Label string `json:"label"`
 }
 
 // Criterion представляет критерий оценки
 type Criterion struct {
-	ID           int64     `json:"id" db:"id"`
-	JobID        int64     `json:"job_id" db:"job_id"`
-	Name         string    `json:"name" db:"name"`
-	DisplayOrder int       `json:"display_order" db:"display_order"`
-	CreatedAt    time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+	ID           int64         `json:"id" db:"id"`
+	JobID        int64         `json:"job_id" db:"job_id"`
+	Name         string        `json:"name" db:"name"`
+	DisplayOrder int           `json:"display_order" db:"display_order"`
+	Weight       float64       `json:"weight" db:"weight"`       // Вклад критерия во взвешенную оценку кандидата
+	Rubric       []RubricLevel `json:"rubric" db:"rubric"`       // Описания опорных уровней шкалы по возрастанию оценки
+	MustHave     bool          `json:"must_have"`                // Отсеивающий критерий: кандидат со средней оценкой ниже min_score не проходит
+	MinScore     int           `json:"min_score" db:"min_score"` // Минимальная оценка отсеивающего критерия
+	CreatedAt    time.Time     `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time     `json:"updated_at" db:"updated_at"`
+}
+
+// RubricLevel описывает, как выглядит ответ кандидата на уровне шкалы. Оценки между
+// опорными уровнями означают ответ между соседними описаниями
+type RubricLevel struct {
+	Score       int    `json:"score"`
+	Description string `json:"description"`
 }
 
 // Candidate представляет кандидата
 type Candidate struct {
 	ID          int64     `json:"id" db:"id"`
-	JobID       int64     `json:"job_id" db:"job_id"`
+	JobID       int64     `json:"job_id" db:"job_id"` // Вакансия, для которой кандидат был добавлен; отклики хранятся в applications
 	Name        string    `json:"name" db:"name"`
 	Email       string    `json:"email" db:"email"`
 	Phone       string    `json:"phone" db:"phone"`
 	Description string    `json:"description" db:"description"`
 	CreatedAt   time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+
+	// Отклики кандидата на вакансии (заполняется при получении кандидата по ID)
+	Applications []Application `json:"applications,omitempty"`
+}
+
+// Application представляет отклик кандидата на вакансию
+type Application struct {
+	ID            int64     `json:"id" db:"id"`
+	CandidateID   int64     `json:"candidate_id" db:"candidate_id"`
+	JobID         int64     `json:"job_id" db:"job_id"`
+	Stage         string    `json:"stage" db:"stage"`                   // Этап найма, меняется только переходом
+	StagePosition int       `json:"stage_position" db:"stage_position"` // Позиция в колонке канбан-доски
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+	// Оценка работы принятого кандидата от 1 до 5, null - не оценен
+	PerformanceRating *int `json:"performance_rating" db:"performance_rating"`
+
+	// Поля для JOIN запросов
+	JobTitle        string       `json:"job_title,omitempty" db:"job_title"`
+	AverageScore    float64      `json:"average_score"`
+	NormalizedScore float64      `json:"normalized_score"` // Средняя оценка в процентах шкалы вакансии (0-100)
+	Evaluations     []Evaluation `json:"evaluations,omitempty"`
+}
+
+// ApplicationRequest представляет запрос на создание отклика кандидата
+type ApplicationRequest struct {
+	JobID int64 `json:"job_id"`
+}
+
+// PerformanceRatingRequest представляет оценку работы принятого кандидата; null снимает оценку
+type PerformanceRatingRequest struct {
+	Rating *int `json:"rating"`
+}
+
+// JobStage представляет этап найма в воронке вакансии
+type JobStage struct {
+	ID           int64     `json:"id" db:"id"`
+	JobID        int64     `json:"job_id" db:"job_id"`
+	Key          string    `json:"key" db:"key"` // Значение поля stage у кандидатов
+	Name         string    `json:"name" db:"name"`
+	DisplayOrder int       `json:"display_order" db:"display_order"`
+	System       bool      `json:"system"` // Системные этапы нельзя удалить
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// JobStageUpdate представляет данные для переименования этапа
+type JobStageUpdate struct {
+	Name string `json:"name"`
+}
+
+// Board представляет канбан-доску вакансии
+type Board struct {
+	JobID   int64         `json:"job_id"`
+	Total   int           `json:"total"`
+	Columns []BoardColumn `json:"columns"`
+}
+
+// BoardColumn представляет колонку доски с кандидатами одного этапа
+type BoardColumn struct {
+	Stage      JobStage           `json:"stage"`
+	Count      int                `json:"count"`
+	Candidates []CandidateWithJob `json:"candidates"`
+}
+
+// CandidateMoveRequest представляет перемещение карточки кандидата на доске
+type CandidateMoveRequest struct {
+	Stage    string `json:"stage"`
+	Position int    `json:"position"` // Позиция в колонке, начиная с 0
+	Reason   string `json:"reason"`
+}
+
+// StageTransition представляет переход кандидата между этапами найма
+type StageTransition struct {
+	ID            int64     `json:"id" db:"id"`
+	ApplicationID int64     `json:"application_id" db:"application_id"`
+	CandidateID   int64     `json:"candidate_id" db:"candidate_id"`
+	JobID         int64     `json:"job_id" db:"job_id"`
+	FromStage     string    `json:"from_stage" db:"from_stage"`
+	ToStage       string    `json:"to_stage" db:"to_stage"`
+	Actor         string    `json:"actor" db:"actor"`
+	Reason        string    `json:"reason" db:"reason"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+}
+
+// StageTransitionRequest представляет запрос на перевод кандидата на другой этап
+type StageTransitionRequest struct {
+	Stage  string `json:"stage"`
+	Reason string `json:"reason"`
 }
 
 // Question представляет вопрос для интервью
@@ -58,42 +176,489 @@ type QuestionWithCriterion struct {
 
 // Evaluation представляет оценку кандидата
 type Evaluation struct {
-	ID          int64     `json:"id" db:"id"`
-	CandidateID int64     `json:"candidate_id" db:"candidate_id"`
-	CriterionID int64     `json:"criterion_id" db:"criterion_id"`
-	Score       int       `json:"score" db:"score"` // Оценка по шкале 1-10
-	Comments    string    `json:"comments" db:"comments"`
-	CreatedAt   time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+	ID            int64     `json:"id" db:"id"`
+	ApplicationID int64     `json:"application_id" db:"application_id"`
+	CandidateID   int64     `json:"candidate_id" db:"candidate_id"`
+	CriterionID   int64     `json:"criterion_id" db:"criterion_id"`
+	Evaluator     string    `json:"evaluator" db:"evaluator"` // Интервьюер, поставивший оценку
+	Score         int       `json:"score" db:"score"`         // Оценка по шкале вакансии
+	Comments      string    `json:"comments" db:"comments"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
 
 	// Поля для JOIN запросов
 	CriterionName string `json:"criterion_name,omitempty" db:"criterion_name"`
 }
 
+// EvaluationRevision представляет сохраненную ревизию оценок кандидата
+type EvaluationRevision struct {
+	ID            int64                    `json:"id" db:"id"`
+	ApplicationID int64                    `json:"application_id" db:"application_id"`
+	CandidateID   int64                    `json:"candidate_id" db:"candidate_id"`
+	Revision      int                      `json:"revision" db:"revision"`
+	Actor         string                   `json:"actor" db:"actor"`
+	CreatedAt     time.Time                `json:"created_at" db:"created_at"`
+	Evaluations   []EvaluationRevisionItem `json:"evaluations"`
+}
+
+// EvaluationRevisionItem представляет оценку по критерию в составе ревизии
+type EvaluationRevisionItem struct {
+	CriterionID   int64  `json:"criterion_id" db:"criterion_id"`
+	CriterionName string `json:"criterion_name" db:"criterion_name"`
+	Evaluator     string `json:"evaluator" db:"evaluator"`
+	Score         int    `json:"score" db:"score"`
+	Comments      string `json:"comments" db:"comments"`
+}
+
+// EvaluationDiff представляет различия оценок между двумя ревизиями
+type EvaluationDiff struct {
+	ApplicationID int64                     `json:"application_id"`
+	CandidateID   int64                     `json:"candidate_id"`
+	FromRevision  int                       `json:"from_revision"`
+	ToRevision    int                       `json:"to_revision"`
+	Criteria      []EvaluationCriterionDiff `json:"criteria"`
+}
+
+// EvaluationCriterionDiff представляет изменение оценки по одному критерию
+type EvaluationCriterionDiff struct {
+	CriterionID   int64  `json:"criterion_id"`
+	CriterionName string `json:"criterion_name"`
+	Evaluator     string `json:"evaluator"`
+	Status        string `json:"status"` // "added", "removed", "changed" или "unchanged"
+	FromScore     *int   `json:"from_score"`
+	ToScore       *int   `json:"to_score"`
+	Delta         int    `json:"delta"`
+	FromComments  string `json:"from_comments,omitempty"`
+	ToComments    string `json:"to_comments,omitempty"`
+}
+
 // Answer представляет ответ кандидата на вопрос
 type Answer struct {
-	ID          int64     `json:"id" db:"id"`
-	CandidateID int64     `json:"candidate_id" db:"candidate_id"`
-	QuestionID  int64     `json:"question_id" db:"question_id"`
-	AnswerText  string    `json:"answer_text" db:"answer_text"`
-	CreatedAt   time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+	ID            int64     `json:"id" db:"id"`
+	ApplicationID int64     `json:"application_id" db:"application_id"`
+	CandidateID   int64     `json:"candidate_id" db:"candidate_id"`
+	QuestionID    int64     `json:"question_id" db:"question_id"`
+	AnswerText    string    `json:"answer_text" db:"answer_text"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
 }
 
-// CandidateWithJob представляет кандидата с информацией о вакансии
+// CandidateWithJob представляет кандидата с информацией о вакансии и отклике на нее
 type CandidateWithJob struct {
 	Candidate
-	JobTitle string `json:"job_title"`
+	JobTitle      string `json:"job_title"`
+	ApplicationID int64  `json:"application_id"`
+	Stage         string `json:"stage"`
+	StagePosition int    `json:"stage_position"`
+}
+
+// CandidateImport результат импорта кандидатов из CSV: проверка каждой строки
+// и, если импорт не пробный, созданные кандидаты
+type CandidateImport struct {
+	JobID    int64                `json:"job_id"`
+	DryRun   bool                 `json:"dry_run"`  // Только проверка, кандидаты не созданы
+	Mapping  map[string]string    `json:"mapping"`  // Поле кандидата -> колонка CSV, по которой оно заполнено
+	Total    int                  `json:"total"`    // Строк с данными в файле
+	Valid    int                  `json:"valid"`    // Строк без ошибок
+	Invalid  int                  `json:"invalid"`  // Строк с ошибками; они не импортируются
+	Imported int                  `json:"imported"` // Создано кандидатов (0 при dry_run)
+	Rows     []CandidateImportRow `json:"rows"`
+}
+
+// CandidateImportRow строка CSV с найденными в ней ошибками
+type CandidateImportRow struct {
+	Line        int                    `json:"line"` // Номер строки в файле, шапка - строка 1
+	Name        string                 `json:"name"`
+	Email       string                 `json:"email"`
+	Phone       string                 `json:"phone"`
+	Description string                 `json:"description"`
+	Valid       bool                   `json:"valid"`
+	Errors      []CandidateImportError `json:"errors"`
+	CandidateID *int64                 `json:"candidate_id,omitempty"` // Созданный кандидат
+}
+
+// CandidateImportError ошибка в поле строки импорта
+type CandidateImportError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"` // "required", "invalid_email" или "duplicate"
+	Message string `json:"message"`
+}
+
+// EvaluationForm представляет оценочный лист интервьюера: критерии вакансии с рубриками
+// и уже поставленные интервьюером оценки
+type EvaluationForm struct {
+	ApplicationID int64                     `json:"application_id"`
+	CandidateID   int64                     `json:"candidate_id"`
+	CandidateName string                    `json:"candidate_name"`
+	JobID         int64                     `json:"job_id"`
+	JobTitle      string                    `json:"job_title"`
+	Evaluator     string                    `json:"evaluator"`
+	Scale         ScoringScale              `json:"scale"`
+	Criteria      []EvaluationFormCriterion `json:"criteria"`
+}
+
+// EvaluationFormCriterion критерий оценочного листа с текущей оценкой интервьюера
+type EvaluationFormCriterion struct {
+	Criterion
+	Evaluation *Evaluation `json:"evaluation"` // null, если интервьюер еще не оценил критерий
 }
 
 // EvaluationSummary представляет сводку оценок кандидата
 type EvaluationSummary struct {
-	CandidateID   int64          `json:"candidate_id"`
-	CandidateName string         `json:"candidate_name"`
-	JobTitle      string         `json:"job_title"`
-	Evaluations   []Evaluation   `json:"evaluations"`
-	AverageScore  float64        `json:"average_score"`
-	ChartData     map[string]int `json:"chart_data"` // Данные для радар-диаграммы
+	ApplicationID   int64                   `json:"application_id"`
+	CandidateID     int64                   `json:"candidate_id"`
+	CandidateName   string                  `json:"candidate_name"`
+	JobTitle        string                  `json:"job_title"`
+	Evaluations     []Evaluation            `json:"evaluations"`
+	Evaluators      []string                `json:"evaluators"`
+	Criteria        []CriterionScoreSummary `json:"criteria"`
+	AverageScore    float64                 `json:"average_score"`            // Среднее по критериям из средних оценок интервьюеров
+	WeightedScore   float64                 `json:"weighted_score"`           // То же среднее с учетом весов критериев; по нему упорядочена сводка
+	NormalizedScore float64                 `json:"normalized_score"`         // weighted_score в процентах шкалы (0-100) для сравнения между вакансиями
+	AdjustedScore   *float64                `json:"adjusted_score,omitempty"` // weighted_score по оценкам с поправкой на строгость интервьюеров (normalize=zscore)
+	Scale           ScoringScale            `json:"scale"`                    // Шкала вакансии: границы осей диаграммы
+	HasDisagreement bool                    `json:"has_disagreement"`         // Хотя бы по одному критерию интервьюеры сильно расходятся
+	KnockoutFailed  bool                    `json:"knockout_failed"`          // Кандидат не прошел хотя бы один отсеивающий критерий; такие кандидаты идут в конце сводки
+	Knockouts       []KnockoutFailure       `json:"knockouts"`                // Непройденные отсеивающие критерии
+	Ranking         CandidateRanking        `json:"ranking"`                  // Место кандидата по выбранному методу ранжирования и вклад критериев
+	ChartData       map[string]float64      `json:"chart_data"`               // Данные для радар-диаграммы: средняя оценка по критерию в единицах шкалы
+}
+
+// CriterionScoreSummary сводит оценки интервьюеров по одному критерию
+type CriterionScoreSummary struct {
+	CriterionID   int64            `json:"criterion_id"`
+	CriterionName string           `json:"criterion_name"`
+	Weight        float64          `json:"weight"`
+	Count         int              `json:"count"`
+	Mean          float64          `json:"mean"`
+	Normalized    float64          `json:"normalized"` // Среднее в процентах шкалы (0-100)
+	Median        float64          `json:"median"`
+	Min           int              `json:"min"`
+	Max           int              `json:"max"`
+	Spread        int              `json:"spread"`  // Разница между максимальной и минимальной оценкой
+	StdDev        float64          `json:"std_dev"` // Стандартное отклонение оценок
+	Disagreement  bool             `json:"disagreement"`
+	AdjustedMean  *float64         `json:"adjusted_mean,omitempty"` // Среднее скорректированных оценок (normalize=zscore)
+	Scores        []EvaluatorScore `json:"scores"`
+}
+
+// KnockoutFailure непройденный отсеивающий критерий
+type KnockoutFailure struct {
+	CriterionID   int64   `json:"criterion_id"`
+	CriterionName string  `json:"criterion_name"`
+	MinScore      int     `json:"min_score"`
+	Score         float64 `json:"score"` // Средняя оценка интервьюеров по критерию
+}
+
+// CandidateRanking объясняет место кандидата в сводке по методу ранжирования
+type CandidateRanking struct {
+	Method            string                  `json:"method"` // "weighted", "topsis" или "ahp"
+	Rank              int                     `json:"rank"`
+	Score             float64                 `json:"score"`                         // weighted - взвешенная оценка, topsis - коэффициент близости, ahp - приоритет кандидата
+	IdealDistance     *float64                `json:"ideal_distance,omitempty"`      // topsis: расстояние до идеального кандидата
+	AntiIdealDistance *float64                `json:"anti_ideal_distance,omitempty"` // topsis: расстояние до антиидеального кандидата
+	ConsistencyRatio  *float64                `json:"consistency_ratio,omitempty"`   // ahp: согласованность попарных сравнений критериев
+	Contributions     []CriterionContribution `json:"contributions"`
+}
+
+// CriterionContribution вклад критерия в место кандидата. Для weighted и ahp вклады
+// в сумме дают score; для topsis положительный вклад приближает кандидата к идеалу,
+// отрицательный - к антиидеалу
+type CriterionContribution struct {
+	CriterionID   int64    `json:"criterion_id"`
+	CriterionName string   `json:"criterion_name"`
+	Value         *float64 `json:"value"`  // Средняя оценка в единицах шкалы, null если критерий не оценен
+	Weight        float64  `json:"weight"` // Вес критерия в методе, веса в сумме дают 1
+	Contribution  float64  `json:"contribution"`
+}
+
+// CriterionComparison попарное сравнение важности критериев по шкале Саати
+type CriterionComparison struct {
+	CriterionA int64   `json:"criterion_a"`
+	CriterionB int64   `json:"criterion_b"`
+	Value      float64 `json:"value"` // Во сколько раз A важнее B: от 1/9 до 9
+}
+
+// AHPWeights веса критериев вакансии, выведенные методом анализа иерархий
+type AHPWeights struct {
+	JobID            int64                 `json:"job_id"`
+	Criteria         []AHPCriterionWeight  `json:"criteria"`
+	Matrix           [][]float64           `json:"matrix"`      // Матрица попарных сравнений в порядке criteria
+	Comparisons      []CriterionComparison `json:"comparisons"` // Заданные сравнения; остальные пары выводятся из весов критериев
+	LambdaMax        float64               `json:"lambda_max"`
+	ConsistencyRatio float64               `json:"consistency_ratio"`
+	Consistent       bool                  `json:"consistent"` // consistency_ratio не больше 0.1
+}
+
+// AHPCriterionWeight вес критерия из вектора приоритетов AHP
+type AHPCriterionWeight struct {
+	CriterionID   int64   `json:"criterion_id"`
+	CriterionName string  `json:"criterion_name"`
+	Weight        float64 `json:"weight"`
+}
+
+// EvaluatorScore оценка одного интервьюера по критерию
+type EvaluatorScore struct {
+	Evaluator string   `json:"evaluator"`
+	Score     int      `json:"score"`
+	Comments  string   `json:"comments,omitempty"`
+	ZScore    *float64 `json:"z_score,omitempty"`  // Отклонение от обычной оценки интервьюера в его стандартных отклонениях
+	Adjusted  *float64 `json:"adjusted,omitempty"` // Оценка в единицах шкалы с поправкой на строгость интервьюера
+}
+
+// InterviewerCalibration показывает, насколько интервьюер строже или мягче остальных.
+// Оценки разных вакансий сравниваются в процентах шкалы (0-100)
+type InterviewerCalibration struct {
+	Evaluator        string                    `json:"evaluator"`
+	Evaluations      int                       `json:"evaluations"`
+	Mean             float64                   `json:"mean"`
+	StdDev           float64                   `json:"std_dev"`
+	Min              float64                   `json:"min"`
+	Max              float64                   `json:"max"`
+	PoolMean         float64                   `json:"pool_mean"` // Среднее оценок всех интервьюеров
+	PoolStdDev       float64                   `json:"pool_std_dev"`
+	Bias             float64                   `json:"bias"`              // mean - pool_mean: плюс - мягче остальных, минус - строже
+	StandardizedBias float64                   `json:"standardized_bias"` // bias в стандартных отклонениях всех оценок
+	PeerComparisons  int                       `json:"peer_comparisons"`  // Оценки, которые тому же кандидату по тому же критерию поставили и другие
+	PeerBias         *float64                  `json:"peer_bias"`         // Средняя разница с другими интервьюерами на общих оценках, null без них
+	Tendency         string                    `json:"tendency"`          // "lenient", "harsh", "neutral" или "insufficient_data"
+	Calibrated       bool                      `json:"calibrated"`        // Оценок достаточно для поправки в сводке
+	Jobs             []InterviewerJobStatistic `json:"jobs"`
+}
+
+// InterviewerJobStatistic оценки интервьюера по одной вакансии
+type InterviewerJobStatistic struct {
+	JobID          int64   `json:"job_id"`
+	JobTitle       string  `json:"job_title"`
+	Evaluations    int     `json:"evaluations"`
+	Mean           float64 `json:"mean"` // В единицах шкалы вакансии
+	NormalizedMean float64 `json:"normalized_mean"`
+}
+
+// JobReliability представляет согласованность оценок интервьюеров по вакансии
+type JobReliability struct {
+	JobID    int64                  `json:"job_id"`
+	JobTitle string                 `json:"job_title"`
+	Overall  ReliabilityMeasures    `json:"overall"` // По всем критериям вместе
+	Criteria []CriterionReliability `json:"criteria"`
+	Pairs    []EvaluatorPair        `json:"pairs"` // Пары интервьюеров, начиная с наиболее расходящихся
+}
+
+// ReliabilityMeasures меры согласованности оценок интервьюеров
+type ReliabilityMeasures struct {
+	Candidates int      `json:"candidates"` // Кандидаты, оцененные хотя бы двумя интервьюерами
+	Ratings    int      `json:"ratings"`    // Оценки этих кандидатов
+	Evaluators int      `json:"evaluators"`
+	ICC        *float64 `json:"icc"`   // Внутриклассовая корреляция ICC(1), null при недостатке данных
+	Alpha      *float64 `json:"alpha"` // Альфа Криппендорфа (интервальная), null при недостатке данных
+	Level      string   `json:"level"` // "reliable", "tentative", "unreliable" или "insufficient_data"
+}
+
+// CriterionReliability согласованность оценок по одному критерию
+type CriterionReliability struct {
+	CriterionID   int64  `json:"criterion_id"`
+	CriterionName string `json:"criterion_name"`
+	ReliabilityMeasures
+}
+
+// EvaluatorPair расхождение двух интервьюеров на общих оценках (один кандидат, один критерий)
+type EvaluatorPair struct {
+	EvaluatorA  string  `json:"evaluator_a"`
+	EvaluatorB  string  `json:"evaluator_b"`
+	Shared      int     `json:"shared"`
+	MeanAbsDiff float64 `json:"mean_abs_diff"`
+	MeanDiff    float64 `json:"mean_diff"` // Средняя разность A - B: знак показывает, кто оценивает строже
+	MaxDiff     int     `json:"max_diff"`
+}
+
+// CandidateComparison сравнение выбранных кандидатов вакансии бок о бок
+type CandidateComparison struct {
+	JobID      int64                 `json:"job_id"`
+	JobTitle   string                `json:"job_title"`
+	Scale      ScoringScale          `json:"scale"`
+	Candidates []ComparisonCandidate `json:"candidates"` // Порядок кандидатов совпадает с порядком ячеек в строках
+	Criteria   []ComparisonCriterion `json:"criteria"`
+	Questions  []ComparisonQuestion  `json:"questions"`
+}
+
+// ComparisonCandidate кандидат в сравнении
+type ComparisonCandidate struct {
+	CandidateID     int64   `json:"candidate_id"`
+	CandidateName   string  `json:"candidate_name"`
+	ApplicationID   int64   `json:"application_id"`
+	Stage           string  `json:"stage"`
+	WeightedScore   float64 `json:"weighted_score"`
+	NormalizedScore float64 `json:"normalized_score"`
+	KnockoutFailed  bool    `json:"knockout_failed"`
+	Wins            int     `json:"wins"` // Критерии, по которым кандидат лучший
+}
+
+// ComparisonCriterion строка матрицы сравнения: оценки кандидатов по одному критерию
+type ComparisonCriterion struct {
+	CriterionID   int64            `json:"criterion_id"`
+	CriterionName string           `json:"criterion_name"`
+	Weight        float64          `json:"weight"`
+	Winners       []int64          `json:"winners"`   // ID кандидатов с лучшей средней оценкой, пусто если критерий не оценен
+	MaxDelta      float64          `json:"max_delta"` // Разница между лучшей и худшей средней оценкой
+	Cells         []ComparisonCell `json:"cells"`
+}
+
+// ComparisonCell оценки одного кандидата по критерию
+type ComparisonCell struct {
+	CandidateID   int64            `json:"candidate_id"`
+	Mean          *float64         `json:"mean"`            // null, если критерий у кандидата не оценен
+	DeltaFromBest *float64         `json:"delta_from_best"` // mean минус лучшая средняя оценка (0 у лучшего)
+	Scores        []EvaluatorScore `json:"scores"`          // Оценки и комментарии интервьюеров
+}
+
+// ComparisonQuestion ответы кандидатов на один вопрос интервью
+type ComparisonQuestion struct {
+	QuestionID    int64              `json:"question_id"`
+	CriterionID   int64              `json:"criterion_id"`
+	CriterionName string             `json:"criterion_name"`
+	Text          string             `json:"text"`
+	Answers       []ComparisonAnswer `json:"answers"`
+}
+
+// ComparisonAnswer ответ кандидата на вопрос, пустой если ответа нет
+type ComparisonAnswer struct {
+	CandidateID int64  `json:"candidate_id"`
+	AnswerText  string `json:"answer_text"`
+}
+
+// CandidateReport карточка кандидата для PDF-отчета: данные кандидата,
+// оценки по критериям с комментариями и ответы на вопросы интервью
+type CandidateReport struct {
+	Candidate   Candidate
+	Application Application
+	StageName   string
+	Summary     EvaluationSummary // ChartData - данные радар-диаграммы
+	Questions   []ReportQuestion
+}
+
+// ReportQuestion вопрос интервью с ответом кандидата, пустым если ответа нет
+type ReportQuestion struct {
+	CriterionName string
+	Text          string
+	AnswerText    string
+}
+
+// JobReport PDF-отчет по вакансии: сравнение всех кандидатов и карточка каждого из них
+type JobReport struct {
+	Comparison CandidateComparison
+	Candidates []CandidateReport // В порядке сводки, как и кандидаты в сравнении
+}
+
+// AnalyticsFilter ограничивает аналитику найма откликами, поданными в период
+type AnalyticsFilter struct {
+	From *time.Time
+	To   *time.Time
+}
+
+// HiringAnalytics аналитика найма по вакансии или по всем вакансиям
+type HiringAnalytics struct {
+	JobID        int64                `json:"job_id,omitempty"` // Не задан в аналитике по всем вакансиям
+	JobTitle     string               `json:"job_title,omitempty"`
+	Jobs         int                  `json:"jobs"`
+	From         *time.Time           `json:"from,omitempty"`
+	To           *time.Time           `json:"to,omitempty"`
+	Applications int                  `json:"applications"`
+	Active       int                  `json:"active"` // Отклики, еще не получившие итог
+	Hired        int                  `json:"hired"`
+	Rejected     int                  `json:"rejected"`
+	HireRate     float64              `json:"hire_rate"` // Доля принятых среди откликов, %
+	Funnel       []StageAnalytics     `json:"funnel"`
+	TimeToHire   DurationStatistics   `json:"time_to_hire"`
+	Scale        *ScoringScale        `json:"scale,omitempty"` // Шкала вакансии; без нее оценки в процентах шкал
+	Criteria     []CriterionHistogram `json:"criteria"`
+}
+
+// StageAnalytics этап воронки найма
+type StageAnalytics struct {
+	Key            string   `json:"key"`
+	Name           string   `json:"name"`
+	Count          int      `json:"count"`           // Отклики на этапе сейчас
+	Reached        int      `json:"reached"`         // Отклики, дошедшие до этапа
+	ReachedRate    float64  `json:"reached_rate"`    // Доля дошедших среди всех откликов, %
+	ConversionRate *float64 `json:"conversion_rate"` // Доля дошедших среди прошедших предыдущий этап, %
+	MedianDays     *float64 `json:"median_days"`     // Медиана дней на этапе до перехода на следующий
+}
+
+// DurationStatistics длительность в днях
+type DurationStatistics struct {
+	Count      int      `json:"count"`
+	MedianDays *float64 `json:"median_days"`
+	MeanDays   *float64 `json:"mean_days"`
+	MinDays    *float64 `json:"min_days"`
+	MaxDays    *float64 `json:"max_days"`
+}
+
+// CriterionHistogram распределение оценок по критерию
+type CriterionHistogram struct {
+	CriterionID   int64             `json:"criterion_id,omitempty"` // Не задан, если критерии вакансий объединены по названию
+	CriterionName string            `json:"criterion_name"`
+	Count         int               `json:"count"`
+	Mean          *float64          `json:"mean"`
+	Buckets       []HistogramBucket `json:"buckets"`
+}
+
+// HistogramBucket интервал гистограммы оценок, границы включительно
+type HistogramBucket struct {
+	Label string  `json:"label"`
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Count int     `json:"count"`
+}
+
+// ValidityInsights показывает, какие критерии и вопросы отличают принятых кандидатов от отказников
+// и связаны с оценкой работы после найма. Учитываются только отклики с итогом: hired или rejected
+type ValidityInsights struct {
+	JobID      int64               `json:"job_id,omitempty"` // Не задан в отчете по всем вакансиям
+	JobTitle   string              `json:"job_title,omitempty"`
+	Jobs       int                 `json:"jobs"`
+	Hired      int                 `json:"hired"`
+	Rejected   int                 `json:"rejected"`
+	RatedHires int                 `json:"rated_hires"` // Принятые с оценкой работы
+	Criteria   []CriterionValidity `json:"criteria"`    // От лучше всего разделяющих к бесполезным
+	Questions  []QuestionValidity  `json:"questions"`
+}
+
+// CriterionValidity прогностическая сила критерия. Оценки - средние по интервьюерам в процентах шкалы
+type CriterionValidity struct {
+	Rank                   int      `json:"rank"`
+	CriterionID            int64    `json:"criterion_id,omitempty"` // Не задан, если критерии вакансий объединены по названию
+	CriterionName          string   `json:"criterion_name"`
+	Templates              []string `json:"templates"` // Шаблоны вакансий с этим критерием
+	Hired                  int      `json:"hired"`     // Принятые с оценкой по критерию
+	Rejected               int      `json:"rejected"`
+	HiredMean              *float64 `json:"hired_mean"`
+	RejectedMean           *float64 `json:"rejected_mean"`
+	Difference             *float64 `json:"difference"`  // hired_mean - rejected_mean
+	Correlation            *float64 `json:"correlation"` // Точечно-бисериальная корреляция оценки с наймом
+	AUC                    *float64 `json:"auc"`         // Вероятность, что у принятого оценка выше, чем у отказника
+	PerformanceRated       int      `json:"performance_rated"`
+	PerformanceCorrelation *float64 `json:"performance_correlation"` // Корреляция оценки с оценкой работы принятых
+	Discrimination         string   `json:"discrimination"`          // "strong", "moderate", "weak" или "insufficient_data"
+}
+
+// QuestionValidity связь использования вопроса с итогом найма. Вопрос считается заданным,
+// если кандидат на него ответил
+type QuestionValidity struct {
+	Rank                   int      `json:"rank"`
+	QuestionID             int64    `json:"question_id,omitempty"` // Не задан, если вопросы вакансий объединены по тексту
+	CriterionName          string   `json:"criterion_name"`
+	Text                   string   `json:"text"`
+	Templates              []string `json:"templates"` // Шаблоны вакансий с этим вопросом
+	Hired                  int      `json:"hired"`     // Принятые и отказники вакансий, где есть вопрос
+	Rejected               int      `json:"rejected"`
+	HiredAsked             int      `json:"hired_asked"`
+	RejectedAsked          int      `json:"rejected_asked"`
+	HiredUsage             *float64 `json:"hired_usage"` // Доля принятых, которым задали вопрос, %
+	RejectedUsage          *float64 `json:"rejected_usage"`
+	Correlation            *float64 `json:"correlation"` // Коэффициент фи между использованием вопроса и наймом
+	PerformanceRated       int      `json:"performance_rated"`
+	PerformanceCorrelation *float64 `json:"performance_correlation"` // Корреляция использования вопроса с оценкой работы принятых
+	Discrimination         string   `json:"discrimination"`
 }
 
 // JobWithCriteria представляет вакансию с критериями
@@ -104,8 +669,136 @@ type JobWithCriteria struct {
 
 // CriterionUpdate представляет данные для обновления критерия
 type CriterionUpdate struct {
-	Name         string `json:"name"`
-	DisplayOrder int    `json:"display_order"`
+	Name         string        `json:"name"`
+	DisplayOrder int           `json:"display_order"`
+	Weight       *float64      `json:"weight,omitempty"`    // Если не задан, вес не меняется
+	Rubric       []RubricLevel `json:"rubric,omitempty"`    // Если не задана, рубрика не меняется; пустой список очищает ее
+	MustHave     *bool         `json:"must_have,omitempty"` // Если не задан, не меняется; min_score без must_have делает критерий отсеивающим
+	MinScore     *int          `json:"min_score,omitempty"` // Если не задана, порог не меняется
+}
+
+// CriterionInput описывает критерий при полной замене критериев вакансии.
+// Принимает как строку с названием, так и объект с названием, весом, рубрикой и порогом
+type CriterionInput struct {
+	Name     string        `json:"name"`
+	Weight   *float64      `json:"weight,omitempty"`    // Если не задан, вес сохраняется (у нового критерия - 1)
+	Rubric   []RubricLevel `json:"rubric,omitempty"`    // Если не задана, рубрика сохраняется; пустой список очищает ее
+	MustHave *bool         `json:"must_have,omitempty"` // Если не задан, сохраняется; min_score без must_have делает критерий отсеивающим
+	MinScore *int          `json:"min_score,omitempty"` // Если не задана, порог сохраняется
+}
+
+// UnmarshalJSON разбирает критерий из строки или объекта
+func (c *CriterionInput) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*c = CriterionInput{Name: name}
+		return nil
+	}
+
+	type criterionInput CriterionInput
+	var input criterionInput
+	if err := json.Unmarshal(data, &input); err != nil {
+		return err
+	}
+	*c = CriterionInput(input)
+	return nil
+}
+
+// Backup представляет снимок базы данных
+type Backup struct {
+	Name      string    `json:"name"`
+	Kind      string    `json:"kind"` // "manual", "scheduled" или "pre-restore"
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Attachment представляет файл, приложенный к кандидату (резюме, портфолио и т.п.)
+type Attachment struct {
+	ID          int64     `json:"id" db:"id"`
+	CandidateID int64     `json:"candidate_id" db:"candidate_id"`
+	FileName    string    `json:"file_name" db:"file_name"`
+	ContentType string    `json:"content_type" db:"content_type"`
+	Size        int64     `json:"size" db:"size"`
+	SHA256      string    `json:"sha256" db:"sha256"` // Хеш содержимого, по нему файл хранится на диске
+	Actor       string    `json:"actor" db:"actor"`
+	HasText     bool      `json:"has_text"`                             // Из файла извлечен текст
+	TextError   string    `json:"text_error,omitempty" db:"text_error"` // Почему текст не извлечен
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// ResumeText представляет текст резюме кандидата, собранный из его вложений
+type ResumeText struct {
+	CandidateID int64              `json:"candidate_id"`
+	Text        string             `json:"text"`
+	Sources     []ResumeTextSource `json:"sources"`
+}
+
+// ResumeTextSource описывает вложение, из которого взят текст
+type ResumeTextSource struct {
+	AttachmentID int64     `json:"attachment_id"`
+	FileName     string    `json:"file_name"`
+	ContentType  string    `json:"content_type"`
+	Length       int       `json:"length"` // Длина текста в символах
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// AuditEntry представляет запись журнала аудита
+type AuditEntry struct {
+	ID          int64           `json:"id" db:"id"`
+	Entity      string          `json:"entity" db:"entity"`
+	EntityID    int64           `json:"entity_id" db:"entity_id"`
+	CandidateID *int64          `json:"candidate_id,omitempty" db:"candidate_id"`
+	Action      string          `json:"action" db:"action"` // "create", "update" или "delete"
+	Actor       string          `json:"actor" db:"actor"`
+	Before      json.RawMessage `json:"before,omitempty" db:"before_json"`
+	After       json.RawMessage `json:"after,omitempty" db:"after_json"`
+	CreatedAt   time.Time       `json:"created_at" db:"created_at"`
+}
+
+// AuditFilter представляет параметры выборки из журнала аудита
+type AuditFilter struct {
+	Entity      string
+	EntityID    int64
+	CandidateID int64
+	Actor       string
+	From        *time.Time
+	To          *time.Time
+	Limit       int
+	Offset      int
+}
+
+// SearchFilter представляет параметры полнотекстового поиска
+type SearchFilter struct {
+	Query string
+	Types []string // Типы сущностей; пустой список означает все типы
+	JobID int64
+	Limit int // Максимум результатов в каждой группе
+}
+
+// SearchResponse представляет результаты поиска, сгруппированные по типам сущностей
+type SearchResponse struct {
+	Query  string        `json:"query"`
+	Total  int           `json:"total"`
+	Groups []SearchGroup `json:"groups"`
+}
+
+// SearchGroup представляет найденные сущности одного типа
+type SearchGroup struct {
+	Type    string         `json:"type"`
+	Total   int            `json:"total"`
+	Results []SearchResult `json:"results"`
+}
+
+// SearchResult представляет найденную сущность с подсвеченным фрагментом текста
+type SearchResult struct {
+	ID          int64   `json:"id"`
+	JobID       int64   `json:"job_id,omitempty"`
+	CandidateID int64   `json:"candidate_id,omitempty"`
+	QuestionID  int64   `json:"question_id,omitempty"`
+	Title       string  `json:"title"`
+	Field       string  `json:"field"`   // Поле, из которого взят фрагмент
+	Snippet     string  `json:"snippet"` // HTML-экранированный текст, совпадения обрамлены <mark>
+	Score       float64 `json:"score"`   // Чем больше, тем релевантнее
 }
 
 // AIRecommendationRequest представляет запрос для AI рекомендаций
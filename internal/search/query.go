@@ -0,0 +1,161 @@
+package search
+
+import (
+	"html"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// maxQueryTerms ограничивает число слов запроса
+	maxQueryTerms = 16
+	// minPrefixLength - наименьшая длина основы, которая ищется как префикс;
+	// более короткие основы совпадают только с таким же словом целиком
+	minPrefixLength = 2
+	// HighlightStart и HighlightEnd обрамляют совпадения во фрагментах
+	HighlightStart = "<mark>"
+	HighlightEnd   = "</mark>"
+	// ellipsis обозначает обрезанный текст во фрагменте
+	ellipsis = "…"
+)
+
+// Query разобранный поисковый запрос: основы слов, каждая из которых
+// ищется как префикс, чтобы находить и недописанные слова. Однобуквенные
+// основы ищутся точно, иначе они совпадали бы с любым словом на эту букву
+type Query struct {
+	Terms []string
+}
+
+// ParseQuery приводит слова запроса к основам, отбрасывая повторы
+func ParseQuery(text string) Query {
+	var query Query
+	seen := make(map[string]bool)
+
+	for _, token := range Tokenize(text) {
+		stem := Stem(token.Text)
+		if stem == "" || seen[stem] {
+			continue
+		}
+		seen[stem] = true
+		query.Terms = append(query.Terms, stem)
+		if len(query.Terms) == maxQueryTerms {
+			break
+		}
+	}
+
+	return query
+}
+
+// Empty сообщает, что в запросе нет ни одного слова
+func (q Query) Empty() bool {
+	return len(q.Terms) == 0
+}
+
+// MatchExpression возвращает выражение для FTS5 MATCH: все основы
+// должны встретиться в документе, каждая как префикс слова
+func (q Query) MatchExpression() string {
+	parts := make([]string, 0, len(q.Terms))
+	for _, term := range q.Terms {
+		if isPrefixTerm(term) {
+			parts = append(parts, `"`+term+`"*`)
+		} else {
+			parts = append(parts, `"`+term+`"`)
+		}
+	}
+	return strings.Join(parts, " AND ")
+}
+
+// Matches сообщает, совпадает ли слово текста с одним из слов запроса
+func (q Query) Matches(word string) bool {
+	stem := Stem(word)
+	for _, term := range q.Terms {
+		if stem == term || isPrefixTerm(term) && strings.HasPrefix(stem, term) {
+			return true
+		}
+	}
+	return false
+}
+
+// isPrefixTerm сообщает, достаточно ли длинная основа, чтобы искать ее как префикс
+func isPrefixTerm(term string) bool {
+	return utf8.RuneCountInString(term) >= minPrefixLength
+}
+
+// CountMatches возвращает число слов текста, совпавших с запросом
+func (q Query) CountMatches(text string) int {
+	count := 0
+	for _, token := range Tokenize(text) {
+		if q.Matches(token.Text) {
+			count++
+		}
+	}
+	return count
+}
+
+// Snippet возвращает фрагмент текста длиной до maxWords слов с наибольшим
+// числом совпадений. Текст экранируется для HTML, совпадения обрамляются
+// HighlightStart и HighlightEnd
+func (q Query) Snippet(text string, maxWords int) string {
+	tokens := Tokenize(text)
+	if len(tokens) == 0 {
+		return ""
+	}
+
+	matched := make([]bool, len(tokens))
+	var matches []int
+	for i, token := range tokens {
+		if q.Matches(token.Text) {
+			matched[i] = true
+			matches = append(matches, i)
+		}
+	}
+
+	// Окно начинается за несколько слов до совпадения, чтобы был виден контекст
+	start, best := 0, 0
+	for _, m := range matches {
+		from := max(m-3, 0)
+		count := 0
+		for i := from; i < len(tokens) && i < from+maxWords; i++ {
+			if matched[i] {
+				count++
+			}
+		}
+		if count > best {
+			start, best = from, count
+		}
+	}
+	end := min(start+maxWords, len(tokens))
+
+	runes := []rune(text)
+	var b strings.Builder
+
+	from := 0
+	if start > 0 {
+		from = tokens[start].Start
+		b.WriteString(ellipsis)
+	}
+	to := len(runes)
+	if end < len(tokens) {
+		to = tokens[end-1].End
+	}
+
+	pos := from
+	for i := start; i < end; i++ {
+		token := tokens[i]
+		b.WriteString(html.EscapeString(string(runes[pos:token.Start])))
+		word := html.EscapeString(string(runes[token.Start:token.End]))
+		if matched[i] {
+			b.WriteString(HighlightStart + word + HighlightEnd)
+		} else {
+			b.WriteString(word)
+		}
+		pos = token.End
+	}
+	b.WriteString(html.EscapeString(string(runes[pos:to])))
+
+	if end < len(tokens) {
+		b.WriteString(ellipsis)
+	}
+
+	return strings.Join(strings.Fields(b.String()), " ")
+}
@@ -0,0 +1,116 @@
+package search
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"", nil},
+		{" , ! ", nil},
+		{"Разработка разработки", []string{"разработк"}},
+		{"senior Go разработчик", []string{"senior", "go", "разработчик"}},
+		{strings.Repeat("a b c d e f g h i j k l m n o p q r ", 2), strings.Fields("a b c d e f g h i j k l m n o p")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := ParseQuery(tt.text).Terms; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseQuery(%q).Terms = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryMatchExpression(t *testing.T) {
+	query := ParseQuery("знание в разработке")
+	if got, want := query.MatchExpression(), `"знан"* AND "в" AND "разработк"*`; got != want {
+		t.Errorf("MatchExpression() = %q, want %q", got, want)
+	}
+}
+
+func TestQueryMatches(t *testing.T) {
+	query := ParseQuery("разраб в")
+
+	tests := []struct {
+		word string
+		want bool
+	}{
+		{"разработке", true},
+		{"Разработчиками", true},
+		{"в", true},
+		{"вагон", false},
+		{"работа", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.word, func(t *testing.T) {
+			if got := query.Matches(tt.word); got != tt.want {
+				t.Errorf("Matches(%q) = %v, want %v", tt.word, got, tt.want)
+			}
+		})
+	}
+
+	if got := query.CountMatches("В разработке и в разработчиках"); got != 4 {
+		t.Errorf("CountMatches() = %d, want 4", got)
+	}
+}
+
+func TestQuerySnippet(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		text     string
+		maxWords int
+		want     string
+	}{
+		{
+			name:     "whole text",
+			query:    "опыт",
+			text:     "Большой  опыт\nработы",
+			maxWords: 10,
+			want:     "Большой <mark>опыт</mark> работы",
+		},
+		{
+			name:     "window around best match",
+			query:    "go",
+			text:     "один два три четыре пять шесть Go и Go семь восемь",
+			maxWords: 5,
+			want:     "…шесть <mark>Go</mark> и <mark>Go</mark> семь…",
+		},
+		{
+			name:     "html escaped",
+			query:    "sql",
+			text:     "<b>SQL</b> & Go",
+			maxWords: 10,
+			want:     "&lt;b&gt;<mark>SQL</mark>&lt;/b&gt; &amp; Go",
+		},
+		{
+			name:     "no matches",
+			query:    "java",
+			text:     "Go и SQL",
+			maxWords: 2,
+			want:     "Go и…",
+		},
+		{
+			name:     "no words",
+			query:    "go",
+			text:     " - ",
+			maxWords: 5,
+			want:     "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseQuery(tt.query).Snippet(tt.text, tt.maxWords); got != tt.want {
+				t.Errorf("Snippet() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
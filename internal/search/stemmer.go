@@ -0,0 +1,262 @@
+package search
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Tokenize разбивает текст на слова в нижнем регистре вместе с их позициями в рунах
+func Tokenize(text string) []Token {
+	var tokens []Token
+	runes := []rune(text)
+
+	start := -1
+	for i := 0; i <= len(runes); i++ {
+		isWord := i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]))
+		if isWord && start < 0 {
+			start = i
+		}
+		if !isWord && start >= 0 {
+			tokens = append(tokens, Token{
+				Text:  strings.ToLower(string(runes[start:i])),
+				Start: start,
+				End:   i,
+			})
+			start = -1
+		}
+	}
+
+	return tokens
+}
+
+// Token представляет слово исходного текста
+type Token struct {
+	Text  string
+	Start int // Позиция первой руны слова
+	End   int // Позиция руны после слова
+}
+
+// StemText приводит все слова текста к основам и соединяет их пробелами.
+// Результат используется для индексации в FTS5 вместо исходного текста
+func StemText(text string) string {
+	tokens := Tokenize(text)
+	stems := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		stems = append(stems, Stem(token.Text))
+	}
+	return strings.Join(stems, " ")
+}
+
+// Stem возвращает основу слова: русские слова обрабатываются стеммером
+// Snowball, латинские - упрощенным английским стеммером
+func Stem(word string) string {
+	word = strings.ToLower(word)
+	runes := []rune(strings.ReplaceAll(word, "ё", "е"))
+
+	for _, r := range runes {
+		if r >= 'а' && r <= 'я' {
+			return string(stemRussian(runes))
+		}
+	}
+
+	return stemEnglish(string(runes))
+}
+
+// Окончания русского стеммера Snowball. Окончания первой группы допустимы
+// только после "а" или "я"
+var (
+	perfectiveGerund1 = []string{"в", "вши", "вшись"}
+	perfectiveGerund2 = []string{"ив", "ивши", "ившись", "ыв", "ывши", "ывшись"}
+	adjective         = []string{"ее", "ие", "ые", "ое", "ими", "ыми", "ей", "ий", "ый", "ой", "ем", "им", "ым", "ом", "его", "ого", "ему", "ому", "их", "ых", "ую", "юю", "ая", "яя", "ою", "ею"}
+	participle1       = []string{"ем", "нн", "вш", "ющ", "щ"}
+	participle2       = []string{"ивш", "ывш", "ующ"}
+	reflexive         = []string{"ся", "сь"}
+	verb1             = []string{"ла", "на", "ете", "йте", "ли", "й", "л", "ем", "н", "ло", "но", "ет", "ют", "ны", "ть", "ешь", "нно"}
+	verb2             = []string{"ила", "ыла", "ена", "ейте", "уйте", "ите", "или", "ыли", "ей", "уй", "ил", "ыл", "им", "ым", "ен", "ило", "ыло", "ено", "ят", "ует", "уют", "ит", "ыт", "ены", "ить", "ыть", "ишь", "ую", "ю"}
+	noun              = []string{"а", "ев", "ов", "ие", "ье", "е", "иями", "ями", "ами", "еи", "ии", "и", "ией", "ей", "ой", "ий", "й", "иям", "ям", "ием", "ем", "ам", "ом", "о", "у", "ах", "иях", "ях", "ы", "ь", "ию", "ью", "ю", "ия", "ья", "я"}
+	derivational      = []string{"ост", "ость"}
+	superlative       = []string{"ейш", "ейше"}
+)
+
+// suffixGroup набор окончаний, из которых выбирается самое длинное совпадение
+type suffixGroup struct {
+	suffixes [][]rune
+	afterAYa []bool
+}
+
+func newSuffixGroup(afterAYa []string, plain ...[]string) suffixGroup {
+	var group suffixGroup
+	for _, suffix := range afterAYa {
+		group.suffixes = append(group.suffixes, []rune(suffix))
+		group.afterAYa = append(group.afterAYa, true)
+	}
+	for _, list := range plain {
+		for _, suffix := range list {
+			group.suffixes = append(group.suffixes, []rune(suffix))
+			group.afterAYa = append(group.afterAYa, false)
+		}
+	}
+
+	// Самые длинные окончания проверяются первыми
+	order := make([]int, len(group.suffixes))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return len(group.suffixes[order[i]]) > len(group.suffixes[order[j]])
+	})
+	sorted := suffixGroup{}
+	for _, i := range order {
+		sorted.suffixes = append(sorted.suffixes, group.suffixes[i])
+		sorted.afterAYa = append(sorted.afterAYa, group.afterAYa[i])
+	}
+
+	return sorted
+}
+
+var (
+	perfectiveGerundGroup = newSuffixGroup(perfectiveGerund1, perfectiveGerund2)
+	adjectiveGroup        = newSuffixGroup(nil, adjective)
+	participleGroup       = newSuffixGroup(participle1, participle2)
+	reflexiveGroup        = newSuffixGroup(nil, reflexive)
+	verbGroup             = newSuffixGroup(verb1, verb2)
+	nounGroup             = newSuffixGroup(nil, noun)
+	derivationalGroup     = newSuffixGroup(nil, derivational)
+	superlativeGroup      = newSuffixGroup(nil, superlative)
+)
+
+// match ищет самое длинное окончание группы, начинающееся не раньше limit.
+// Возвращает длину слова без окончания или -1, если подходящего окончания нет
+func (g suffixGroup) match(word []rune, limit int) int {
+	for i, suffix := range g.suffixes {
+		if !hasSuffix(word, suffix) {
+			continue
+		}
+		pos := len(word) - len(suffix)
+		if pos < limit {
+			// Как и в Snowball, более короткие окончания после найденного не проверяются
+			return -1
+		}
+		if g.afterAYa[i] {
+			if pos-1 < limit || (word[pos-1] != 'а' && word[pos-1] != 'я') {
+				return -1
+			}
+		}
+		return pos
+	}
+	return -1
+}
+
+func hasSuffix(word, suffix []rune) bool {
+	if len(suffix) > len(word) {
+		return false
+	}
+	offset := len(word) - len(suffix)
+	for i, r := range suffix {
+		if word[offset+i] != r {
+			return false
+		}
+	}
+	return true
+}
+
+func isRussianVowel(r rune) bool {
+	switch r {
+	case 'а', 'е', 'и', 'о', 'у', 'ы', 'э', 'ю', 'я':
+		return true
+	}
+	return false
+}
+
+// stemRussian реализует русский стеммер Snowball
+func stemRussian(word []rune) []rune {
+	// RV - часть слова после первой гласной, R2 - вторая область R
+	rv := len(word)
+	for i, r := range word {
+		if isRussianVowel(r) {
+			rv = i + 1
+			break
+		}
+	}
+	r1 := regionAfter(word, 0)
+	r2 := regionAfter(word, r1)
+
+	// Шаг 1: деепричастия, либо возвратные частицы и окончания прилагательных, глаголов, существительных
+	if pos := perfectiveGerundGroup.match(word, rv); pos >= 0 {
+		word = word[:pos]
+	} else {
+		if pos := reflexiveGroup.match(word, rv); pos >= 0 {
+			word = word[:pos]
+		}
+		if pos := adjectiveGroup.match(word, rv); pos >= 0 {
+			word = word[:pos]
+			if pos := participleGroup.match(word, rv); pos >= 0 {
+				word = word[:pos]
+			}
+		} else if pos := verbGroup.match(word, rv); pos >= 0 {
+			word = word[:pos]
+		} else if pos := nounGroup.match(word, rv); pos >= 0 {
+			word = word[:pos]
+		}
+	}
+
+	// Шаг 2: конечная "и"
+	if len(word) > rv && word[len(word)-1] == 'и' {
+		word = word[:len(word)-1]
+	}
+
+	// Шаг 3: словообразовательные окончания в R2
+	if pos := derivationalGroup.match(word, r2); pos >= 0 {
+		word = word[:pos]
+	}
+
+	// Шаг 4: превосходная степень, удвоенная "н" и мягкий знак
+	if pos := superlativeGroup.match(word, rv); pos >= 0 {
+		word = word[:pos]
+		if len(word)-2 >= rv && hasSuffix(word, []rune("нн")) {
+			word = word[:len(word)-1]
+		}
+	} else if len(word)-2 >= rv && hasSuffix(word, []rune("нн")) {
+		word = word[:len(word)-1]
+	} else if len(word) > rv && word[len(word)-1] == 'ь' {
+		word = word[:len(word)-1]
+	}
+
+	return word
+}
+
+// regionAfter возвращает начало области R: после первой согласной,
+// следующей за гласной, начиная с позиции start
+func regionAfter(word []rune, start int) int {
+	for i := start + 1; i < len(word); i++ {
+		if !isRussianVowel(word[i]) && isRussianVowel(word[i-1]) {
+			return i + 1
+		}
+	}
+	return len(word)
+}
+
+// stemEnglish отбрасывает наиболее частые английские окончания
+func stemEnglish(word string) string {
+	if len(word) <= 3 {
+		return word
+	}
+
+	switch {
+	case strings.HasSuffix(word, "sses"):
+		return word[:len(word)-2]
+	case strings.HasSuffix(word, "ies"):
+		return word[:len(word)-3] + "y"
+	case strings.HasSuffix(word, "ing") && len(word) > 5:
+		return word[:len(word)-3]
+	case strings.HasSuffix(word, "ed") && len(word) > 4:
+		return word[:len(word)-2]
+	case strings.HasSuffix(word, "ss") || strings.HasSuffix(word, "us"):
+		return word
+	case strings.HasSuffix(word, "s"):
+		return word[:len(word)-1]
+	}
+
+	return word
+}
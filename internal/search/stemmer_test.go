@@ -0,0 +1,69 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStem(t *testing.T) {
+	// Русские пары взяты из эталонного словаря стеммера Snowball
+	tests := []struct {
+		word string
+		want string
+	}{
+		{"вагон", "вагон"},
+		{"вагонов", "вагон"},
+		{"важнейшими", "важн"},
+		{"важничаешь", "важнича"},
+		{"важности", "важност"},
+		{"важностью", "важност"},
+		{"валерьяна", "валерья"},
+		{"валетами", "валет"},
+		{"валился", "вал"},
+		{"валилось", "вал"},
+		{"разработка", "разработк"},
+		{"разработки", "разработк"},
+		{"разработке", "разработк"},
+		{"программирование", "программирован"},
+		{"собеседованиях", "собеседован"},
+		{"кошками", "кошк"},
+		{"бегущий", "бегущ"},
+		{"красивейшая", "красив"},
+		{"Ёлка", "елк"},
+		{"в", "в"},
+		{"testing", "test"},
+		{"classes", "class"},
+		{"stories", "story"},
+		{"jumped", "jump"},
+		{"bus", "bus"},
+		{"go", "go"},
+		{"42", "42"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.word, func(t *testing.T) {
+			if got := Stem(tt.word); got != tt.want {
+				t.Errorf("Stem(%q) = %q, want %q", tt.word, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	got := Tokenize("Go-разработчик, 5 лет")
+	want := []Token{
+		{Text: "go", Start: 0, End: 2},
+		{Text: "разработчик", Start: 3, End: 14},
+		{Text: "5", Start: 16, End: 17},
+		{Text: "лет", Start: 18, End: 21},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Tokenize() = %+v, want %+v", got, want)
+	}
+}
+
+func TestStemText(t *testing.T) {
+	if got, want := StemText("Опытные разработчики, Testing!"), "опытн разработчик test"; got != want {
+		t.Errorf("StemText() = %q, want %q", got, want)
+	}
+}
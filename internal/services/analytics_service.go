@@ -0,0 +1,493 @@
+package services
+
+import (
+	"choizee/internal/database"
+	"choizee/internal/models"
+	"choizee/internal/stats"
+	"database/sql"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+)
+
+// percentBuckets интервалов по 100 / percentBuckets процентов в гистограммах по всем вакансиям
+const percentBuckets = 10
+
+type AnalyticsService struct {
+	db *database.DB
+}
+
+func NewAnalyticsService(db *database.DB) *AnalyticsService {
+	return &AnalyticsService{db: db}
+}
+
+// analyticsApplication отклик с историей переходов по этапам
+type analyticsApplication struct {
+	id          int64
+	jobID       int64
+	stage       string
+	createdAt   time.Time
+	transitions []analyticsTransition
+}
+
+// analyticsTransition переход отклика между этапами
+type analyticsTransition struct {
+	from string
+	to   string
+	at   time.Time
+}
+
+// analyticsRating оценка отклика по критерию
+type analyticsRating struct {
+	applicationID int64
+	criterionID   int64
+	criterionName string
+	jobID         int64
+	score         int
+}
+
+// funnelCounter накапливает показатели этапа воронки
+type funnelCounter struct {
+	stage    models.StageAnalytics
+	eligible int // Отклики, прошедшие предыдущий этап в воронке своей вакансии
+	days     []float64
+}
+
+// GetJobAnalytics считает воронку, распределения оценок по критериям и время найма по вакансии
+func (s *AnalyticsService) GetJobAnalytics(jobID int64, filter models.AnalyticsFilter) (*models.HiringAnalytics, error) {
+	analytics := &models.HiringAnalytics{JobID: jobID, Jobs: 1}
+	err := s.db.QueryRow("SELECT title FROM jobs WHERE id = ?", jobID).Scan(&analytics.JobTitle)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("job not found")
+		}
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+
+	stages, err := loadJobStages(s.db, jobID)
+	if err != nil {
+		return nil, err
+	}
+	applications, err := loadAnalyticsApplications(s.db, jobID, filter)
+	if err != nil {
+		return nil, err
+	}
+	ratings, err := loadAnalyticsRatings(s.db, jobID, applications)
+	if err != nil {
+		return nil, err
+	}
+	scale, err := loadJobScale(s.db, jobID)
+	if err != nil {
+		return nil, err
+	}
+	criteria, err := loadJobCriteria(s.db, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	fillHiringAnalytics(analytics, filter, map[int64][]models.JobStage{jobID: stages}, applications)
+	analytics.Scale = &scale
+
+	// Столбец гистограммы на каждое значение шкалы
+	var values []models.ScaleLevel
+	if len(scale.Levels) > 0 {
+		values = scale.Levels
+	} else {
+		for v := scale.Min; v <= scale.Max; v++ {
+			values = append(values, models.ScaleLevel{Value: v, Label: fmt.Sprint(v)})
+		}
+	}
+
+	scores := make(map[int64][]float64)
+	for _, rating := range ratings {
+		scores[rating.criterionID] = append(scores[rating.criterionID], float64(rating.score))
+	}
+
+	analytics.Criteria = make([]models.CriterionHistogram, len(criteria))
+	for i, criterion := range criteria {
+		histogram := models.CriterionHistogram{
+			CriterionID:   criterion.ID,
+			CriterionName: criterion.Name,
+			Buckets:       make([]models.HistogramBucket, len(values)),
+		}
+		for j, level := range values {
+			histogram.Buckets[j] = models.HistogramBucket{Label: level.Label, Min: float64(level.Value), Max: float64(level.Value)}
+		}
+		fillHistogram(&histogram, scores[criterion.ID])
+		analytics.Criteria[i] = histogram
+	}
+
+	return analytics, nil
+}
+
+// GetAnalytics считает те же показатели по всем вакансиям. Этапы разных вакансий объединяются
+// по ключу, а критерии - по названию; оценки переводятся в проценты шкал вакансий
+func (s *AnalyticsService) GetAnalytics(filter models.AnalyticsFilter) (*models.HiringAnalytics, error) {
+	analytics := &models.HiringAnalytics{}
+
+	rows, err := s.db.Query("SELECT id, scale_type, scale_levels FROM jobs ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get jobs: %w", err)
+	}
+	defer rows.Close()
+
+	scales := make(map[int64]models.ScoringScale)
+	var jobIDs []int64
+	for rows.Next() {
+		var jobID int64
+		var scaleType string
+		var levels sql.NullString
+		if err := rows.Scan(&jobID, &scaleType, &levels); err != nil {
+			return nil, fmt.Errorf("failed to scan job: %w", err)
+		}
+		if scales[jobID], err = scaleFromColumns(scaleType, levels); err != nil {
+			return nil, err
+		}
+		jobIDs = append(jobIDs, jobID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read jobs: %w", err)
+	}
+	analytics.Jobs = len(jobIDs)
+
+	stages := make(map[int64][]models.JobStage, len(jobIDs))
+	for _, jobID := range jobIDs {
+		if stages[jobID], err = loadJobStages(s.db, jobID); err != nil {
+			return nil, err
+		}
+	}
+	applications, err := loadAnalyticsApplications(s.db, 0, filter)
+	if err != nil {
+		return nil, err
+	}
+	ratings, err := loadAnalyticsRatings(s.db, 0, applications)
+	if err != nil {
+		return nil, err
+	}
+
+	fillHiringAnalytics(analytics, filter, stages, applications)
+
+	// Критерии с одинаковым названием объединяются, порядок - по первому появлению
+	var names []string
+	scores := make(map[string][]float64)
+	for _, rating := range ratings {
+		key := strings.ToLower(strings.TrimSpace(rating.criterionName))
+		if _, ok := scores[key]; !ok {
+			names = append(names, rating.criterionName)
+		}
+		scores[key] = append(scores[key], normalizeScore(scales[rating.jobID], float64(rating.score)))
+	}
+
+	analytics.Criteria = make([]models.CriterionHistogram, len(names))
+	for i, name := range names {
+		histogram := models.CriterionHistogram{
+			CriterionName: name,
+			Buckets:       make([]models.HistogramBucket, percentBuckets),
+		}
+		for j := range histogram.Buckets {
+			low, high := float64(j*100/percentBuckets), float64((j+1)*100/percentBuckets)
+			histogram.Buckets[j] = models.HistogramBucket{Label: fmt.Sprintf("%.0f-%.0f%%", low, high), Min: low, Max: high}
+		}
+		fillHistogram(&histogram, scores[strings.ToLower(strings.TrimSpace(name))])
+		analytics.Criteria[i] = histogram
+	}
+
+	return analytics, nil
+}
+
+// fillHiringAnalytics считает итоги откликов, воронку и время найма.
+// stages - этапы вакансий откликов в порядке отображения
+func fillHiringAnalytics(analytics *models.HiringAnalytics, filter models.AnalyticsFilter, stages map[int64][]models.JobStage, applications []analyticsApplication) {
+	analytics.From = filter.From
+	analytics.To = filter.To
+	analytics.Applications = len(applications)
+
+	counters := funnelCounters(stages)
+	var hireDays []float64
+	for _, application := range applications {
+		switch application.stage {
+		case StageHired:
+			analytics.Hired++
+		case StageRejected:
+			analytics.Rejected++
+		default:
+			analytics.Active++
+		}
+
+		// Рабочие этапы вакансии по порядку, за ними hired
+		var pipeline []string
+		for _, stage := range stages[application.jobID] {
+			if !isOutcomeStage(stage.Key) {
+				pipeline = append(pipeline, stage.Key)
+			}
+		}
+		pipeline = append(pipeline, StageHired)
+
+		// Кандидат мог перескочить этапы, поэтому дошедшим до этапа считается каждый,
+		// кто побывал на нем или дальше по воронке
+		furthest := 0
+		rejected := application.stage == StageRejected
+		visit := func(stage string) {
+			furthest = max(furthest, slices.Index(pipeline, stage))
+			rejected = rejected || stage == StageRejected
+		}
+		visit(application.stage)
+		for _, transition := range application.transitions {
+			visit(transition.from)
+			visit(transition.to)
+		}
+		for i, key := range pipeline {
+			counter := counters[key]
+			if i <= furthest {
+				counter.stage.Reached++
+			}
+			if i > 0 && i-1 <= furthest {
+				counter.eligible++
+			}
+		}
+		if rejected {
+			counters[StageRejected].stage.Reached++
+		}
+		if counter, ok := counters[application.stage]; ok {
+			counter.stage.Count++
+		}
+
+		// Время на этапе - от перехода на него до перехода дальше; незавершенное пребывание не учитывается
+		enteredAt := application.createdAt
+		for _, transition := range application.transitions {
+			if counter, ok := counters[transition.from]; ok {
+				counter.days = append(counter.days, days(transition.at.Sub(enteredAt)))
+			}
+			enteredAt = transition.at
+			if transition.to == StageHired && application.stage == StageHired {
+				hireDays = append(hireDays, days(transition.at.Sub(application.createdAt)))
+			}
+		}
+	}
+
+	analytics.Funnel = make([]models.StageAnalytics, 0, len(counters))
+	for _, key := range stageOrder(stages) {
+		counter := counters[key]
+		stage := counter.stage
+		if analytics.Applications > 0 {
+			stage.ReachedRate = percent(stage.Reached, analytics.Applications)
+		}
+		if counter.eligible > 0 {
+			rate := percent(stage.Reached, counter.eligible)
+			stage.ConversionRate = &rate
+		}
+		if len(counter.days) > 0 {
+			median := stats.Median(counter.days)
+			stage.MedianDays = &median
+		}
+		analytics.Funnel = append(analytics.Funnel, stage)
+	}
+
+	if analytics.Applications > 0 {
+		analytics.HireRate = percent(analytics.Hired, analytics.Applications)
+	}
+	analytics.TimeToHire = durationStatistics(hireDays)
+}
+
+// funnelCounters создает счетчики для каждого этапа вакансий; название берется из первой вакансии с этим этапом
+func funnelCounters(stages map[int64][]models.JobStage) map[string]*funnelCounter {
+	counters := map[string]*funnelCounter{
+		StageNew:      {stage: models.StageAnalytics{Key: StageNew, Name: StageNew}},
+		StageHired:    {stage: models.StageAnalytics{Key: StageHired, Name: StageHired}},
+		StageRejected: {stage: models.StageAnalytics{Key: StageRejected, Name: StageRejected}},
+	}
+	named := make(map[string]bool)
+
+	jobIDs := make([]int64, 0, len(stages))
+	for jobID := range stages {
+		jobIDs = append(jobIDs, jobID)
+	}
+	slices.Sort(jobIDs)
+	for _, jobID := range jobIDs {
+		for _, stage := range stages[jobID] {
+			if _, ok := counters[stage.Key]; !ok {
+				counters[stage.Key] = &funnelCounter{stage: models.StageAnalytics{Key: stage.Key}}
+			}
+			if !named[stage.Key] {
+				counters[stage.Key].stage.Name = stage.Name
+				named[stage.Key] = true
+			}
+		}
+	}
+	return counters
+}
+
+// stageOrder объединяет воронки вакансий в один порядок этапов: этап, которого еще нет,
+// встает после предшествующего ему этапа своей вакансии; hired и rejected - в конце
+func stageOrder(stages map[int64][]models.JobStage) []string {
+	jobIDs := make([]int64, 0, len(stages))
+	for jobID := range stages {
+		jobIDs = append(jobIDs, jobID)
+	}
+	slices.Sort(jobIDs)
+
+	order := []string{StageNew}
+	for _, jobID := range jobIDs {
+		position := 0
+		for _, stage := range stages[jobID] {
+			if isOutcomeStage(stage.Key) {
+				continue
+			}
+			if i := slices.Index(order, stage.Key); i >= 0 {
+				position = i + 1
+				continue
+			}
+			order = slices.Insert(order, position, stage.Key)
+			position++
+		}
+	}
+	return append(order, StageHired, StageRejected)
+}
+
+// fillHistogram раскладывает оценки по столбцам гистограммы. Значение попадает в первый
+// столбец, в границы которого входит; значения вне столбцов не учитываются в столбцах
+func fillHistogram(histogram *models.CriterionHistogram, values []float64) {
+	histogram.Count = len(values)
+	if len(values) > 0 {
+		mean := stats.Mean(values)
+		histogram.Mean = &mean
+	}
+	for _, v := range values {
+		for i := range histogram.Buckets {
+			bucket := &histogram.Buckets[i]
+			if v >= bucket.Min && (v < bucket.Max || v == bucket.Max && (bucket.Min == bucket.Max || i == len(histogram.Buckets)-1)) {
+				bucket.Count++
+				break
+			}
+		}
+	}
+}
+
+// durationStatistics считает медиану, среднее и диапазон длительностей в днях
+func durationStatistics(values []float64) models.DurationStatistics {
+	result := models.DurationStatistics{Count: len(values)}
+	if len(values) == 0 {
+		return result
+	}
+	median, mean := stats.Median(values), stats.Mean(values)
+	low, high := slices.Min(values), slices.Max(values)
+	result.MedianDays, result.MeanDays, result.MinDays, result.MaxDays = &median, &mean, &low, &high
+	return result
+}
+
+// days переводит длительность в дни
+func days(d time.Duration) float64 {
+	return math.Max(0, d.Hours()/24)
+}
+
+// percent возвращает долю part от total в процентах
+func percent(part, total int) float64 {
+	return float64(part) / float64(total) * 100
+}
+
+// loadAnalyticsApplications загружает отклики вакансии (jobID = 0 - всех вакансий), поданные
+// в период фильтра, вместе с историей переходов по этапам
+func loadAnalyticsApplications(q querier, jobID int64, filter models.AnalyticsFilter) ([]analyticsApplication, error) {
+	// Отклики удаленных вакансий не учитываются
+	query := "SELECT a.id, a.job_id, a.stage, a.created_at FROM applications a JOIN jobs j ON j.id = a.job_id"
+	var args []any
+	if jobID != 0 {
+		query += " WHERE a.job_id = ?"
+		args = append(args, jobID)
+	}
+	rows, err := q.Query(query+" ORDER BY a.id", args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get applications: %w", err)
+	}
+	defer rows.Close()
+
+	applications := []analyticsApplication{}
+	index := make(map[int64]int)
+	for rows.Next() {
+		var application analyticsApplication
+		if err := rows.Scan(&application.id, &application.jobID, &application.stage, &application.createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan application: %w", err)
+		}
+		if filter.From != nil && application.createdAt.Before(*filter.From) || filter.To != nil && application.createdAt.After(*filter.To) {
+			continue
+		}
+		index[application.id] = len(applications)
+		applications = append(applications, application)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read applications: %w", err)
+	}
+
+	transitionsQuery := `
+		SELECT t.application_id, t.from_stage, t.to_stage, t.created_at
+		FROM candidate_stage_transitions t
+		JOIN applications a ON a.id = t.application_id
+	`
+	if jobID != 0 {
+		transitionsQuery += " WHERE a.job_id = ?"
+	}
+	transitionRows, err := q.Query(transitionsQuery+" ORDER BY t.created_at ASC, t.id ASC", args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stage transitions: %w", err)
+	}
+	defer transitionRows.Close()
+
+	for transitionRows.Next() {
+		var applicationID int64
+		var transition analyticsTransition
+		if err := transitionRows.Scan(&applicationID, &transition.from, &transition.to, &transition.at); err != nil {
+			return nil, fmt.Errorf("failed to scan stage transition: %w", err)
+		}
+		if i, ok := index[applicationID]; ok {
+			applications[i].transitions = append(applications[i].transitions, transition)
+		}
+	}
+	if err := transitionRows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read stage transitions: %w", err)
+	}
+
+	return applications, nil
+}
+
+// loadAnalyticsRatings загружает текущие оценки выбранных откликов вакансии (jobID = 0 - всех вакансий)
+func loadAnalyticsRatings(q querier, jobID int64, applications []analyticsApplication) ([]analyticsRating, error) {
+	selected := make(map[int64]bool, len(applications))
+	for _, application := range applications {
+		selected[application.id] = true
+	}
+
+	query := `
+		SELECT e.application_id, e.criterion_id, c.name, a.job_id, e.score
+		FROM evaluations e
+		JOIN applications a ON a.id = e.application_id
+		JOIN criteria c ON c.id = e.criterion_id
+	`
+	var args []any
+	if jobID != 0 {
+		query += " WHERE a.job_id = ?"
+		args = append(args, jobID)
+	}
+	rows, err := q.Query(query+" ORDER BY a.job_id, c.display_order, e.id", args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get evaluations: %w", err)
+	}
+	defer rows.Close()
+
+	var ratings []analyticsRating
+	for rows.Next() {
+		var rating analyticsRating
+		if err := rows.Scan(&rating.applicationID, &rating.criterionID, &rating.criterionName, &rating.jobID, &rating.score); err != nil {
+			return nil, fmt.Errorf("failed to scan evaluation: %w", err)
+		}
+		if selected[rating.applicationID] {
+			ratings = append(ratings, rating)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read evaluations: %w", err)
+	}
+
+	return ratings, nil
+}
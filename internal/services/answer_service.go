@@ -3,47 +3,79 @@ package services
 import (
 	"choizee/internal/database"
 	"choizee/internal/models"
+	"database/sql"
+	"fmt"
 	"time"
 )
 
 type AnswerService struct {
-	db *database.DB
+	db    *database.DB
+	audit *AuditService
 }
 
-func NewAnswerService(db *database.DB) *AnswerService {
-	return &AnswerService{db: db}
+func NewAnswerService(db *database.DB, audit *AuditService) *AnswerService {
+	return &AnswerService{db: db, audit: audit}
 }
 
-// SaveAnswer сохраняет ответ кандидата на вопрос
-func (s *AnswerService) SaveAnswer(answer *models.Answer) (*models.Answer, error) {
+// SaveAnswer сохраняет ответ кандидата на вопрос. Если отклик не указан, ответ
+// относится к отклику кандидата на вакансию вопроса
+func (s *AnswerService) SaveAnswer(answer *models.Answer, actor string) (*models.Answer, error) {
+	if answer.ApplicationID == 0 {
+		err := s.db.QueryRow(`
+			SELECT a.id FROM applications a
+			JOIN questions q ON q.job_id = a.job_id
+			WHERE a.candidate_id = ? AND q.id = ?
+		`, answer.CandidateID, answer.QuestionID).Scan(&answer.ApplicationID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return nil, fmt.Errorf("application not found")
+			}
+			return nil, err
+		}
+	}
+
 	now := time.Now()
 	answer.CreatedAt = now
 	answer.UpdatedAt = now
 
 	query := `
-		INSERT INTO answers (candidate_id, question_id, answer_text, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?)
+		INSERT INTO answers (application_id, candidate_id, question_id, answer_text, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
 		RETURNING id
 	`
 
-	err := s.db.QueryRow(query, answer.CandidateID, answer.QuestionID, answer.AnswerText, answer.CreatedAt, answer.UpdatedAt).Scan(&answer.ID)
+	tx, err := s.db.Begin()
 	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(query, answer.ApplicationID, answer.CandidateID, answer.QuestionID, answer.AnswerText, answer.CreatedAt, answer.UpdatedAt).Scan(&answer.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.audit.Record(tx, AuditEntityAnswer, answer.ID, &answer.CandidateID, AuditActionCreate, actor, nil, answer); err != nil {
 		return nil, err
 	}
 
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
 	return answer, nil
 }
 
-// GetAnswersByCandidate получает все ответы кандидата
-func (s *AnswerService) GetAnswersByCandidate(candidateID int64) ([]models.Answer, error) {
+// GetAnswersByApplication получает все ответы отклика
+func (s *AnswerService) GetAnswersByApplication(applicationID int64) ([]models.Answer, error) {
 	query := `
-		SELECT id, candidate_id, question_id, answer_text, created_at, updated_at
+		SELECT id, application_id, candidate_id, question_id, answer_text, created_at, updated_at
 		FROM answers
-		WHERE candidate_id = ?
+		WHERE application_id = ?
 		ORDER BY question_id
 	`
 
-	rows, err := s.db.Query(query, candidateID)
+	rows, err := s.db.Query(query, applicationID)
 	if err != nil {
 		return nil, err
 	}
@@ -52,7 +84,7 @@ func (s *AnswerService) GetAnswersByCandidate(candidateID int64) ([]models.Answe
 	var answers []models.Answer
 	for rows.Next() {
 		var answer models.Answer
-		err := rows.Scan(&answer.ID, &answer.CandidateID, &answer.QuestionID, &answer.AnswerText, &answer.CreatedAt, &answer.UpdatedAt)
+		err := rows.Scan(&answer.ID, &answer.ApplicationID, &answer.CandidateID, &answer.QuestionID, &answer.AnswerText, &answer.CreatedAt, &answer.UpdatedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -63,7 +95,12 @@ func (s *AnswerService) GetAnswersByCandidate(candidateID int64) ([]models.Answe
 }
 
 // UpdateAnswer обновляет ответ
-func (s *AnswerService) UpdateAnswer(id int64, answer *models.Answer) (*models.Answer, error) {
+func (s *AnswerService) UpdateAnswer(id int64, answer *models.Answer, actor string) (*models.Answer, error) {
+	before, err := s.getAnswerByID(id)
+	if err != nil {
+		return nil, err
+	}
+
 	answer.UpdatedAt = time.Now()
 
 	query := `
@@ -72,43 +109,97 @@ func (s *AnswerService) UpdateAnswer(id int64, answer *models.Answer) (*models.A
 		WHERE id = ?
 	`
 
-	_, err := s.db.Exec(query, answer.AnswerText, answer.UpdatedAt, id)
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(query, answer.AnswerText, answer.UpdatedAt, id)
 	if err != nil {
 		return nil, err
 	}
 
 	answer.ID = id
+	answer.ApplicationID = before.ApplicationID
+	answer.CandidateID = before.CandidateID
+	answer.QuestionID = before.QuestionID
+	answer.CreatedAt = before.CreatedAt
+	if err := s.audit.Record(tx, AuditEntityAnswer, id, &before.CandidateID, AuditActionUpdate, actor, before, answer); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
 	return answer, nil
 }
 
-// SaveCandidateAnswers сохраняет все ответы кандидата (batch operation)
-func (s *AnswerService) SaveCandidateAnswers(candidateID int64, answers []models.Answer) error {
+// getAnswerByID получает ответ по ID
+func (s *AnswerService) getAnswerByID(id int64) (*models.Answer, error) {
+	query := `
+		SELECT id, COALESCE(application_id, 0), candidate_id, question_id, answer_text, created_at, updated_at
+		FROM answers
+		WHERE id = ?
+	`
+
+	var answer models.Answer
+	err := s.db.QueryRow(query, id).Scan(&answer.ID, &answer.ApplicationID, &answer.CandidateID, &answer.QuestionID, &answer.AnswerText, &answer.CreatedAt, &answer.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("answer not found")
+		}
+		return nil, err
+	}
+
+	return &answer, nil
+}
+
+// SaveApplicationAnswers сохраняет все ответы отклика (batch operation)
+func (s *AnswerService) SaveApplicationAnswers(applicationID int64, answers []models.Answer, actor string) error {
+	application, err := loadApplication(s.db, applicationID)
+	if err != nil {
+		return err
+	}
+
+	before, err := s.GetAnswersByApplication(applicationID)
+	if err != nil {
+		return err
+	}
+
 	tx, err := s.db.Begin()
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
-	// Сначала удаляем существующие ответы кандидата
-	_, err = tx.Exec("DELETE FROM answers WHERE candidate_id = ?", candidateID)
+	// Сначала удаляем существующие ответы отклика
+	_, err = tx.Exec("DELETE FROM answers WHERE application_id = ?", applicationID)
 	if err != nil {
 		return err
 	}
 
 	// Затем вставляем новые ответы
-	for _, answer := range answers {
-		answer.CandidateID = candidateID
+	for i := range answers {
+		answer := &answers[i]
+		answer.ApplicationID = applicationID
+		answer.CandidateID = application.CandidateID
 		answer.CreatedAt = time.Now()
 		answer.UpdatedAt = time.Now()
 
-		_, err = tx.Exec(
-			"INSERT INTO answers (candidate_id, question_id, answer_text, created_at, updated_at) VALUES (?, ?, ?, ?, ?)",
-			answer.CandidateID, answer.QuestionID, answer.AnswerText, answer.CreatedAt, answer.UpdatedAt,
-		)
+		err = tx.QueryRow(
+			"INSERT INTO answers (application_id, candidate_id, question_id, answer_text, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?) RETURNING id",
+			answer.ApplicationID, answer.CandidateID, answer.QuestionID, answer.AnswerText, answer.CreatedAt, answer.UpdatedAt,
+		).Scan(&answer.ID)
 		if err != nil {
 			return err
 		}
 	}
 
+	if err := s.audit.Record(tx, AuditEntityApplicationAnswers, applicationID, &application.CandidateID, AuditActionUpdate, actor, before, answers); err != nil {
+		return err
+	}
+
 	return tx.Commit()
 }
@@ -0,0 +1,380 @@
+package services
+
+import (
+	"choizee/internal/database"
+	"choizee/internal/models"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// Оценка работы принятого кандидата
+const (
+	MinPerformanceRating = 1
+	MaxPerformanceRating = 5
+)
+
+// ErrInvalidPerformance возвращается при некорректной оценке работы кандидата
+var ErrInvalidPerformance = errors.New("invalid performance rating")
+
+type ApplicationService struct {
+	db    *database.DB
+	audit *AuditService
+}
+
+func NewApplicationService(db *database.DB, audit *AuditService) *ApplicationService {
+	return &ApplicationService{db: db, audit: audit}
+}
+
+// CreateApplication создает отклик кандидата на вакансию. Отклик начинает воронку
+// вакансии с этапа new и встает в конец его колонки
+func (s *ApplicationService) CreateApplication(candidateID, jobID int64, actor string) (*models.Application, error) {
+	if _, err := loadCandidate(s.db, candidateID); err != nil {
+		return nil, err
+	}
+
+	var jobExists bool
+	if err := s.db.QueryRow("SELECT EXISTS(SELECT 1 FROM jobs WHERE id = ?)", jobID).Scan(&jobExists); err != nil {
+		return nil, fmt.Errorf("failed to check job: %w", err)
+	}
+	if !jobExists {
+		return nil, fmt.Errorf("job not found")
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	created, err := insertApplication(tx, candidateID, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.audit.Record(tx, AuditEntityApplication, created.ID, &candidateID, AuditActionCreate, actor, nil, created); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return created, nil
+}
+
+// GetApplicationByID получает отклик по ID
+func (s *ApplicationService) GetApplicationByID(id int64) (*models.Application, error) {
+	return loadApplication(s.db, id)
+}
+
+// GetCandidateApplications возвращает отклики кандидата с этапами и оценками
+func (s *ApplicationService) GetCandidateApplications(candidateID int64) ([]models.Application, error) {
+	return loadCandidateApplications(s.db, candidateID)
+}
+
+// ResolveApplication находит отклик кандидата на вакансию. Если вакансия не указана,
+// берется отклик на вакансию, для которой кандидат был добавлен, а если его нет - самый ранний
+func (s *ApplicationService) ResolveApplication(candidateID, jobID int64) (int64, error) {
+	query := `
+		SELECT a.id
+		FROM applications a
+		JOIN candidates c ON c.id = a.candidate_id
+		WHERE a.candidate_id = ? AND (? = 0 OR a.job_id = ?)
+		ORDER BY a.job_id = c.job_id DESC, a.created_at ASC, a.id ASC
+		LIMIT 1
+	`
+
+	var id int64
+	err := s.db.QueryRow(query, candidateID, jobID, jobID).Scan(&id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, fmt.Errorf("application not found")
+		}
+		return 0, fmt.Errorf("failed to get application: %w", err)
+	}
+
+	return id, nil
+}
+
+// DeleteApplication удаляет отклик вместе с его оценками, ответами и историей
+func (s *ApplicationService) DeleteApplication(id int64, actor string) error {
+	before, err := loadApplication(s.db, id)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := deleteApplications(tx, "id = ?", id); err != nil {
+		return err
+	}
+
+	// Закрываем промежуток в колонке, из которой ушел отклик
+	column, err := stageColumn(tx, before.JobID, before.Stage, before.ID)
+	if err != nil {
+		return err
+	}
+	if err := renumberColumn(tx, column); err != nil {
+		return err
+	}
+
+	if err := s.audit.Record(tx, AuditEntityApplication, id, &before.CandidateID, AuditActionDelete, actor, before, nil); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// TransitionApplication переводит отклик на другой этап найма по правилам воронки вакансии
+// и записывает переход в историю. На новом этапе отклик встает в конец колонки
+func (s *ApplicationService) TransitionApplication(id int64, request *models.StageTransitionRequest, actor string) (*models.Application, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	before, err := loadApplication(tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	stages, err := loadJobStages(tx, before.JobID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkStageTransition(stages, before.Stage, request.Stage); err != nil {
+		return nil, err
+	}
+
+	if err := changeApplicationStage(tx, s.audit, before, request.Stage, -1, request.Reason, actor); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return s.GetApplicationByID(id)
+}
+
+// SetPerformanceRating ставит оценку работы принятому кандидату или снимает ее (rating = nil)
+func (s *ApplicationService) SetPerformanceRating(id int64, rating *int, actor string) (*models.Application, error) {
+	if rating != nil && (*rating < MinPerformanceRating || *rating > MaxPerformanceRating) {
+		return nil, fmt.Errorf("%w: rating must be between %d and %d", ErrInvalidPerformance, MinPerformanceRating, MaxPerformanceRating)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	before, err := loadApplication(tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if rating != nil && before.Stage != StageHired {
+		return nil, fmt.Errorf("%w: only hired candidates can be rated", ErrInvalidPerformance)
+	}
+
+	if _, err := tx.Exec("UPDATE applications SET performance_rating = ? WHERE id = ?", rating, id); err != nil {
+		return nil, fmt.Errorf("failed to update performance rating: %w", err)
+	}
+
+	after, err := loadApplication(tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.audit.Record(tx, AuditEntityApplication, id, &before.CandidateID, AuditActionUpdate, actor, before, after); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return after, nil
+}
+
+// GetStageTransitions возвращает историю переходов отклика между этапами
+func (s *ApplicationService) GetStageTransitions(applicationID int64) ([]models.StageTransition, error) {
+	query := `
+		SELECT t.id, t.application_id, t.candidate_id, a.job_id, t.from_stage, t.to_stage,
+		       t.actor, COALESCE(t.reason, ''), t.created_at
+		FROM candidate_stage_transitions t
+		JOIN applications a ON a.id = t.application_id
+		WHERE t.application_id = ?
+		ORDER BY t.created_at ASC, t.id ASC
+	`
+
+	rows, err := s.db.Query(query, applicationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stage transitions: %w", err)
+	}
+	defer rows.Close()
+
+	transitions := []models.StageTransition{}
+	for rows.Next() {
+		var t models.StageTransition
+		err := rows.Scan(&t.ID, &t.ApplicationID, &t.CandidateID, &t.JobID, &t.FromStage, &t.ToStage, &t.Actor, &t.Reason, &t.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan stage transition: %w", err)
+		}
+		transitions = append(transitions, t)
+	}
+
+	return transitions, nil
+}
+
+// insertApplication добавляет отклик в конец колонки new воронки вакансии
+func insertApplication(tx *sql.Tx, candidateID, jobID int64) (*models.Application, error) {
+	var id int64
+	err := tx.QueryRow(`
+		INSERT INTO applications (candidate_id, job_id, stage, stage_position)
+		VALUES (?, ?, ?, (
+			SELECT COALESCE(MAX(stage_position) + 1, 0) FROM applications WHERE job_id = ? AND stage = ?
+		))
+		ON CONFLICT(candidate_id, job_id) DO NOTHING
+		RETURNING id
+	`, candidateID, jobID, StageNew, jobID, StageNew).Scan(&id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("%w: candidate has already applied to job %d", ErrInvalidPipeline, jobID)
+		}
+		return nil, fmt.Errorf("failed to create application: %w", err)
+	}
+
+	return loadApplication(tx, id)
+}
+
+// deleteApplications удаляет отклики по условию where и все связанные с ними данные
+func deleteApplications(tx *sql.Tx, where string, args ...any) error {
+	ids := "SELECT id FROM applications WHERE " + where
+	queries := []string{
+		"DELETE FROM evaluation_revision_items WHERE revision_id IN (SELECT id FROM evaluation_revisions WHERE application_id IN (" + ids + "))",
+		"DELETE FROM evaluation_revisions WHERE application_id IN (" + ids + ")",
+		"DELETE FROM evaluations WHERE application_id IN (" + ids + ")",
+		"DELETE FROM answers WHERE application_id IN (" + ids + ")",
+		"DELETE FROM candidate_stage_transitions WHERE application_id IN (" + ids + ")",
+		"DELETE FROM applications WHERE " + where,
+	}
+	for _, query := range queries {
+		if _, err := tx.Exec(query, args...); err != nil {
+			return fmt.Errorf("failed to delete application: %w", err)
+		}
+	}
+	return nil
+}
+
+// loadApplication читает отклик как через подключение, так и внутри транзакции
+func loadApplication(q querier, id int64) (*models.Application, error) {
+	query := `
+		SELECT a.id, a.candidate_id, a.job_id, a.stage, a.stage_position, a.created_at, a.updated_at,
+		       a.performance_rating, COALESCE(j.title, '')
+		FROM applications a
+		LEFT JOIN jobs j ON j.id = a.job_id
+		WHERE a.id = ?
+	`
+
+	var application models.Application
+	var rating sql.NullInt64
+	err := q.QueryRow(query, id).Scan(
+		&application.ID, &application.CandidateID, &application.JobID, &application.Stage,
+		&application.StagePosition, &application.CreatedAt, &application.UpdatedAt, &rating, &application.JobTitle,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("application not found")
+		}
+		return nil, fmt.Errorf("failed to get application: %w", err)
+	}
+	application.PerformanceRating = performanceRating(rating)
+
+	return &application, nil
+}
+
+// loadCandidateApplications читает отклики кандидата вместе со средней оценкой
+// (среднее по критериям из средних оценок интервьюеров) и оценками по критериям
+func loadCandidateApplications(q querier, candidateID int64) ([]models.Application, error) {
+	query := `
+		SELECT a.id, a.candidate_id, a.job_id, a.stage, a.stage_position, a.created_at, a.updated_at,
+		       a.performance_rating, COALESCE(j.title, ''), COALESCE(j.scale_type, ''), j.scale_levels,
+		       COALESCE((
+		           SELECT AVG(criterion_score) FROM (
+		               SELECT AVG(e.score) AS criterion_score FROM evaluations e
+		               WHERE e.application_id = a.id
+		               GROUP BY e.criterion_id
+		           )
+		       ), 0)
+		FROM applications a
+		LEFT JOIN jobs j ON j.id = a.job_id
+		WHERE a.candidate_id = ?
+		ORDER BY a.created_at ASC, a.id ASC
+	`
+
+	rows, err := q.Query(query, candidateID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get applications: %w", err)
+	}
+	defer rows.Close()
+
+	applications := []models.Application{}
+	var scales []models.ScoringScale
+	for rows.Next() {
+		var application models.Application
+		var rating sql.NullInt64
+		var scaleType string
+		var levels sql.NullString
+		err := rows.Scan(
+			&application.ID, &application.CandidateID, &application.JobID, &application.Stage,
+			&application.StagePosition, &application.CreatedAt, &application.UpdatedAt,
+			&rating, &application.JobTitle, &scaleType, &levels, &application.AverageScore,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan application: %w", err)
+		}
+		// Если вакансия удалена, scale_type пуст и берется шкала по умолчанию,
+		// чтобы кандидата со старым откликом можно было открыть и удалить
+		scale, err := scaleFromColumns(scaleType, levels)
+		if err != nil {
+			return nil, err
+		}
+		application.PerformanceRating = performanceRating(rating)
+		applications = append(applications, application)
+		scales = append(scales, scale)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read applications: %w", err)
+	}
+
+	// Шкалы вакансий различаются, поэтому для сравнения откликов средняя переводится в проценты шкалы
+	for i := range applications {
+		application := &applications[i]
+		evaluations, err := loadApplicationEvaluations(q, application.ID, "")
+		if err != nil {
+			return nil, err
+		}
+		application.Evaluations = evaluations
+
+		if len(evaluations) > 0 {
+			application.NormalizedScore = normalizeScore(scales[i], application.AverageScore)
+		}
+	}
+
+	return applications, nil
+}
+
+// performanceRating переводит значение performance_rating в оценку работы (nil - не оценен)
+func performanceRating(value sql.NullInt64) *int {
+	if !value.Valid {
+		return nil
+	}
+	rating := int(value.Int64)
+	return &rating
+}
@@ -0,0 +1,533 @@
+package services
+
+import (
+	"bytes"
+	"choizee/internal/database"
+	"choizee/internal/models"
+	"choizee/internal/textextract"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// MaxAttachmentSize максимальный размер вложения в байтах
+const MaxAttachmentSize = 10 << 20
+
+var (
+	// ErrAttachmentTooLarge возвращается, если файл больше MaxAttachmentSize
+	ErrAttachmentTooLarge = errors.New("attachment is too large")
+	// ErrUnsupportedAttachmentType возвращается для файлов недопустимого типа
+	ErrUnsupportedAttachmentType = errors.New("unsupported attachment type")
+	// ErrInvalidAttachment возвращается для пустых и некорректных файлов
+	ErrInvalidAttachment = errors.New("invalid attachment")
+)
+
+const docxContentType = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+
+// oleSignature начало файлов Microsoft Office до 2007 года (.doc)
+var oleSignature = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+
+type AttachmentService struct {
+	db    *database.DB
+	audit *AuditService
+	dir   string
+	// mu упорядочивает запись и удаление файлов: файл с одним содержимым
+	// может принадлежать нескольким вложениям
+	mu sync.Mutex
+}
+
+func NewAttachmentService(db *database.DB, audit *AuditService) *AttachmentService {
+	return &AttachmentService{
+		db:    db,
+		audit: audit,
+		dir:   filepath.Join(database.DataDir, "attachments"),
+	}
+}
+
+// CreateAttachment сохраняет файл кандидата. Размер ограничен MaxAttachmentSize,
+// тип определяется по содержимому и должен быть одним из допустимых.
+// Из PDF, DOCX и текстовых файлов извлекается текст резюме
+func (s *AttachmentService) CreateAttachment(candidateID int64, fileName string, content io.Reader, actor string) (*models.Attachment, error) {
+	if _, err := loadCandidate(s.db, candidateID); err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create attachments directory: %w", err)
+	}
+
+	// Пишем во временный файл, одновременно считая хеш и размер
+	tmp, err := os.CreateTemp(s.dir, "upload-*.tmp")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hash), io.LimitReader(content, MaxAttachmentSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to save attachment: %w", err)
+	}
+	if size > MaxAttachmentSize {
+		return nil, fmt.Errorf("%w: maximum size is %d bytes", ErrAttachmentTooLarge, MaxAttachmentSize)
+	}
+	if size == 0 {
+		return nil, fmt.Errorf("%w: file is empty", ErrInvalidAttachment)
+	}
+
+	head := make([]byte, 512)
+	n, err := tmp.ReadAt(head, 0)
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to read attachment: %w", err)
+	}
+
+	attachment := &models.Attachment{
+		CandidateID: candidateID,
+		FileName:    cleanAttachmentName(fileName),
+		Size:        size,
+		SHA256:      hex.EncodeToString(hash.Sum(nil)),
+		Actor:       actor,
+	}
+	attachment.ContentType, err = detectAttachmentType(attachment.FileName, head[:n])
+	if err != nil {
+		return nil, err
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, fmt.Errorf("failed to save attachment: %w", err)
+	}
+
+	text, textError := extractAttachmentText(attachment.ContentType, tmp.Name())
+	attachment.HasText = text != nil
+	if textError != nil {
+		attachment.TextError = *textError
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Одинаковое содержимое хранится один раз
+	committed := false
+	path := s.path(attachment.SHA256)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create attachments directory: %w", err)
+		}
+		if err := os.Rename(tmp.Name(), path); err != nil {
+			return nil, fmt.Errorf("failed to save attachment: %w", err)
+		}
+		// Файл создан под s.mu, поэтому, если вложение не сохранится, на него никто не ссылается
+		defer func() {
+			if !committed {
+				os.Remove(path)
+			}
+		}()
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to access attachment: %w", err)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`
+		INSERT INTO candidate_attachments (candidate_id, file_name, content_type, size, sha256, actor, text_content, text_error)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id, created_at
+	`, attachment.CandidateID, attachment.FileName, attachment.ContentType, attachment.Size, attachment.SHA256, attachment.Actor,
+		text, textError,
+	).Scan(&attachment.ID, &attachment.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create attachment: %w", err)
+	}
+
+	if err := refreshResumeText(tx, candidateID); err != nil {
+		return nil, err
+	}
+
+	if err := s.audit.Record(tx, AuditEntityAttachment, attachment.ID, &candidateID, AuditActionCreate, actor, nil, attachment); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	committed = true
+
+	return attachment, nil
+}
+
+// GetCandidateAttachments возвращает вложения кандидата, начиная с последних
+func (s *AttachmentService) GetCandidateAttachments(candidateID int64) ([]models.Attachment, error) {
+	query := `
+		SELECT id, candidate_id, file_name, content_type, size, sha256, actor,
+		       text_content IS NOT NULL, COALESCE(text_error, ''), created_at
+		FROM candidate_attachments
+		WHERE candidate_id = ?
+		ORDER BY created_at DESC, id DESC
+	`
+
+	rows, err := s.db.Query(query, candidateID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get attachments: %w", err)
+	}
+	defer rows.Close()
+
+	attachments := []models.Attachment{}
+	for rows.Next() {
+		var a models.Attachment
+		err := rows.Scan(
+			&a.ID, &a.CandidateID, &a.FileName, &a.ContentType, &a.Size, &a.SHA256, &a.Actor,
+			&a.HasText, &a.TextError, &a.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan attachment: %w", err)
+		}
+		attachments = append(attachments, a)
+	}
+
+	return attachments, rows.Err()
+}
+
+// GetAttachment получает вложение кандидата по ID
+func (s *AttachmentService) GetAttachment(candidateID, id int64) (*models.Attachment, error) {
+	query := `
+		SELECT id, candidate_id, file_name, content_type, size, sha256, actor,
+		       text_content IS NOT NULL, COALESCE(text_error, ''), created_at
+		FROM candidate_attachments
+		WHERE id = ? AND candidate_id = ?
+	`
+
+	var a models.Attachment
+	err := s.db.QueryRow(query, id, candidateID).Scan(
+		&a.ID, &a.CandidateID, &a.FileName, &a.ContentType, &a.Size, &a.SHA256, &a.Actor,
+		&a.HasText, &a.TextError, &a.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("attachment not found")
+		}
+		return nil, fmt.Errorf("failed to get attachment: %w", err)
+	}
+
+	return &a, nil
+}
+
+// OpenAttachment открывает содержимое вложения для чтения. Файл закрывает вызывающий
+func (s *AttachmentService) OpenAttachment(candidateID, id int64) (*models.Attachment, *os.File, error) {
+	attachment, err := s.GetAttachment(candidateID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	file, err := os.Open(s.path(attachment.SHA256))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open attachment: %w", err)
+	}
+
+	return attachment, file, nil
+}
+
+// DeleteAttachment удаляет вложение, а файл - если на него больше нет ссылок
+func (s *AttachmentService) DeleteAttachment(candidateID, id int64, actor string) error {
+	before, err := s.GetAttachment(candidateID, id)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM candidate_attachments WHERE id = ?", id); err != nil {
+		return fmt.Errorf("failed to delete attachment: %w", err)
+	}
+
+	if err := refreshResumeText(tx, candidateID); err != nil {
+		return err
+	}
+
+	if err := s.audit.Record(tx, AuditEntityAttachment, id, &candidateID, AuditActionDelete, actor, before, nil); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	s.removeUnreferenced([]string{before.SHA256})
+	return nil
+}
+
+// GetResumeText возвращает текст резюме кандидата и вложения, из которых он собран
+func (s *AttachmentService) GetResumeText(candidateID int64) (*models.ResumeText, error) {
+	if _, err := loadCandidate(s.db, candidateID); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT id, file_name, content_type, text_content, created_at
+		FROM candidate_attachments
+		WHERE candidate_id = ? AND text_content IS NOT NULL
+		ORDER BY created_at DESC, id DESC
+	`
+
+	rows, err := s.db.Query(query, candidateID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get resume text: %w", err)
+	}
+	defer rows.Close()
+
+	resume := &models.ResumeText{CandidateID: candidateID, Sources: []models.ResumeTextSource{}}
+	var texts []string
+	for rows.Next() {
+		var source models.ResumeTextSource
+		var text string
+		if err := rows.Scan(&source.AttachmentID, &source.FileName, &source.ContentType, &text, &source.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan resume text: %w", err)
+		}
+		source.Length = utf8.RuneCountInString(text)
+		resume.Sources = append(resume.Sources, source)
+		texts = append(texts, text)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read resume text: %w", err)
+	}
+
+	resume.Text = joinResumeTexts(texts)
+	return resume, nil
+}
+
+// ExtractPendingTexts извлекает текст из вложений, загруженных до появления
+// извлечения текста, и возвращает число обработанных вложений
+func (s *AttachmentService) ExtractPendingTexts() (int, error) {
+	rows, err := s.db.Query(`
+		SELECT id, candidate_id, content_type, sha256
+		FROM candidate_attachments
+		WHERE text_content IS NULL AND text_error IS NULL
+		ORDER BY id
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get attachments: %w", err)
+	}
+
+	var pending []models.Attachment
+	for rows.Next() {
+		var a models.Attachment
+		if err := rows.Scan(&a.ID, &a.CandidateID, &a.ContentType, &a.SHA256); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan attachment: %w", err)
+		}
+		pending = append(pending, a)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to read attachments: %w", err)
+	}
+
+	for _, a := range pending {
+		text, textError := extractAttachmentText(a.ContentType, s.path(a.SHA256))
+
+		tx, err := s.db.Begin()
+		if err != nil {
+			return 0, fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		_, err = tx.Exec("UPDATE candidate_attachments SET text_content = ?, text_error = ? WHERE id = ?", text, textError, a.ID)
+		if err == nil {
+			err = refreshResumeText(tx, a.CandidateID)
+		}
+		if err == nil {
+			err = tx.Commit()
+		}
+		if err != nil {
+			tx.Rollback()
+			return 0, fmt.Errorf("failed to save attachment text: %w", err)
+		}
+	}
+
+	return len(pending), nil
+}
+
+// extractAttachmentText извлекает текст из файла вложения. Возвращает либо текст,
+// либо причину, по которой его нет; оба значения сохраняются как есть (nil - NULL)
+func extractAttachmentText(contentType, path string) (*string, *string) {
+	fail := func(err error) (*string, *string) {
+		reason := err.Error()
+		return nil, &reason
+	}
+
+	if !textextract.Supported(contentType) {
+		return fail(textextract.ErrUnsupportedFormat)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fail(fmt.Errorf("failed to read attachment: %w", err))
+	}
+
+	text, err := textextract.Extract(contentType, data)
+	if err != nil {
+		return fail(err)
+	}
+	return &text, nil
+}
+
+// refreshResumeText пересобирает текст резюме кандидата из текста его вложений:
+// от последних к первым, одинаковые тексты включаются один раз
+func refreshResumeText(tx *sql.Tx, candidateID int64) error {
+	rows, err := tx.Query(`
+		SELECT text_content
+		FROM candidate_attachments
+		WHERE candidate_id = ? AND text_content IS NOT NULL
+		ORDER BY created_at DESC, id DESC
+	`, candidateID)
+	if err != nil {
+		return fmt.Errorf("failed to get resume text: %w", err)
+	}
+	defer rows.Close()
+
+	var texts []string
+	for rows.Next() {
+		var text string
+		if err := rows.Scan(&text); err != nil {
+			return fmt.Errorf("failed to scan resume text: %w", err)
+		}
+		texts = append(texts, text)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read resume text: %w", err)
+	}
+
+	var resumeText any
+	if len(texts) > 0 {
+		resumeText = joinResumeTexts(texts)
+	}
+
+	// Не трогаем кандидата, если текст не изменился: иначе сдвинется updated_at
+	_, err = tx.Exec("UPDATE candidates SET resume_text = ? WHERE id = ? AND resume_text IS NOT ?", resumeText, candidateID, resumeText)
+	if err != nil {
+		return fmt.Errorf("failed to update resume text: %w", err)
+	}
+	return nil
+}
+
+// joinResumeTexts объединяет тексты вложений через пустую строку, пропуская повторы
+func joinResumeTexts(texts []string) string {
+	seen := make(map[string]bool, len(texts))
+	unique := make([]string, 0, len(texts))
+	for _, text := range texts {
+		if seen[text] {
+			continue
+		}
+		seen[text] = true
+		unique = append(unique, text)
+	}
+	return strings.Join(unique, "\n\n")
+}
+
+// deleteCandidateAttachments удаляет записи о вложениях кандидата внутри транзакции
+// и возвращает хеши их файлов для removeUnreferenced после фиксации
+func (s *AttachmentService) deleteCandidateAttachments(tx *sql.Tx, candidateID int64) ([]string, error) {
+	rows, err := tx.Query("SELECT DISTINCT sha256 FROM candidate_attachments WHERE candidate_id = ?", candidateID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get attachments: %w", err)
+	}
+	defer rows.Close()
+
+	var hashes []string
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			return nil, fmt.Errorf("failed to scan attachment: %w", err)
+		}
+		hashes = append(hashes, hash)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read attachments: %w", err)
+	}
+
+	if _, err := tx.Exec("DELETE FROM candidate_attachments WHERE candidate_id = ?", candidateID); err != nil {
+		return nil, fmt.Errorf("failed to delete attachments: %w", err)
+	}
+
+	return hashes, nil
+}
+
+// removeUnreferenced удаляет с диска файлы, на которые не ссылается ни одно вложение.
+// Ошибки только записываются в лог: записи о вложениях к этому моменту уже удалены
+func (s *AttachmentService) removeUnreferenced(hashes []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, hash := range hashes {
+		var referenced bool
+		err := s.db.QueryRow("SELECT EXISTS(SELECT 1 FROM candidate_attachments WHERE sha256 = ?)", hash).Scan(&referenced)
+		if err != nil {
+			log.Printf("Failed to check attachment %s references: %v", hash, err)
+			continue
+		}
+		if referenced {
+			continue
+		}
+		if err := os.Remove(s.path(hash)); err != nil && !os.IsNotExist(err) {
+			log.Printf("Failed to remove attachment file %s: %v", hash, err)
+		}
+	}
+}
+
+// path возвращает путь к файлу с содержимым: data/attachments/ab/abcdef...
+func (s *AttachmentService) path(hash string) string {
+	return filepath.Join(s.dir, hash[:2], hash)
+}
+
+// cleanAttachmentName оставляет от имени загруженного файла только последний элемент пути
+func cleanAttachmentName(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, `\`, "/"))
+	name = strings.TrimSpace(name)
+	if name == "" || name == "." || name == "/" {
+		return "attachment"
+	}
+	return name
+}
+
+// detectAttachmentType определяет MIME-тип по содержимому файла. Допустимы PDF,
+// документы Word, RTF, текст и изображения PNG и JPEG. DOCX и DOC по содержимому
+// не отличить от других архивов и файлов Office, поэтому для них учитывается расширение
+func detectAttachmentType(fileName string, head []byte) (string, error) {
+	ext := strings.ToLower(filepath.Ext(fileName))
+	sniffed := http.DetectContentType(head)
+	mediaType, _, err := mime.ParseMediaType(sniffed)
+	if err != nil {
+		mediaType = sniffed
+	}
+
+	switch {
+	case mediaType == "application/pdf", mediaType == "image/png", mediaType == "image/jpeg":
+		return mediaType, nil
+	case mediaType == "application/zip" && ext == ".docx":
+		return docxContentType, nil
+	case bytes.HasPrefix(head, oleSignature) && ext == ".doc":
+		return "application/msword", nil
+	case bytes.HasPrefix(head, []byte(`{\rtf`)):
+		return "application/rtf", nil
+	case mediaType == "text/plain":
+		return sniffed, nil
+	}
+
+	return "", fmt.Errorf("%w: %s", ErrUnsupportedAttachmentType, mediaType)
+}
@@ -0,0 +1,175 @@
+package services
+
+import (
+	"choizee/internal/database"
+	"choizee/internal/models"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	AuditActionCreate = "create"
+	AuditActionUpdate = "update"
+	AuditActionDelete = "delete"
+)
+
+const (
+	AuditEntityJob                    = "job"
+	AuditEntityCandidate              = "candidate"
+	AuditEntityQuestion               = "question"
+	AuditEntityCriterion              = "criterion"
+	AuditEntityJobCriteria            = "job_criteria"
+	AuditEntityEvaluation             = "evaluation"
+	AuditEntityAnswer                 = "answer"
+	AuditEntityJobStage               = "job_stage"
+	AuditEntityJobStages              = "job_stages"
+	AuditEntityApplication            = "application"
+	AuditEntityApplicationEvaluations = "application_evaluations"
+	AuditEntityApplicationAnswers     = "application_answers"
+	AuditEntityAttachment             = "attachment"
+	AuditEntityCriterionComparisons   = "criterion_comparisons"
+)
+
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+// execer позволяет писать в журнал как через подключение, так и внутри транзакции
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+type AuditService struct {
+	db *database.DB
+}
+
+func NewAuditService(db *database.DB) *AuditService {
+	return &AuditService{db: db}
+}
+
+// Record сохраняет запись аудита в транзакции изменения, чтобы изменение
+// и его запись в журнале фиксировались или откатывались вместе
+func (s *AuditService) Record(exec execer, entity string, entityID int64, candidateID *int64, action, actor string, before, after any) error {
+	beforeJSON, err := auditJSON(before)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit state: %w", err)
+	}
+	afterJSON, err := auditJSON(after)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit state: %w", err)
+	}
+
+	query := `
+		INSERT INTO audit_log (entity, entity_id, candidate_id, action, actor, before_json, after_json, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	_, err = exec.Exec(query, entity, entityID, candidateID, action, actor, beforeJSON, afterJSON, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+
+	return nil
+}
+
+// GetEntries возвращает записи журнала по фильтру, начиная с самых новых
+func (s *AuditService) GetEntries(filter models.AuditFilter) ([]models.AuditEntry, error) {
+	var conditions []string
+	var args []any
+
+	if filter.Entity != "" {
+		conditions = append(conditions, "entity = ?")
+		args = append(args, filter.Entity)
+	}
+	if filter.EntityID != 0 {
+		conditions = append(conditions, "entity_id = ?")
+		args = append(args, filter.EntityID)
+	}
+	if filter.CandidateID != 0 {
+		conditions = append(conditions, "candidate_id = ?")
+		args = append(args, filter.CandidateID)
+	}
+	if filter.Actor != "" {
+		conditions = append(conditions, "actor = ?")
+		args = append(args, filter.Actor)
+	}
+	if filter.From != nil {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, filter.From.UTC())
+	}
+	if filter.To != nil {
+		conditions = append(conditions, "created_at <= ?")
+		args = append(args, filter.To.UTC())
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultAuditLimit
+	}
+	if limit > maxAuditLimit {
+		limit = maxAuditLimit
+	}
+
+	query := `
+		SELECT id, entity, entity_id, candidate_id, action, actor, before_json, after_json, created_at
+		FROM audit_log
+	`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY created_at DESC, id DESC LIMIT ? OFFSET ?"
+	args = append(args, limit, filter.Offset)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit log: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []models.AuditEntry{}
+	for rows.Next() {
+		var entry models.AuditEntry
+		var candidateID sql.NullInt64
+		var before, after sql.NullString
+		err := rows.Scan(
+			&entry.ID, &entry.Entity, &entry.EntityID, &candidateID, &entry.Action,
+			&entry.Actor, &before, &after, &entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan audit entry: %w", err)
+		}
+		if candidateID.Valid {
+			entry.CandidateID = &candidateID.Int64
+		}
+		if before.Valid {
+			entry.Before = json.RawMessage(before.String)
+		}
+		if after.Valid {
+			entry.After = json.RawMessage(after.String)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// auditJSON сериализует состояние сущности; nil означает отсутствие состояния
+func auditJSON(state any) (any, error) {
+	if state == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return nil, err
+	}
+	if string(data) == "null" {
+		return nil, nil
+	}
+
+	return string(data), nil
+}
@@ -0,0 +1,259 @@
+package services
+
+import (
+	"choizee/internal/database"
+	"choizee/internal/models"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	BackupKindManual     = "manual"
+	BackupKindScheduled  = "scheduled"
+	BackupKindPreRestore = "pre-restore"
+)
+
+// backupPrefixes префиксы имен файлов для каждого вида снимков
+var backupPrefixes = map[string]string{
+	BackupKindManual:     "choizee_backup_",
+	BackupKindScheduled:  "choizee_scheduled_",
+	BackupKindPreRestore: "choizee_prerestore_",
+}
+
+var backupNamePattern = regexp.MustCompile(`^choizee_[a-z]+_\d{8}_\d{6}(_\d+)?\.db$`)
+
+var (
+	// ErrInvalidBackup возвращается, если имя снимка некорректно или снимок не проходит проверку
+	ErrInvalidBackup = errors.New("invalid backup")
+	// ErrBackupNotFound возвращается, если снимка с таким именем нет
+	ErrBackupNotFound = errors.New("backup not found")
+)
+
+type BackupService struct {
+	db         *database.DB
+	backupsDir string
+	legacyDir  string // Раньше снимки сохранялись прямо в data/
+	mu         sync.Mutex
+}
+
+func NewBackupService(db *database.DB) *BackupService {
+	return &BackupService{
+		db:         db,
+		backupsDir: filepath.Join(database.DataDir, "backups"),
+		legacyDir:  database.DataDir,
+	}
+}
+
+// CreateBackup создает снимок базы данных, не останавливая сервер
+func (s *BackupService) CreateBackup(kind string) (*models.Backup, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.createBackup(kind)
+}
+
+func (s *BackupService) createBackup(kind string) (*models.Backup, error) {
+	prefix, ok := backupPrefixes[kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown backup kind: %s", kind)
+	}
+
+	if err := os.MkdirAll(s.backupsDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create backups directory: %w", err)
+	}
+
+	base := prefix + time.Now().Format("20060102_150405")
+	name := base + ".db"
+	for i := 2; ; i++ {
+		if _, err := os.Stat(filepath.Join(s.backupsDir, name)); os.IsNotExist(err) {
+			break
+		}
+		name = fmt.Sprintf("%s_%d.db", base, i)
+	}
+
+	// Пишем во временный файл, чтобы в списке не появлялись недописанные снимки
+	path := filepath.Join(s.backupsDir, name)
+	tmpPath := path + ".tmp"
+	os.Remove(tmpPath)
+	if err := s.db.BackupTo(tmpPath); err != nil {
+		os.Remove(tmpPath)
+		return nil, fmt.Errorf("failed to create backup: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return nil, fmt.Errorf("failed to save backup: %w", err)
+	}
+
+	return s.backupInfo(name)
+}
+
+// ListBackups возвращает все снимки, начиная с самых свежих. Кроме data/backups/
+// в список попадают снимки, сохраненные прежними версиями прямо в data/
+func (s *BackupService) ListBackups() ([]models.Backup, error) {
+	backups := []models.Backup{}
+	seen := make(map[string]bool)
+	for _, dir := range []string{s.backupsDir, s.legacyDir} {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to read backups directory: %w", err)
+		}
+
+		for _, entry := range entries {
+			if entry.IsDir() || !backupNamePattern.MatchString(entry.Name()) || seen[entry.Name()] {
+				continue
+			}
+			seen[entry.Name()] = true
+			backup, err := s.backupInfo(entry.Name())
+			if err != nil {
+				return nil, err
+			}
+			backups = append(backups, *backup)
+		}
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].CreatedAt.After(backups[j].CreatedAt)
+	})
+
+	return backups, nil
+}
+
+// RestoreBackup проверяет снимок и подменяет им текущую базу.
+// Перед восстановлением сохраняется снимок текущего состояния
+func (s *BackupService) RestoreBackup(name string) (*models.Backup, error) {
+	if !backupNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("%w: malformed name %q", ErrInvalidBackup, name)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path, err := s.backupPath(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := database.ValidateBackup(path); err != nil {
+		return nil, fmt.Errorf("%w: validation failed: %v", ErrInvalidBackup, err)
+	}
+
+	safety, err := s.createBackup(BackupKindPreRestore)
+	if err != nil {
+		return nil, fmt.Errorf("failed to snapshot current database: %w", err)
+	}
+
+	if err := s.db.RestoreFrom(path); err != nil {
+		return nil, fmt.Errorf("failed to restore backup (current state saved as %s): %w", safety.Name, err)
+	}
+
+	// Снимок мог быть сделан на более старой версии схемы
+	if _, err := s.db.MigrateUp(); err != nil {
+		return nil, fmt.Errorf("failed to migrate restored database: %w", err)
+	}
+
+	return s.backupInfo(name)
+}
+
+// PruneBackups удаляет самые старые снимки указанного вида, оставляя keep последних
+func (s *BackupService) PruneBackups(kind string, keep int) (int, error) {
+	backups, err := s.ListBackups()
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	kept := 0
+	for _, backup := range backups {
+		if backup.Kind != kind {
+			continue
+		}
+		if kept < keep {
+			kept++
+			continue
+		}
+		path, err := s.backupPath(backup.Name)
+		if err != nil {
+			return removed, err
+		}
+		if err := os.Remove(path); err != nil {
+			return removed, fmt.Errorf("failed to remove backup %s: %w", backup.Name, err)
+		}
+		removed++
+	}
+
+	return removed, nil
+}
+
+// StartScheduler запускает периодическое создание снимков с хранением keep последних
+func (s *BackupService) StartScheduler(interval time.Duration, keep int) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			backup, err := s.CreateBackup(BackupKindScheduled)
+			if err != nil {
+				log.Printf("Scheduled backup failed: %v", err)
+				continue
+			}
+			log.Printf("Scheduled backup created: %s", backup.Name)
+
+			if _, err := s.PruneBackups(BackupKindScheduled, keep); err != nil {
+				log.Printf("Failed to prune scheduled backups: %v", err)
+			}
+		}
+	}()
+}
+
+// backupPath находит файл снимка: сначала в data/backups/, затем в data/
+func (s *BackupService) backupPath(name string) (string, error) {
+	for _, dir := range []string{s.backupsDir, s.legacyDir} {
+		path := filepath.Join(dir, name)
+		_, err := os.Stat(path)
+		if err == nil {
+			return path, nil
+		}
+		if !os.IsNotExist(err) {
+			return "", fmt.Errorf("failed to access backup: %w", err)
+		}
+	}
+	return "", ErrBackupNotFound
+}
+
+// backupInfo собирает сведения о файле снимка
+func (s *BackupService) backupInfo(name string) (*models.Backup, error) {
+	path, err := s.backupPath(name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat backup: %w", err)
+	}
+
+	kind := ""
+	for k, prefix := range backupPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			kind = k
+			break
+		}
+	}
+
+	return &models.Backup{
+		Name:      name,
+		Kind:      kind,
+		Size:      info.Size(),
+		CreatedAt: info.ModTime(),
+	}, nil
+}
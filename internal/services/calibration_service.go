@@ -0,0 +1,251 @@
+package services
+
+import (
+	"choizee/internal/database"
+	"choizee/internal/models"
+	"choizee/internal/stats"
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Склонность интервьюера оценивать выше или ниже остальных
+const (
+	TendencyLenient          = "lenient"
+	TendencyHarsh            = "harsh"
+	TendencyNeutral          = "neutral"
+	TendencyInsufficientData = "insufficient_data"
+
+	// minCalibrationScores оценок нужно интервьюеру, чтобы судить о его строгости
+	minCalibrationScores = 5
+	// tendencyThreshold отклонение среднего интервьюера в стандартных отклонениях всех оценок,
+	// начиная с которого он считается мягким или строгим
+	tendencyThreshold = 0.5
+)
+
+type CalibrationService struct {
+	db *database.DB
+}
+
+func NewCalibrationService(db *database.DB) *CalibrationService {
+	return &CalibrationService{db: db}
+}
+
+// calibrationRating оценка интервьюера в процентах шкалы вакансии
+type calibrationRating struct {
+	applicationID int64
+	criterionID   int64
+	jobID         int64
+	jobTitle      string
+	evaluator     string
+	score         int
+	normalized    float64
+}
+
+// evaluatorProfile распределение оценок интервьюера в процентах шкалы
+type evaluatorProfile struct {
+	count  int
+	mean   float64
+	stdDev float64
+}
+
+// calibrated сообщает, что оценок достаточно для поправки на строгость
+func (p evaluatorProfile) calibrated() bool {
+	return p.count >= minCalibrationScores
+}
+
+// GetInterviewerCalibration сравнивает оценки интервьюера по всем вакансиям с оценками остальных
+func (s *CalibrationService) GetInterviewerCalibration(evaluator string) (*models.InterviewerCalibration, error) {
+	ratings, err := loadCalibrationRatings(s.db)
+	if err != nil {
+		return nil, err
+	}
+
+	profiles, pool := evaluatorProfiles(ratings)
+	profile, ok := profiles[evaluator]
+	if !ok {
+		return nil, fmt.Errorf("interviewer not found")
+	}
+
+	report := &models.InterviewerCalibration{
+		Evaluator:   evaluator,
+		Evaluations: profile.count,
+		Mean:        profile.mean,
+		StdDev:      profile.stdDev,
+		Min:         math.Inf(1),
+		Max:         math.Inf(-1),
+		PoolMean:    pool.mean,
+		PoolStdDev:  pool.stdDev,
+		Bias:        profile.mean - pool.mean,
+		Tendency:    TendencyInsufficientData,
+		Calibrated:  profile.calibrated(),
+		Jobs:        []models.InterviewerJobStatistic{},
+	}
+	if pool.stdDev > 0 {
+		report.StandardizedBias = report.Bias / pool.stdDev
+	}
+	if report.Calibrated {
+		switch {
+		case report.StandardizedBias >= tendencyThreshold:
+			report.Tendency = TendencyLenient
+		case report.StandardizedBias <= -tendencyThreshold:
+			report.Tendency = TendencyHarsh
+		default:
+			report.Tendency = TendencyNeutral
+		}
+	}
+
+	// Оценки других интервьюеров по тем же кандидатам и критериям
+	type unitKey struct{ applicationID, criterionID int64 }
+	others := make(map[unitKey][]float64)
+	for _, rating := range ratings {
+		if rating.evaluator != evaluator {
+			key := unitKey{rating.applicationID, rating.criterionID}
+			others[key] = append(others[key], rating.normalized)
+		}
+	}
+
+	jobs := make(map[int64]*models.InterviewerJobStatistic)
+	peerDiff := 0.0
+	for _, rating := range ratings {
+		if rating.evaluator != evaluator {
+			continue
+		}
+		report.Min = math.Min(report.Min, rating.normalized)
+		report.Max = math.Max(report.Max, rating.normalized)
+
+		if peers := others[unitKey{rating.applicationID, rating.criterionID}]; len(peers) > 0 {
+			peerDiff += rating.normalized - stats.Mean(peers)
+			report.PeerComparisons++
+		}
+
+		job, ok := jobs[rating.jobID]
+		if !ok {
+			job = &models.InterviewerJobStatistic{JobID: rating.jobID, JobTitle: rating.jobTitle}
+			jobs[rating.jobID] = job
+		}
+		job.Evaluations++
+		job.Mean += float64(rating.score)
+		job.NormalizedMean += rating.normalized
+	}
+	if report.PeerComparisons > 0 {
+		peerBias := peerDiff / float64(report.PeerComparisons)
+		report.PeerBias = &peerBias
+	}
+
+	for _, job := range jobs {
+		job.Mean /= float64(job.Evaluations)
+		job.NormalizedMean /= float64(job.Evaluations)
+		report.Jobs = append(report.Jobs, *job)
+	}
+	sort.Slice(report.Jobs, func(i, j int) bool {
+		return report.Jobs[i].JobID < report.Jobs[j].JobID
+	})
+
+	return report, nil
+}
+
+// loadCalibrationRatings загружает все оценки, переведенные в проценты шкал их вакансий
+func loadCalibrationRatings(q querier) ([]calibrationRating, error) {
+	rows, err := q.Query(`
+		SELECT e.application_id, e.criterion_id, a.job_id, j.title, e.evaluator, e.score, j.scale_type, j.scale_levels
+		FROM evaluations e
+		JOIN applications a ON a.id = e.application_id
+		JOIN jobs j ON j.id = a.job_id
+		ORDER BY e.id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query evaluations: %w", err)
+	}
+	defer rows.Close()
+
+	scales := make(map[int64]models.ScoringScale)
+	var ratings []calibrationRating
+	for rows.Next() {
+		var rating calibrationRating
+		var scaleType string
+		var scaleLevels sql.NullString
+		err := rows.Scan(
+			&rating.applicationID, &rating.criterionID, &rating.jobID, &rating.jobTitle,
+			&rating.evaluator, &rating.score, &scaleType, &scaleLevels,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan evaluation: %w", err)
+		}
+
+		scale, ok := scales[rating.jobID]
+		if !ok {
+			scale, err = scaleFromColumns(scaleType, scaleLevels)
+			if err != nil {
+				return nil, err
+			}
+			scales[rating.jobID] = scale
+		}
+		rating.normalized = normalizeScore(scale, float64(rating.score))
+		ratings = append(ratings, rating)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read evaluations: %w", err)
+	}
+
+	return ratings, nil
+}
+
+// evaluatorProfiles считает распределение оценок каждого интервьюера и всех оценок вместе
+func evaluatorProfiles(ratings []calibrationRating) (map[string]evaluatorProfile, evaluatorProfile) {
+	byEvaluator := make(map[string][]float64)
+	all := make([]float64, 0, len(ratings))
+	for _, rating := range ratings {
+		byEvaluator[rating.evaluator] = append(byEvaluator[rating.evaluator], rating.normalized)
+		all = append(all, rating.normalized)
+	}
+
+	profiles := make(map[string]evaluatorProfile, len(byEvaluator))
+	for evaluator, values := range byEvaluator {
+		profiles[evaluator] = evaluatorProfile{count: len(values), mean: stats.Mean(values), stdDev: stats.StdDev(values)}
+	}
+	return profiles, evaluatorProfile{count: len(all), mean: stats.Mean(all), stdDev: stats.StdDev(all)}
+}
+
+// adjustScore переводит оценку в z-оценку по распределению интервьюера и обратно в шкалу
+// вакансии по распределению всех оценок. Если интервьюер всегда ставит одно и то же,
+// его оценка не несет информации и становится средней. ok = false, если истории
+// интервьюера недостаточно
+func adjustScore(profile, pool evaluatorProfile, scale models.ScoringScale, score int) (z, adjusted float64, ok bool) {
+	if !profile.calibrated() {
+		return 0, float64(score), false
+	}
+	if profile.stdDev > 0 {
+		z = (normalizeScore(scale, float64(score)) - profile.mean) / profile.stdDev
+	}
+	percent := math.Max(0, math.Min(100, pool.mean+z*pool.stdDev))
+	return z, float64(scale.Min) + percent/100*float64(scale.Max-scale.Min), true
+}
+
+// calibrateSummary добавляет в сводку кандидата оценки с поправкой на строгость интервьюеров.
+// Оценки интервьюеров без достаточной истории остаются без поправки
+func calibrateSummary(summary *models.EvaluationSummary, profiles map[string]evaluatorProfile, pool evaluatorProfile, weights map[int64]float64) {
+	weightedTotal, totalWeight := 0.0, 0.0
+	for i := range summary.Criteria {
+		criterion := &summary.Criteria[i]
+		adjustedTotal := 0.0
+		for j := range criterion.Scores {
+			score := &criterion.Scores[j]
+			z, adjusted, ok := adjustScore(profiles[score.Evaluator], pool, summary.Scale, score.Score)
+			if ok {
+				score.ZScore = &z
+			}
+			score.Adjusted = &adjusted
+			adjustedTotal += adjusted
+		}
+		mean := adjustedTotal / float64(len(criterion.Scores))
+		criterion.AdjustedMean = &mean
+		weightedTotal += weights[criterion.CriterionID] * mean
+		totalWeight += weights[criterion.CriterionID]
+	}
+	if totalWeight > 0 {
+		score := weightedTotal / totalWeight
+		summary.AdjustedScore = &score
+	}
+}
@@ -0,0 +1,349 @@
+package services
+
+import (
+	"bytes"
+	"choizee/internal/models"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/mail"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxImportSize ограничивает размер CSV-файла импорта кандидатов
+const MaxImportSize = 10 << 20
+
+// maxImportRows ограничивает число строк в одном импорте
+const maxImportRows = 5000
+
+// ErrInvalidImport возвращается, если файл импорта нельзя прочитать или сопоставить с полями кандидата
+var ErrInvalidImport = errors.New("invalid import")
+
+// Коды ошибок в строках импорта
+const (
+	ImportErrorRequired     = "required"
+	ImportErrorInvalidEmail = "invalid_email"
+	ImportErrorDuplicate    = "duplicate"
+)
+
+// importFields поля кандидата, которые заполняются из CSV
+var importFields = []string{"name", "email", "phone", "description"}
+
+// importAliases заголовки колонок, которые без явного сопоставления считаются полями кандидата
+var importAliases = map[string][]string{
+	"name":        {"name", "full name", "candidate", "имя", "фио", "кандидат"},
+	"email":       {"email", "e-mail", "mail", "почта", "электронная почта"},
+	"phone":       {"phone", "telephone", "телефон"},
+	"description": {"description", "notes", "comment", "описание", "комментарий", "заметки"},
+}
+
+// CandidateImportOptions параметры импорта кандидатов
+type CandidateImportOptions struct {
+	Mapping map[string]string // Поле кандидата -> заголовок колонки; "" - поле не импортируется
+	DryRun  bool              // Только проверить строки, ничего не создавая
+}
+
+// importRecord строка CSV и ее номер в файле
+type importRecord struct {
+	line   int
+	fields []string
+}
+
+// ImportCandidates добавляет кандидатов вакансии из CSV. Первая строка файла - шапка, колонки
+// сопоставляются с полями кандидата по options.Mapping, а несопоставленные поля - по известным
+// заголовкам. Каждая строка проверяется: имя обязательно, email должен быть корректным,
+// кандидат не должен повторять более раннюю строку без ошибок или уже откликнувшегося
+// на вакансию кандидата (по email, а без него по телефону). При options.DryRun возвращается только проверка,
+// иначе все строки без ошибок создаются в одной транзакции, а строки с ошибками пропускаются
+func (s *CandidateService) ImportCandidates(jobID int64, data io.Reader, options CandidateImportOptions, actor string) (*models.CandidateImport, error) {
+	var jobExists bool
+	if err := s.db.QueryRow("SELECT EXISTS(SELECT 1 FROM jobs WHERE id = ?)", jobID).Scan(&jobExists); err != nil {
+		return nil, fmt.Errorf("failed to check job: %w", err)
+	}
+	if !jobExists {
+		return nil, fmt.Errorf("job not found")
+	}
+
+	header, records, err := readImportCSV(data)
+	if err != nil {
+		return nil, err
+	}
+	columns, mapping, err := importColumns(header, options.Mapping)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := s.jobCandidateKeys(jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.CandidateImport{
+		JobID:   jobID,
+		DryRun:  options.DryRun,
+		Mapping: mapping,
+		Rows:    []models.CandidateImportRow{},
+	}
+	seen := make(map[string]int)
+	for _, record := range records {
+		value := func(field string) string {
+			if i, ok := columns[field]; ok && i < len(record.fields) {
+				return strings.TrimSpace(record.fields[i])
+			}
+			return ""
+		}
+		row := models.CandidateImportRow{
+			Line:        record.line,
+			Name:        value("name"),
+			Email:       value("email"),
+			Phone:       value("phone"),
+			Description: value("description"),
+			Errors:      []models.CandidateImportError{},
+		}
+		if row.Name == "" && row.Email == "" && row.Phone == "" && row.Description == "" {
+			continue
+		}
+
+		if row.Name == "" {
+			row.Errors = append(row.Errors, models.CandidateImportError{Field: "name", Code: ImportErrorRequired, Message: "name is required"})
+		}
+		if row.Email != "" && !validEmail(row.Email) {
+			row.Errors = append(row.Errors, models.CandidateImportError{Field: "email", Code: ImportErrorInvalidEmail, Message: fmt.Sprintf("malformed email %q", row.Email)})
+		}
+		if field, key := importKey(row.Email, row.Phone); key != "" {
+			if id, ok := existing[key]; ok {
+				row.Errors = append(row.Errors, models.CandidateImportError{Field: field, Code: ImportErrorDuplicate, Message: fmt.Sprintf("candidate %d with the same %s has already applied to the job", id, field)})
+			} else if line, ok := seen[key]; ok {
+				row.Errors = append(row.Errors, models.CandidateImportError{Field: field, Code: ImportErrorDuplicate, Message: fmt.Sprintf("duplicate of line %d", line)})
+			}
+		}
+
+		row.Valid = len(row.Errors) == 0
+		if row.Valid {
+			result.Valid++
+			// Запоминаются только строки, которые будут импортированы: исправленная строка
+			// после ошибочной не считается ее дубликатом. Телефон запоминается и у строк
+			// с email - с ним сравниваются строки без email
+			for _, key := range []string{emailKey(row.Email), phoneKey(row.Phone)} {
+				if _, ok := seen[key]; !ok && key != "" {
+					seen[key] = row.Line
+				}
+			}
+		} else {
+			result.Invalid++
+		}
+		result.Rows = append(result.Rows, row)
+	}
+	result.Total = len(result.Rows)
+
+	if options.DryRun || result.Valid == 0 {
+		return result, nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for i := range result.Rows {
+		row := &result.Rows[i]
+		if !row.Valid {
+			continue
+		}
+		created, err := s.createCandidate(tx, &models.Candidate{
+			JobID:       jobID,
+			Name:        row.Name,
+			Email:       row.Email,
+			Phone:       row.Phone,
+			Description: row.Description,
+		}, actor)
+		if err != nil {
+			return nil, fmt.Errorf("failed to import line %d: %w", row.Line, err)
+		}
+		row.CandidateID = &created.ID
+		result.Imported++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return result, nil
+}
+
+// readImportCSV читает шапку и строки CSV в UTF-8. Разделитель - запятая, точка с запятой
+// (так сохраняет CSV русский Excel) или табуляция - определяется по шапке
+func readImportCSV(data io.Reader) ([]string, []importRecord, error) {
+	content, err := io.ReadAll(io.LimitReader(data, MaxImportSize+1))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read import file: %w", err)
+	}
+	if len(content) > MaxImportSize {
+		return nil, nil, fmt.Errorf("%w: file is larger than %d MB", ErrInvalidImport, MaxImportSize>>20)
+	}
+	content = bytes.TrimPrefix(content, []byte("\ufeff"))
+	if !utf8.Valid(content) {
+		return nil, nil, fmt.Errorf("%w: file must be UTF-8 encoded", ErrInvalidImport)
+	}
+
+	firstLine, _, _ := bytes.Cut(content, []byte("\n"))
+	reader := csv.NewReader(bytes.NewReader(content))
+	reader.Comma = ','
+	for _, delimiter := range []rune{';', '\t'} {
+		if bytes.Count(firstLine, []byte(string(delimiter))) > bytes.Count(firstLine, []byte(string(reader.Comma))) {
+			reader.Comma = delimiter
+		}
+	}
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil, fmt.Errorf("%w: file is empty", ErrInvalidImport)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+	}
+
+	var records []importRecord
+	for {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+		}
+		if len(records) == maxImportRows {
+			return nil, nil, fmt.Errorf("%w: more than %d rows", ErrInvalidImport, maxImportRows)
+		}
+		line, _ := reader.FieldPos(0)
+		records = append(records, importRecord{line: line, fields: fields})
+	}
+
+	return header, records, nil
+}
+
+// importColumns сопоставляет поля кандидата с колонками шапки: сначала по mapping,
+// затем по известным заголовкам. Возвращает номера колонок полей и примененное сопоставление
+func importColumns(header []string, mapping map[string]string) (map[string]int, map[string]string, error) {
+	index := make(map[string]int, len(header))
+	for i, title := range header {
+		key := strings.ToLower(strings.TrimSpace(title))
+		if _, ok := index[key]; !ok && key != "" {
+			index[key] = i
+		}
+	}
+
+	for field := range mapping {
+		if _, ok := importAliases[field]; !ok {
+			return nil, nil, fmt.Errorf("%w: unknown field %q, expected one of %s", ErrInvalidImport, field, strings.Join(importFields, ", "))
+		}
+	}
+
+	columns := make(map[string]int)
+	applied := make(map[string]string)
+	for _, field := range importFields {
+		if title, ok := mapping[field]; ok {
+			if title == "" {
+				continue
+			}
+			i, ok := index[strings.ToLower(strings.TrimSpace(title))]
+			if !ok {
+				return nil, nil, fmt.Errorf("%w: column %q for %s not found", ErrInvalidImport, title, field)
+			}
+			columns[field] = i
+			applied[field] = header[i]
+			continue
+		}
+		for _, alias := range importAliases[field] {
+			if i, ok := index[alias]; ok {
+				columns[field] = i
+				applied[field] = header[i]
+				break
+			}
+		}
+	}
+
+	if _, ok := columns["name"]; !ok {
+		return nil, nil, fmt.Errorf("%w: no column for name, set it in mapping", ErrInvalidImport)
+	}
+	return columns, applied, nil
+}
+
+// jobCandidateKeys возвращает ключи дубликатов кандидатов, уже откликнувшихся на вакансию
+func (s *CandidateService) jobCandidateKeys(jobID int64) (map[string]int64, error) {
+	rows, err := s.db.Query(`
+		SELECT c.id, COALESCE(c.email, ''), COALESCE(c.phone, '')
+		FROM applications a
+		JOIN candidates c ON a.candidate_id = c.id
+		WHERE a.job_id = ?
+	`, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job candidates: %w", err)
+	}
+	defer rows.Close()
+
+	keys := make(map[string]int64)
+	for rows.Next() {
+		var id int64
+		var email, phone string
+		if err := rows.Scan(&id, &email, &phone); err != nil {
+			return nil, fmt.Errorf("failed to scan candidate: %w", err)
+		}
+		// Сравниваются и email, и телефон: в файле у кандидата может не оказаться email
+		for _, key := range []string{emailKey(email), phoneKey(phone)} {
+			if key != "" {
+				keys[key] = id
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read job candidates: %w", err)
+	}
+
+	return keys, nil
+}
+
+// importKey возвращает поле и ключ, по которым ищутся дубликаты строки: email,
+// а без него телефон. Пустой ключ - кандидата не с чем сравнить
+func importKey(email, phone string) (string, string) {
+	if key := emailKey(email); key != "" {
+		return "email", key
+	}
+	if key := phoneKey(phone); key != "" {
+		return "phone", key
+	}
+	return "", ""
+}
+
+// emailKey - email без учета регистра
+func emailKey(email string) string {
+	if email = strings.ToLower(strings.TrimSpace(email)); email != "" {
+		return "email:" + email
+	}
+	return ""
+}
+
+// phoneKey - только цифры телефона, без пробелов, скобок и дефисов
+func phoneKey(phone string) string {
+	digits := strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, phone)
+	if digits != "" {
+		return "phone:" + digits
+	}
+	return ""
+}
+
+// validEmail проверяет, что строка - один адрес вида user@example.com без имени и угловых скобок
+func validEmail(email string) bool {
+	address, err := mail.ParseAddress(email)
+	return err == nil && address.Name == "" && address.Address == email && strings.Contains(email[strings.LastIndex(email, "@")+1:], ".")
+}
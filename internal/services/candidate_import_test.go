@@ -0,0 +1,288 @@
+package services
+
+import (
+	"bytes"
+	"choizee/internal/database"
+	"choizee/internal/models"
+	"encoding/csv"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// newTestDB создает базу с актуальной схемой во временном каталоге
+func newTestDB(t *testing.T) *database.DB {
+	t.Helper()
+	t.Chdir(t.TempDir())
+
+	db, err := database.New()
+	if errors.Is(err, database.ErrNoFTS5) {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatalf("database.New() error = %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func newTestCandidateService(t *testing.T) (*CandidateService, int64) {
+	t.Helper()
+	db := newTestDB(t)
+	audit := NewAuditService(db)
+
+	result, err := db.Exec("INSERT INTO jobs (title) VALUES ('Go-разработчик')")
+	if err != nil {
+		t.Fatalf("failed to create job: %v", err)
+	}
+	jobID, _ := result.LastInsertId()
+	return NewCandidateService(db, audit, NewAttachmentService(db, audit)), jobID
+}
+
+func TestReadImportCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		header  []string
+		records []importRecord
+		err     error
+	}{
+		{
+			name:    "comma",
+			data:    "name,email\nАнна,anna@example.com\n",
+			header:  []string{"name", "email"},
+			records: []importRecord{{line: 2, fields: []string{"Анна", "anna@example.com"}}},
+		},
+		{
+			name:    "semicolon with BOM",
+			data:    "\ufeffФИО;Почта\r\n\"Иванов, Иван\";ivan@example.com\r\n",
+			header:  []string{"ФИО", "Почта"},
+			records: []importRecord{{line: 2, fields: []string{"Иванов, Иван", "ivan@example.com"}}},
+		},
+		{
+			name:   "tab and multiline field",
+			data:   "name\tnotes\nАнна\t\"две\nстроки\"\nБорис\t\n",
+			header: []string{"name", "notes"},
+			records: []importRecord{
+				{line: 2, fields: []string{"Анна", "две\nстроки"}},
+				{line: 4, fields: []string{"Борис", ""}},
+			},
+		},
+		{name: "empty", data: "", err: ErrInvalidImport},
+		{name: "not UTF-8", data: "name\n\xcf\xf0\xe8\n", err: ErrInvalidImport},
+		{name: "too many rows", data: "name\n" + strings.Repeat("x\n", maxImportRows+1), err: ErrInvalidImport},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header, records, err := readImportCSV(strings.NewReader(tt.data))
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("readImportCSV() error = %v, want %v", err, tt.err)
+			}
+			if tt.err != nil {
+				return
+			}
+			if !reflect.DeepEqual(header, tt.header) {
+				t.Errorf("header = %q, want %q", header, tt.header)
+			}
+			if !reflect.DeepEqual(records, tt.records) {
+				t.Errorf("records = %+v, want %+v", records, tt.records)
+			}
+		})
+	}
+}
+
+func TestImportColumns(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  []string
+		mapping map[string]string
+		columns map[string]int
+		applied map[string]string
+		err     error
+	}{
+		{
+			name:    "aliases",
+			header:  []string{" ФИО ", "E-mail", "Телефон", "Комментарий"},
+			columns: map[string]int{"name": 0, "email": 1, "phone": 2, "description": 3},
+			applied: map[string]string{"name": " ФИО ", "email": "E-mail", "phone": "Телефон", "description": "Комментарий"},
+		},
+		{
+			name:    "explicit mapping and skipped field",
+			header:  []string{"Candidate", "Contact", "Notes"},
+			mapping: map[string]string{"name": "contact", "description": ""},
+			columns: map[string]int{"name": 1},
+			applied: map[string]string{"name": "Contact"},
+		},
+		{name: "unknown field", header: []string{"name"}, mapping: map[string]string{"salary": "name"}, err: ErrInvalidImport},
+		{name: "missing column", header: []string{"name"}, mapping: map[string]string{"email": "mail"}, err: ErrInvalidImport},
+		{name: "no name column", header: []string{"email"}, err: ErrInvalidImport},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			columns, applied, err := importColumns(tt.header, tt.mapping)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("importColumns() error = %v, want %v", err, tt.err)
+			}
+			if tt.err != nil {
+				return
+			}
+			if !reflect.DeepEqual(columns, tt.columns) || !reflect.DeepEqual(applied, tt.applied) {
+				t.Errorf("importColumns() = %v, %q; want %v, %q", columns, applied, tt.columns, tt.applied)
+			}
+		})
+	}
+}
+
+func TestValidEmail(t *testing.T) {
+	tests := []struct {
+		email string
+		want  bool
+	}{
+		{"anna@example.com", true},
+		{"anna.petrova+hr@mail.example.ru", true},
+		{"anna@localhost", false},
+		{"Anna <anna@example.com>", false},
+		{"anna@example.com, boris@example.com", false},
+		{"anna", false},
+	}
+
+	for _, tt := range tests {
+		if got := validEmail(tt.email); got != tt.want {
+			t.Errorf("validEmail(%q) = %v, want %v", tt.email, got, tt.want)
+		}
+	}
+}
+
+func TestImportKey(t *testing.T) {
+	tests := []struct {
+		email, phone string
+		field, key   string
+	}{
+		{"Anna@Example.com ", "+7 900", "email", "email:anna@example.com"},
+		{"", "+7 (900) 000-00-00", "phone", "phone:79000000000"},
+		{"", "нет", "", ""},
+	}
+
+	for _, tt := range tests {
+		if field, key := importKey(tt.email, tt.phone); field != tt.field || key != tt.key {
+			t.Errorf("importKey(%q, %q) = %q, %q; want %q, %q", tt.email, tt.phone, field, key, tt.field, tt.key)
+		}
+	}
+}
+
+// TestImportCandidatesRoundTrip записывает кандидатов в CSV, импортирует файл и проверяет,
+// что созданные кандидаты совпадают с исходными, а повторный импорт находит дубликаты
+func TestImportCandidatesRoundTrip(t *testing.T) {
+	candidates := []models.Candidate{
+		{Name: "Анна Петрова", Email: "anna@example.com", Phone: "+7 900 000-00-01", Description: "Go, PostgreSQL"},
+		{Name: "Иванов, Иван", Email: "", Phone: "+7 (900) 000-00-02", Description: "Строка 1\nСтрока 2 с \"кавычками\""},
+		{Name: "Борис", Email: "boris@example.com", Phone: "", Description: ""},
+	}
+	for _, comma := range []rune{',', ';'} {
+		t.Run(string(comma), func(t *testing.T) {
+			service, jobID := newTestCandidateService(t)
+
+			var buf bytes.Buffer
+			writer := csv.NewWriter(&buf)
+			writer.Comma = comma
+			writer.Write([]string{"Имя", "Email", "Телефон", "Описание"})
+			for _, c := range candidates {
+				writer.Write([]string{c.Name, c.Email, c.Phone, c.Description})
+			}
+			writer.Flush()
+			data := buf.Bytes()
+
+			dryRun, err := service.ImportCandidates(jobID, bytes.NewReader(data), CandidateImportOptions{DryRun: true}, "hr")
+			if err != nil {
+				t.Fatalf("ImportCandidates(dry run) error = %v", err)
+			}
+			if dryRun.Valid != len(candidates) || dryRun.Imported != 0 {
+				t.Fatalf("dry run: valid = %d, imported = %d; want %d, 0", dryRun.Valid, dryRun.Imported, len(candidates))
+			}
+			if existing, _ := service.GetCandidatesByJobID(jobID, nil); len(existing) != 0 {
+				t.Fatalf("dry run created %d candidates", len(existing))
+			}
+
+			result, err := service.ImportCandidates(jobID, bytes.NewReader(data), CandidateImportOptions{}, "hr")
+			if err != nil {
+				t.Fatalf("ImportCandidates() error = %v", err)
+			}
+			if result.Imported != len(candidates) || result.Invalid != 0 {
+				t.Fatalf("imported = %d, invalid = %d; want %d, 0", result.Imported, result.Invalid, len(candidates))
+			}
+
+			created, err := service.GetCandidatesByJobID(jobID, nil)
+			if err != nil {
+				t.Fatalf("GetCandidatesByJobID() error = %v", err)
+			}
+			sort.Slice(created, func(i, j int) bool { return created[i].ID < created[j].ID })
+			var got []models.Candidate
+			for _, c := range created {
+				got = append(got, models.Candidate{Name: c.Name, Email: c.Email, Phone: c.Phone, Description: c.Description})
+			}
+			if !reflect.DeepEqual(got, candidates) {
+				t.Errorf("imported candidates = %+v, want %+v", got, candidates)
+			}
+
+			again, err := service.ImportCandidates(jobID, bytes.NewReader(data), CandidateImportOptions{DryRun: true}, "hr")
+			if err != nil {
+				t.Fatalf("repeated ImportCandidates() error = %v", err)
+			}
+			for _, row := range again.Rows {
+				if row.Valid || len(row.Errors) != 1 || row.Errors[0].Code != ImportErrorDuplicate {
+					t.Errorf("repeated line %d: valid = %v, errors = %+v; want duplicate", row.Line, row.Valid, row.Errors)
+				}
+			}
+		})
+	}
+}
+
+func TestImportCandidatesValidation(t *testing.T) {
+	service, jobID := newTestCandidateService(t)
+	if _, err := service.CreateCandidate(&models.Candidate{JobID: jobID, Name: "Вера", Email: "vera@example.com"}, "hr"); err != nil {
+		t.Fatalf("CreateCandidate() error = %v", err)
+	}
+
+	data := strings.Join([]string{
+		"name,email,phone",
+		",nobody@example.com,",                  // 2: нет имени
+		"Глеб,gleb@,",                           // 3: некорректный email
+		"Вера,VERA@example.com,",                // 4: уже откликалась
+		"Дина,dina@example.com,8 900 000-00-03", // 5
+		"Дина Б.,Dina@Example.com,",             // 6: дубликат строки 5 по email
+		"Егор,,+8 (900) 000-00-03",              // 7: дубликат строки 5 по телефону
+		",,",                                    // пустая строка пропускается
+		"Жанна,,",                               // 9
+	}, "\n")
+
+	result, err := service.ImportCandidates(jobID, strings.NewReader(data), CandidateImportOptions{}, "hr")
+	if err != nil {
+		t.Fatalf("ImportCandidates() error = %v", err)
+	}
+
+	want := map[int]string{2: ImportErrorRequired, 3: ImportErrorInvalidEmail, 4: ImportErrorDuplicate, 5: "", 6: ImportErrorDuplicate, 7: ImportErrorDuplicate, 9: ""}
+	got := make(map[int]string)
+	for _, row := range result.Rows {
+		got[row.Line] = ""
+		if len(row.Errors) > 0 {
+			got[row.Line] = row.Errors[0].Code
+		}
+		if row.Valid != (row.CandidateID != nil) {
+			t.Errorf("line %d: valid = %v, candidate = %v", row.Line, row.Valid, row.CandidateID)
+		}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("row errors = %v, want %v", got, want)
+	}
+	if result.Total != 7 || result.Valid != 2 || result.Invalid != 5 || result.Imported != 2 {
+		t.Errorf("total = %d, valid = %d, invalid = %d, imported = %d; want 7, 2, 5, 2", result.Total, result.Valid, result.Invalid, result.Imported)
+	}
+
+	if _, err := service.ImportCandidates(jobID+1, strings.NewReader(data), CandidateImportOptions{}, "hr"); err == nil || err.Error() != "job not found" {
+		t.Errorf("ImportCandidates() for missing job error = %v, want job not found", err)
+	}
+}
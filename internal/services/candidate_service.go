@@ -5,24 +5,47 @@ import (
 	"choizee/internal/models"
 	"database/sql"
 	"fmt"
+	"strings"
 )
 
 type CandidateService struct {
-	db *database.DB
+	db          *database.DB
+	audit       *AuditService
+	attachments *AttachmentService
 }
 
-func NewCandidateService(db *database.DB) *CandidateService {
-	return &CandidateService{db: db}
+func NewCandidateService(db *database.DB, audit *AuditService, attachments *AttachmentService) *CandidateService {
+	return &CandidateService{db: db, audit: audit, attachments: attachments}
 }
 
-// CreateCandidate создает нового кандидата
-func (s *CandidateService) CreateCandidate(candidate *models.Candidate) (*models.Candidate, error) {
+// CreateCandidate создает нового кандидата вместе с откликом на его вакансию
+func (s *CandidateService) CreateCandidate(candidate *models.Candidate, actor string) (*models.Candidate, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	created, err := s.createCandidate(tx, candidate, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return created, nil
+}
+
+// createCandidate добавляет кандидата и его отклик в транзакции tx и записывает это в журнал аудита
+func (s *CandidateService) createCandidate(tx *sql.Tx, candidate *models.Candidate, actor string) (*models.Candidate, error) {
 	query := `
 		INSERT INTO candidates (job_id, name, email, phone, description) 
 		VALUES (?, ?, ?, ?, ?)
 	`
 
-	result, err := s.db.Exec(query, candidate.JobID, candidate.Name, candidate.Email, candidate.Phone, candidate.Description)
+	result, err := tx.Exec(query, candidate.JobID, candidate.Name, candidate.Email, candidate.Phone, candidate.Description)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create candidate: %w", err)
 	}
@@ -33,45 +56,63 @@ func (s *CandidateService) CreateCandidate(candidate *models.Candidate) (*models
 	}
 
 	candidate.ID = id
-	return s.GetCandidateByID(id)
+	if candidate.JobID != 0 {
+		if _, err := insertApplication(tx, id, candidate.JobID); err != nil {
+			return nil, err
+		}
+	}
+
+	created, err := loadCandidate(tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if created.Applications, err = loadCandidateApplications(tx, id); err != nil {
+		return nil, err
+	}
+
+	if err := s.audit.Record(tx, AuditEntityCandidate, id, &id, AuditActionCreate, actor, nil, created); err != nil {
+		return nil, err
+	}
+
+	return created, nil
 }
 
-// GetCandidateByID получает кандидата по ID
+// GetCandidateByID получает кандидата по ID вместе со всеми его откликами
 func (s *CandidateService) GetCandidateByID(id int64) (*models.Candidate, error) {
-	query := `
-		SELECT id, job_id, name, email, phone, description, created_at, updated_at 
-		FROM candidates 
-		WHERE id = ?
-	`
-
-	var candidate models.Candidate
-	err := s.db.QueryRow(query, id).Scan(
-		&candidate.ID, &candidate.JobID, &candidate.Name, &candidate.Email,
-		&candidate.Phone, &candidate.Description, &candidate.CreatedAt, &candidate.UpdatedAt,
-	)
+	candidate, err := loadCandidate(s.db, id)
+	if err != nil {
+		return nil, err
+	}
 
+	candidate.Applications, err = loadCandidateApplications(s.db, id)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("candidate not found")
-		}
-		return nil, fmt.Errorf("failed to get candidate: %w", err)
+		return nil, err
 	}
 
-	return &candidate, nil
+	return candidate, nil
 }
 
-// GetCandidatesByJobID получает всех кандидатов для вакансии
-func (s *CandidateService) GetCandidatesByJobID(jobID int64) ([]models.CandidateWithJob, error) {
+// GetCandidatesByJobID получает кандидатов, откликнувшихся на вакансию.
+// Если stages не пуст, возвращаются только отклики на указанных этапах
+func (s *CandidateService) GetCandidatesByJobID(jobID int64, stages []string) ([]models.CandidateWithJob, error) {
 	query := `
-		SELECT c.id, c.job_id, c.name, c.email, c.phone, c.description, 
-		       c.created_at, c.updated_at, j.title as job_title
-		FROM candidates c
-		JOIN jobs j ON c.job_id = j.id
-		WHERE c.job_id = ?
-		ORDER BY c.created_at DESC
+		SELECT c.id, a.job_id, c.name, c.email, c.phone, c.description, c.created_at, c.updated_at,
+		       j.title as job_title, a.id, a.stage, a.stage_position
+		FROM applications a
+		JOIN candidates c ON c.id = a.candidate_id
+		JOIN jobs j ON a.job_id = j.id
+		WHERE a.job_id = ?
 	`
+	args := []any{jobID}
+	if len(stages) > 0 {
+		query += " AND a.stage IN (?" + strings.Repeat(", ?", len(stages)-1) + ")"
+		for _, stage := range stages {
+			args = append(args, stage)
+		}
+	}
+	query += " ORDER BY a.created_at DESC"
 
-	rows, err := s.db.Query(query, jobID)
+	rows, err := s.db.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get candidates: %w", err)
 	}
@@ -82,8 +123,8 @@ func (s *CandidateService) GetCandidatesByJobID(jobID int64) ([]models.Candidate
 		var candidate models.CandidateWithJob
 		err := rows.Scan(
 			&candidate.ID, &candidate.JobID, &candidate.Name, &candidate.Email,
-			&candidate.Phone, &candidate.Description, &candidate.CreatedAt,
-			&candidate.UpdatedAt, &candidate.JobTitle,
+			&candidate.Phone, &candidate.Description, &candidate.CreatedAt, &candidate.UpdatedAt,
+			&candidate.JobTitle, &candidate.ApplicationID, &candidate.Stage, &candidate.StagePosition,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan candidate: %w", err)
@@ -94,27 +135,96 @@ func (s *CandidateService) GetCandidatesByJobID(jobID int64) ([]models.Candidate
 	return candidates, nil
 }
 
-// UpdateCandidate обновляет кандидата
-func (s *CandidateService) UpdateCandidate(id int64, candidate *models.Candidate) (*models.Candidate, error) {
+// UpdateCandidate обновляет кандидата. При смене вакансии кандидат откликается
+// на новую вакансию, а отклик на прежнюю сохраняется
+func (s *CandidateService) UpdateCandidate(id int64, candidate *models.Candidate, actor string) (*models.Candidate, error) {
+	before, err := loadCandidate(s.db, id)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
 	query := `
 		UPDATE candidates 
 		SET job_id = ?, name = ?, email = ?, phone = ?, description = ?
 		WHERE id = ?
 	`
 
-	_, err := s.db.Exec(query, candidate.JobID, candidate.Name, candidate.Email, candidate.Phone, candidate.Description, id)
+	_, err = tx.Exec(query, candidate.JobID, candidate.Name, candidate.Email, candidate.Phone, candidate.Description, id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update candidate: %w", err)
 	}
 
+	if candidate.JobID != before.JobID && candidate.JobID != 0 {
+		var applied bool
+		err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM applications WHERE candidate_id = ? AND job_id = ?)", id, candidate.JobID).Scan(&applied)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check applications: %w", err)
+		}
+		if !applied {
+			if _, err := insertApplication(tx, id, candidate.JobID); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	updated, err := loadCandidate(tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.audit.Record(tx, AuditEntityCandidate, id, &id, AuditActionUpdate, actor, before, updated); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
 	return s.GetCandidateByID(id)
 }
 
-// DeleteCandidate удаляет кандидата
-func (s *CandidateService) DeleteCandidate(id int64) error {
+// DeleteCandidate удаляет кандидата вместе со всеми его откликами и вложениями
+func (s *CandidateService) DeleteCandidate(id int64, actor string) error {
+	before, err := s.GetCandidateByID(id)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := deleteApplications(tx, "candidate_id = ?", id); err != nil {
+		return err
+	}
+
+	// Закрываем промежутки в колонках, из которых ушли отклики
+	for _, application := range before.Applications {
+		column, err := stageColumn(tx, application.JobID, application.Stage, application.ID)
+		if err != nil {
+			return err
+		}
+		if err := renumberColumn(tx, column); err != nil {
+			return err
+		}
+	}
+
+	attachmentHashes, err := s.attachments.deleteCandidateAttachments(tx, id)
+	if err != nil {
+		return err
+	}
+
 	query := `DELETE FROM candidates WHERE id = ?`
 
-	result, err := s.db.Exec(query, id)
+	result, err := tx.Exec(query, id)
 	if err != nil {
 		return fmt.Errorf("failed to delete candidate: %w", err)
 	}
@@ -128,5 +238,38 @@ func (s *CandidateService) DeleteCandidate(id int64) error {
 		return fmt.Errorf("candidate not found")
 	}
 
+	if err := s.audit.Record(tx, AuditEntityCandidate, id, &id, AuditActionDelete, actor, before, nil); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	s.attachments.removeUnreferenced(attachmentHashes)
 	return nil
 }
+
+// loadCandidate читает кандидата как через подключение, так и внутри транзакции
+func loadCandidate(q querier, id int64) (*models.Candidate, error) {
+	query := `
+		SELECT id, job_id, name, email, phone, description, created_at, updated_at 
+		FROM candidates 
+		WHERE id = ?
+	`
+
+	var candidate models.Candidate
+	err := q.QueryRow(query, id).Scan(
+		&candidate.ID, &candidate.JobID, &candidate.Name, &candidate.Email, &candidate.Phone,
+		&candidate.Description, &candidate.CreatedAt, &candidate.UpdatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("candidate not found")
+		}
+		return nil, fmt.Errorf("failed to get candidate: %w", err)
+	}
+
+	return &candidate, nil
+}
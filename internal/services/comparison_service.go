@@ -0,0 +1,243 @@
+package services
+
+import (
+	"choizee/internal/database"
+	"choizee/internal/models"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Сравнивать можно от двух до maxComparedCandidates кандидатов: больше не помещается на страницу
+const (
+	minComparedCandidates = 2
+	maxComparedCandidates = 10
+)
+
+var (
+	// ErrInvalidComparison возвращается при некорректном наборе кандидатов для сравнения
+	ErrInvalidComparison = errors.New("invalid comparison")
+	// ErrComparisonNotFound возвращается, если вакансии для сравнения нет
+	ErrComparisonNotFound = errors.New("job not found")
+)
+
+type ComparisonService struct {
+	db          *database.DB
+	evaluations *EvaluationService
+}
+
+func NewComparisonService(db *database.DB, evaluations *EvaluationService) *ComparisonService {
+	return &ComparisonService{db: db, evaluations: evaluations}
+}
+
+// CompareCandidates сравнивает кандидатов вакансии бок о бок: оценки по каждому критерию
+// с лучшими кандидатами и отставанием от них, комментарии интервьюеров и ответы на вопросы.
+// Кандидаты идут в порядке candidateIDs
+func (s *ComparisonService) CompareCandidates(jobID int64, candidateIDs []int64) (*models.CandidateComparison, error) {
+	seen := make(map[int64]bool, len(candidateIDs))
+	var ids []int64
+	for _, id := range candidateIDs {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) < minComparedCandidates || len(ids) > maxComparedCandidates {
+		return nil, fmt.Errorf("%w: select from %d to %d candidates", ErrInvalidComparison, minComparedCandidates, maxComparedCandidates)
+	}
+
+	comparison, _, err := s.compare(jobID, ids)
+	return comparison, err
+}
+
+// compare строит матрицу сравнения для кандидатов ids без ограничения их числа
+// и возвращает вместе с ней сводки этих кандидатов в том же порядке.
+// ids = nil - все кандидаты вакансии в порядке сводки
+func (s *ComparisonService) compare(jobID int64, ids []int64) (*models.CandidateComparison, []*models.EvaluationSummary, error) {
+	comparison := &models.CandidateComparison{
+		JobID:      jobID,
+		Candidates: []models.ComparisonCandidate{},
+		Criteria:   []models.ComparisonCriterion{},
+		Questions:  []models.ComparisonQuestion{},
+	}
+	err := s.db.QueryRow("SELECT title FROM jobs WHERE id = ?", jobID).Scan(&comparison.JobTitle)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil, ErrComparisonNotFound
+		}
+		return nil, nil, fmt.Errorf("failed to get job: %w", err)
+	}
+
+	summaries, err := s.evaluations.GetEvaluationsSummary(jobID, SummaryOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+	all := ids == nil
+	byCandidate := make(map[int64]*models.EvaluationSummary, len(summaries))
+	for i := range summaries {
+		byCandidate[summaries[i].CandidateID] = &summaries[i]
+		if all {
+			ids = append(ids, summaries[i].CandidateID)
+		}
+	}
+
+	// Сводки выбранных кандидатов и их средние оценки по критериям
+	selected := make([]*models.EvaluationSummary, len(ids))
+	criterionScores := make([]map[int64]models.CriterionScoreSummary, len(ids))
+	for i, id := range ids {
+		summary, ok := byCandidate[id]
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: candidate %d has not applied to the job", ErrInvalidComparison, id)
+		}
+		application, err := loadApplication(s.db, summary.ApplicationID)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		selected[i] = summary
+		criterionScores[i] = make(map[int64]models.CriterionScoreSummary, len(summary.Criteria))
+		for _, criterion := range summary.Criteria {
+			criterionScores[i][criterion.CriterionID] = criterion
+		}
+		comparison.Scale = summary.Scale
+		comparison.Candidates = append(comparison.Candidates, models.ComparisonCandidate{
+			CandidateID:     id,
+			CandidateName:   summary.CandidateName,
+			ApplicationID:   summary.ApplicationID,
+			Stage:           application.Stage,
+			WeightedScore:   summary.WeightedScore,
+			NormalizedScore: summary.NormalizedScore,
+			KnockoutFailed:  summary.KnockoutFailed,
+		})
+	}
+
+	criteria, err := loadJobCriteria(s.db, jobID)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, criterion := range criteria {
+		row := models.ComparisonCriterion{
+			CriterionID:   criterion.ID,
+			CriterionName: criterion.Name,
+			Weight:        criterion.Weight,
+			Winners:       []int64{},
+			Cells:         make([]models.ComparisonCell, len(ids)),
+		}
+
+		evaluated := false
+		var best, worst float64
+		for i, id := range ids {
+			row.Cells[i] = models.ComparisonCell{CandidateID: id, Scores: []models.EvaluatorScore{}}
+			scores, ok := criterionScores[i][criterion.ID]
+			if !ok {
+				continue
+			}
+			mean := scores.Mean
+			row.Cells[i].Mean = &mean
+			row.Cells[i].Scores = scores.Scores
+			if !evaluated || mean > best {
+				best = mean
+			}
+			if !evaluated || mean < worst {
+				worst = mean
+			}
+			evaluated = true
+		}
+
+		if evaluated {
+			row.MaxDelta = best - worst
+			for i := range row.Cells {
+				cell := &row.Cells[i]
+				if cell.Mean == nil {
+					continue
+				}
+				delta := *cell.Mean - best
+				cell.DeltaFromBest = &delta
+				if delta == 0 {
+					row.Winners = append(row.Winners, cell.CandidateID)
+					comparison.Candidates[i].Wins++
+				}
+			}
+		}
+		comparison.Criteria = append(comparison.Criteria, row)
+	}
+
+	comparison.Questions, err = s.compareAnswers(jobID, selected)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return comparison, selected, nil
+}
+
+// compareAnswers собирает ответы выбранных кандидатов на каждый вопрос вакансии
+func (s *ComparisonService) compareAnswers(jobID int64, selected []*models.EvaluationSummary) ([]models.ComparisonQuestion, error) {
+	rows, err := s.db.Query(`
+		SELECT q.id, q.criterion_id, c.name, q.text
+		FROM questions q
+		JOIN criteria c ON q.criterion_id = c.id
+		WHERE q.job_id = ?
+		ORDER BY c.display_order ASC, q.created_at ASC, q.id ASC
+	`, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get questions: %w", err)
+	}
+	defer rows.Close()
+
+	questions := []models.ComparisonQuestion{}
+	for rows.Next() {
+		var question models.ComparisonQuestion
+		if err := rows.Scan(&question.QuestionID, &question.CriterionID, &question.CriterionName, &question.Text); err != nil {
+			return nil, fmt.Errorf("failed to scan question: %w", err)
+		}
+		question.Answers = make([]models.ComparisonAnswer, len(selected))
+		for i, summary := range selected {
+			question.Answers[i] = models.ComparisonAnswer{CandidateID: summary.CandidateID}
+		}
+		questions = append(questions, question)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read questions: %w", err)
+	}
+	if len(selected) == 0 {
+		return questions, nil
+	}
+
+	positions := make(map[int64]int, len(selected))
+	args := make([]any, len(selected))
+	for i, summary := range selected {
+		positions[summary.ApplicationID] = i
+		args[i] = summary.ApplicationID
+	}
+	index := make(map[int64]int, len(questions))
+	for i, question := range questions {
+		index[question.QuestionID] = i
+	}
+
+	answerRows, err := s.db.Query(`
+		SELECT application_id, question_id, COALESCE(answer_text, '')
+		FROM answers
+		WHERE application_id IN (?`+strings.Repeat(", ?", len(args)-1)+`)
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get answers: %w", err)
+	}
+	defer answerRows.Close()
+
+	for answerRows.Next() {
+		var applicationID, questionID int64
+		var text string
+		if err := answerRows.Scan(&applicationID, &questionID, &text); err != nil {
+			return nil, fmt.Errorf("failed to scan answer: %w", err)
+		}
+		if i, ok := index[questionID]; ok {
+			questions[i].Answers[positions[applicationID]].AnswerText = text
+		}
+	}
+	if err := answerRows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read answers: %w", err)
+	}
+
+	return questions, nil
+}
@@ -4,27 +4,51 @@ import (
 	"choizee/internal/database"
 	"choizee/internal/models"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"sort"
+	"strings"
 )
 
+// DefaultCriterionWeight вес критерия, если он не задан
+const DefaultCriterionWeight = 1.0
+
+var (
+	// ErrInvalidRubric возвращается при некорректной рубрике критерия
+	ErrInvalidRubric = errors.New("invalid rubric")
+	// ErrInvalidWeight возвращается, если вес критерия не положительное конечное число
+	ErrInvalidWeight = errors.New("invalid weight")
+)
+
+// criterionColumns поля критерия в порядке, который ожидает scanCriterion
+const criterionColumns = "id, job_id, name, display_order, weight, rubric, min_score, created_at, updated_at"
+
 type CriteriaService struct {
-	db *database.DB
+	db    *database.DB
+	audit *AuditService
 }
 
-func NewCriteriaService(db *database.DB) *CriteriaService {
-	return &CriteriaService{db: db}
+func NewCriteriaService(db *database.DB, audit *AuditService) *CriteriaService {
+	return &CriteriaService{db: db, audit: audit}
 }
 
 // GetJobCriteria получает все критерии для вакансии
 func (s *CriteriaService) GetJobCriteria(jobID int64) ([]models.Criterion, error) {
+	return loadJobCriteria(s.db, jobID)
+}
+
+// loadJobCriteria загружает критерии вакансии в порядке отображения
+func loadJobCriteria(q querier, jobID int64) ([]models.Criterion, error) {
 	query := `
-		SELECT id, job_id, name, display_order, created_at, updated_at 
+		SELECT ` + criterionColumns + ` 
 		FROM criteria 
 		WHERE job_id = ? 
 		ORDER BY display_order ASC, created_at ASC
 	`
 
-	rows, err := s.db.Query(query, jobID)
+	rows, err := q.Query(query, jobID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get criteria: %w", err)
 	}
@@ -32,19 +56,64 @@ func (s *CriteriaService) GetJobCriteria(jobID int64) ([]models.Criterion, error
 
 	var criteria []models.Criterion
 	for rows.Next() {
-		var c models.Criterion
-		err := rows.Scan(&c.ID, &c.JobID, &c.Name, &c.DisplayOrder, &c.CreatedAt, &c.UpdatedAt)
+		c, err := scanCriterion(rows.Scan)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan criterion: %w", err)
+			return nil, err
 		}
 		criteria = append(criteria, c)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read criteria: %w", err)
+	}
 
 	return criteria, nil
 }
 
+// scanCriterion читает критерий, выбранный полями criterionColumns
+func scanCriterion(scan func(dest ...any) error) (models.Criterion, error) {
+	var c models.Criterion
+	var rubric sql.NullString
+	var minScore sql.NullInt64
+	if err := scan(&c.ID, &c.JobID, &c.Name, &c.DisplayOrder, &c.Weight, &rubric, &minScore, &c.CreatedAt, &c.UpdatedAt); err != nil {
+		return c, err
+	}
+	c.MustHave = minScore.Valid
+	c.MinScore = int(minScore.Int64)
+
+	c.Rubric = []models.RubricLevel{}
+	if rubric.Valid && rubric.String != "" {
+		if err := json.Unmarshal([]byte(rubric.String), &c.Rubric); err != nil {
+			return c, fmt.Errorf("failed to decode rubric: %w", err)
+		}
+	}
+	return c, nil
+}
+
 // CreateCriterion создает новый критерий
-func (s *CriteriaService) CreateCriterion(criterion models.Criterion) (*models.Criterion, error) {
+func (s *CriteriaService) CreateCriterion(criterion models.Criterion, actor string) (*models.Criterion, error) {
+	if criterion.Weight == 0 {
+		criterion.Weight = DefaultCriterionWeight
+	}
+	if err := validateCriterionWeight(criterion.Weight); err != nil {
+		return nil, err
+	}
+
+	scale, err := loadJobScale(s.db, criterion.JobID)
+	if err != nil {
+		return nil, err
+	}
+	criterion.Rubric, err = normalizeRubric(scale, criterion.Rubric)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateKnockout(scale, &criterion); err != nil {
+		return nil, err
+	}
+	rubric, err := rubricColumn(criterion.Rubric)
+	if err != nil {
+		return nil, err
+	}
+
 	// Если display_order не указан, ставим в конец
 	if criterion.DisplayOrder == 0 {
 		var maxOrder int
@@ -56,35 +125,85 @@ func (s *CriteriaService) CreateCriterion(criterion models.Criterion) (*models.C
 	}
 
 	query := `
-		INSERT INTO criteria (job_id, name, display_order) 
-		VALUES (?, ?, ?) 
+		INSERT INTO criteria (job_id, name, display_order, weight, rubric, min_score) 
+		VALUES (?, ?, ?, ?, ?, ?) 
 		RETURNING id, created_at, updated_at
 	`
 
-	err := s.db.QueryRow(query, criterion.JobID, criterion.Name, criterion.DisplayOrder).Scan(
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(query, criterion.JobID, criterion.Name, criterion.DisplayOrder, criterion.Weight, rubric, minScoreColumn(criterion)).Scan(
 		&criterion.ID, &criterion.CreatedAt, &criterion.UpdatedAt,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create criterion: %w", err)
 	}
 
+	if err := s.audit.Record(tx, AuditEntityCriterion, criterion.ID, nil, AuditActionCreate, actor, nil, criterion); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
 	return &criterion, nil
 }
 
 // UpdateCriterion обновляет критерий
-func (s *CriteriaService) UpdateCriterion(id int64, update models.CriterionUpdate) (*models.Criterion, error) {
+func (s *CriteriaService) UpdateCriterion(id int64, update models.CriterionUpdate, actor string) (*models.Criterion, error) {
+	if update.Weight != nil {
+		if err := validateCriterionWeight(*update.Weight); err != nil {
+			return nil, err
+		}
+	}
+
+	before, err := s.GetCriterionByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	scale, err := loadJobScale(s.db, before.JobID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Без рубрики в запросе рубрика не меняется
+	rubricLevels := before.Rubric
+	if update.Rubric != nil {
+		rubricLevels, err = normalizeRubric(scale, update.Rubric)
+		if err != nil {
+			return nil, err
+		}
+	}
+	rubric, err := rubricColumn(rubricLevels)
+	if err != nil {
+		return nil, err
+	}
+
+	knockout := *before
+	if err := applyKnockoutUpdate(scale, &knockout, update.MustHave, update.MinScore); err != nil {
+		return nil, err
+	}
+
 	query := `
 		UPDATE criteria 
-		SET name = ?, display_order = ? 
+		SET name = ?, display_order = ?, weight = COALESCE(?, weight), rubric = ?, min_score = ? 
 		WHERE id = ? 
-		RETURNING id, job_id, name, display_order, created_at, updated_at
+		RETURNING ` + criterionColumns + `
 	`
 
-	var criterion models.Criterion
-	err := s.db.QueryRow(query, update.Name, update.DisplayOrder, id).Scan(
-		&criterion.ID, &criterion.JobID, &criterion.Name, &criterion.DisplayOrder,
-		&criterion.CreatedAt, &criterion.UpdatedAt,
-	)
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	criterion, err := scanCriterion(tx.QueryRow(query, update.Name, update.DisplayOrder, update.Weight, rubric, minScoreColumn(knockout), id).Scan)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("criterion not found")
@@ -92,14 +211,27 @@ func (s *CriteriaService) UpdateCriterion(id int64, update models.CriterionUpdat
 		return nil, fmt.Errorf("failed to update criterion: %w", err)
 	}
 
+	if err := s.audit.Record(tx, AuditEntityCriterion, id, nil, AuditActionUpdate, actor, before, criterion); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
 	return &criterion, nil
 }
 
 // DeleteCriterion удаляет критерий
-func (s *CriteriaService) DeleteCriterion(id int64) error {
+func (s *CriteriaService) DeleteCriterion(id int64, actor string) error {
+	before, err := s.GetCriterionByID(id)
+	if err != nil {
+		return err
+	}
+
 	// Проверяем, есть ли связанные вопросы
 	var questionCount int
-	err := s.db.QueryRow("SELECT COUNT(*) FROM questions WHERE criterion_id = ?", id).Scan(&questionCount)
+	err = s.db.QueryRow("SELECT COUNT(*) FROM questions WHERE criterion_id = ?", id).Scan(&questionCount)
 	if err != nil {
 		return fmt.Errorf("failed to check questions count: %w", err)
 	}
@@ -119,8 +251,14 @@ func (s *CriteriaService) DeleteCriterion(id int64) error {
 		return fmt.Errorf("cannot delete criterion: %d evaluations are associated with it", evaluationCount)
 	}
 
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
 	query := "DELETE FROM criteria WHERE id = ?"
-	result, err := s.db.Exec(query, id)
+	result, err := tx.Exec(query, id)
 	if err != nil {
 		return fmt.Errorf("failed to delete criterion: %w", err)
 	}
@@ -134,11 +272,24 @@ func (s *CriteriaService) DeleteCriterion(id int64) error {
 		return fmt.Errorf("criterion not found")
 	}
 
-	return nil
+	if err := deleteCriterionComparisons(tx, id); err != nil {
+		return err
+	}
+
+	if err := s.audit.Record(tx, AuditEntityCriterion, id, nil, AuditActionDelete, actor, before, nil); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 // ReorderCriteria обновляет порядок отображения критериев
-func (s *CriteriaService) ReorderCriteria(jobID int64, criteriaIDs []int64) error {
+func (s *CriteriaService) ReorderCriteria(jobID int64, criteriaIDs []int64, actor string) error {
+	before, err := s.GetJobCriteria(jobID)
+	if err != nil {
+		return err
+	}
+
 	tx, err := s.db.Begin()
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
@@ -154,26 +305,27 @@ func (s *CriteriaService) ReorderCriteria(jobID int64, criteriaIDs []int64) erro
 		}
 	}
 
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+	after, err := loadJobCriteria(tx, jobID)
+	if err != nil {
+		return err
 	}
 
-	return nil
+	if err := s.audit.Record(tx, AuditEntityJobCriteria, jobID, nil, AuditActionUpdate, actor, before, after); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 // GetCriterionByID получает критерий по ID
 func (s *CriteriaService) GetCriterionByID(id int64) (*models.Criterion, error) {
 	query := `
-		SELECT id, job_id, name, display_order, created_at, updated_at 
+		SELECT ` + criterionColumns + ` 
 		FROM criteria 
 		WHERE id = ?
 	`
 
-	var criterion models.Criterion
-	err := s.db.QueryRow(query, id).Scan(
-		&criterion.ID, &criterion.JobID, &criterion.Name, &criterion.DisplayOrder,
-		&criterion.CreatedAt, &criterion.UpdatedAt,
-	)
+	criterion, err := scanCriterion(s.db.QueryRow(query, id).Scan)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("criterion not found")
@@ -185,7 +337,15 @@ func (s *CriteriaService) GetCriterionByID(id int64) (*models.Criterion, error)
 }
 
 // UpdateJobCriteria полностью заменяет критерии вакансии
-func (s *CriteriaService) UpdateJobCriteria(jobID int64, criteriaNames []string) ([]models.Criterion, error) {
+func (s *CriteriaService) UpdateJobCriteria(jobID int64, inputs []models.CriterionInput, actor string) ([]models.Criterion, error) {
+	for _, input := range inputs {
+		if input.Weight != nil {
+			if err := validateCriterionWeight(*input.Weight); err != nil {
+				return nil, fmt.Errorf("criterion '%s': %w", input.Name, err)
+			}
+		}
+	}
+
 	tx, err := s.db.Begin()
 	if err != nil {
 		return nil, fmt.Errorf("failed to begin transaction: %w", err)
@@ -198,42 +358,74 @@ func (s *CriteriaService) UpdateJobCriteria(jobID int64, criteriaNames []string)
 		return nil, fmt.Errorf("failed to get existing criteria: %w", err)
 	}
 
+	scale, err := loadJobScale(tx, jobID)
+	if err != nil {
+		return nil, err
+	}
+
 	var result []models.Criterion
 
 	// Обрабатываем критерии по позициям
-	for i, name := range criteriaNames {
+	for i, input := range inputs {
 		if i < len(existingCriteria) {
-			// Обновляем существующий критерий (может изменяться название и порядок)
+			// Обновляем существующий критерий (может изменяться название, порядок, вес, рубрика и порог)
 			existing := existingCriteria[i]
-			_, err := tx.Exec("UPDATE criteria SET name = ?, display_order = ? WHERE id = ?",
-				name, i, existing.ID)
+			if input.Weight != nil {
+				existing.Weight = *input.Weight
+			}
+			if input.Rubric != nil {
+				existing.Rubric, err = normalizeRubric(scale, input.Rubric)
+				if err != nil {
+					return nil, fmt.Errorf("criterion '%s': %w", input.Name, err)
+				}
+			}
+			if err := applyKnockoutUpdate(scale, &existing, input.MustHave, input.MinScore); err != nil {
+				return nil, fmt.Errorf("criterion '%s': %w", input.Name, err)
+			}
+			rubric, err := rubricColumn(existing.Rubric)
+			if err != nil {
+				return nil, err
+			}
+			_, err = tx.Exec("UPDATE criteria SET name = ?, display_order = ?, weight = ?, rubric = ?, min_score = ? WHERE id = ?",
+				input.Name, i, existing.Weight, rubric, minScoreColumn(existing), existing.ID)
 			if err != nil {
 				return nil, fmt.Errorf("failed to update criterion: %w", err)
 			}
 
 			// Обновляем данные для ответа
-			existing.Name = name
+			existing.Name = input.Name
 			existing.DisplayOrder = i
 			result = append(result, existing)
 		} else {
 			// Создаем новый критерий (если критериев стало больше)
-			var newCriterion models.Criterion
-			err := tx.QueryRow(
-				"INSERT INTO criteria (job_id, name, display_order) VALUES (?, ?, ?) RETURNING id, created_at, updated_at",
-				jobID, name, i,
+			newCriterion := models.Criterion{JobID: jobID, Name: input.Name, DisplayOrder: i, Weight: DefaultCriterionWeight}
+			if input.Weight != nil {
+				newCriterion.Weight = *input.Weight
+			}
+			newCriterion.Rubric, err = normalizeRubric(scale, input.Rubric)
+			if err != nil {
+				return nil, fmt.Errorf("criterion '%s': %w", input.Name, err)
+			}
+			if err := applyKnockoutUpdate(scale, &newCriterion, input.MustHave, input.MinScore); err != nil {
+				return nil, fmt.Errorf("criterion '%s': %w", input.Name, err)
+			}
+			rubric, err := rubricColumn(newCriterion.Rubric)
+			if err != nil {
+				return nil, err
+			}
+			err = tx.QueryRow(
+				"INSERT INTO criteria (job_id, name, display_order, weight, rubric, min_score) VALUES (?, ?, ?, ?, ?, ?) RETURNING id, created_at, updated_at",
+				jobID, input.Name, i, newCriterion.Weight, rubric, minScoreColumn(newCriterion),
 			).Scan(&newCriterion.ID, &newCriterion.CreatedAt, &newCriterion.UpdatedAt)
 			if err != nil {
 				return nil, fmt.Errorf("failed to create criterion: %w", err)
 			}
-			newCriterion.JobID = jobID
-			newCriterion.Name = name
-			newCriterion.DisplayOrder = i
 			result = append(result, newCriterion)
 		}
 	}
 
 	// Удаляем лишние критерии (если критериев стало меньше)
-	for i := len(criteriaNames); i < len(existingCriteria); i++ {
+	for i := len(inputs); i < len(existingCriteria); i++ {
 		criterion := existingCriteria[i]
 
 		// Проверяем наличие связанных данных
@@ -256,6 +448,13 @@ func (s *CriteriaService) UpdateJobCriteria(jobID int64, criteriaNames []string)
 		if err != nil {
 			return nil, fmt.Errorf("failed to delete criterion: %w", err)
 		}
+		if err := deleteCriterionComparisons(tx, criterion.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.audit.Record(tx, AuditEntityJobCriteria, jobID, nil, AuditActionUpdate, actor, existingCriteria, result); err != nil {
+		return nil, err
 	}
 
 	if err := tx.Commit(); err != nil {
@@ -264,3 +463,58 @@ func (s *CriteriaService) UpdateJobCriteria(jobID int64, criteriaNames []string)
 
 	return result, nil
 }
+
+// deleteCriterionComparisons удаляет попарные сравнения удаленного критерия
+func deleteCriterionComparisons(exec execer, criterionID int64) error {
+	_, err := exec.Exec("DELETE FROM criterion_comparisons WHERE criterion_a_id = ? OR criterion_b_id = ?", criterionID, criterionID)
+	if err != nil {
+		return fmt.Errorf("failed to delete criterion comparisons: %w", err)
+	}
+	return nil
+}
+
+// validateCriterionWeight проверяет, что вес критерия - положительное конечное число
+func validateCriterionWeight(weight float64) error {
+	if math.IsNaN(weight) || math.IsInf(weight, 0) || weight <= 0 {
+		return fmt.Errorf("%w: weight must be a positive number", ErrInvalidWeight)
+	}
+	return nil
+}
+
+// normalizeRubric проверяет рубрику по шкале вакансии: у каждого уровня допустимая оценка
+// и описание, оценки не повторяются. Возвращает уровни по возрастанию оценки
+func normalizeRubric(scale models.ScoringScale, rubric []models.RubricLevel) ([]models.RubricLevel, error) {
+	result := make([]models.RubricLevel, 0, len(rubric))
+	seen := make(map[int]bool)
+	for _, level := range rubric {
+		level.Description = strings.TrimSpace(level.Description)
+		if level.Description == "" {
+			return nil, fmt.Errorf("%w: level %d has no description", ErrInvalidRubric, level.Score)
+		}
+		if err := validateScore(scale, level.Score); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidRubric, err)
+		}
+		if seen[level.Score] {
+			return nil, fmt.Errorf("%w: duplicate level %d", ErrInvalidRubric, level.Score)
+		}
+		seen[level.Score] = true
+		result = append(result, level)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Score < result[j].Score
+	})
+	return result, nil
+}
+
+// rubricColumn возвращает значение поля rubric: JSON уровней или NULL для пустой рубрики
+func rubricColumn(rubric []models.RubricLevel) (any, error) {
+	if len(rubric) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(rubric)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode rubric: %w", err)
+	}
+	return string(data), nil
+}
@@ -3,35 +3,100 @@ package services
 import (
 	"choizee/internal/database"
 	"choizee/internal/models"
+	"choizee/internal/stats"
+	"database/sql"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 )
 
+// ErrInvalidCriterion возвращается, если оценка ставится по критерию другой вакансии
+var ErrInvalidCriterion = errors.New("invalid criterion")
+
 type EvaluationService struct {
-	db *database.DB
+	db    *database.DB
+	audit *AuditService
+}
+
+func NewEvaluationService(db *database.DB, audit *AuditService) *EvaluationService {
+	return &EvaluationService{db: db, audit: audit}
 }
 
-func NewEvaluationService(db *database.DB) *EvaluationService {
-	return &EvaluationService{db: db}
+// SummaryOptions параметры сводки оценок по вакансии
+type SummaryOptions struct {
+	Method            string // Метод ранжирования: weighted (по умолчанию), topsis или ahp
+	ExcludeKnockedOut bool   // Не включать кандидатов, не прошедших отсеивающие критерии
+	Calibrate         bool   // Добавить оценки с поправкой на строгость интервьюеров
 }
 
-// CreateEvaluation создает новую оценку
-func (s *EvaluationService) CreateEvaluation(evaluation *models.Evaluation) (*models.Evaluation, error) {
+// CreateEvaluation создает новую оценку или заменяет оценку того же интервьюера по критерию.
+// Если отклик не указан, оценка относится к отклику кандидата на вакансию критерия,
+// если не указан интервьюер - оценку ставит автор изменения
+func (s *EvaluationService) CreateEvaluation(evaluation *models.Evaluation, actor string) (*models.Evaluation, error) {
+	evaluation.Evaluator = evaluatorOrActor(evaluation.Evaluator, actor)
+
+	if evaluation.ApplicationID == 0 {
+		err := s.db.QueryRow(`
+			SELECT a.id FROM applications a
+			JOIN criteria c ON c.job_id = a.job_id
+			WHERE a.candidate_id = ? AND c.id = ?
+		`, evaluation.CandidateID, evaluation.CriterionID).Scan(&evaluation.ApplicationID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return nil, fmt.Errorf("application not found")
+			}
+			return nil, fmt.Errorf("failed to get application: %w", err)
+		}
+	}
+
+	application, err := loadApplication(s.db, evaluation.ApplicationID)
+	if err != nil {
+		return nil, err
+	}
+	evaluation.CandidateID = application.CandidateID
+
+	if err := checkJobCriteria(s.db, application.JobID, []models.Evaluation{*evaluation}); err != nil {
+		return nil, err
+	}
+
+	scale, err := loadJobScale(s.db, application.JobID)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateScore(scale, evaluation.Score); err != nil {
+		return nil, err
+	}
+
+	before, err := s.findEvaluation(evaluation.ApplicationID, evaluation.CriterionID, evaluation.Evaluator)
+	if err != nil {
+		return nil, err
+	}
+
 	evaluation.CreatedAt = time.Now()
 	evaluation.UpdatedAt = time.Now()
 
 	query := `
-		INSERT INTO evaluations (candidate_id, criterion_id, score, comments, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?)
-		ON CONFLICT(candidate_id, criterion_id) DO UPDATE SET
+		INSERT INTO evaluations (application_id, candidate_id, criterion_id, evaluator, score, comments, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(application_id, criterion_id, evaluator) DO UPDATE SET
 			score = excluded.score,
 			comments = excluded.comments,
 			updated_at = excluded.updated_at
 	`
 
-	result, err := s.db.Exec(query,
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(query,
+		evaluation.ApplicationID,
 		evaluation.CandidateID,
 		evaluation.CriterionID,
+		evaluation.Evaluator,
 		evaluation.Score,
 		evaluation.Comments,
 		evaluation.CreatedAt,
@@ -47,50 +112,152 @@ func (s *EvaluationService) CreateEvaluation(evaluation *models.Evaluation) (*mo
 	}
 
 	evaluation.ID = id
+	action := AuditActionCreate
+	if before != nil {
+		// При конфликте строка обновляется, а LastInsertId не относится к ней
+		evaluation.ID = before.ID
+		action = AuditActionUpdate
+	}
+
+	if _, err := s.recordRevision(tx, application, actor); err != nil {
+		return nil, err
+	}
+
+	if err := rejectKnockedOut(tx, s.audit, application.ID, actor); err != nil {
+		return nil, err
+	}
+
+	if err := s.audit.Record(tx, AuditEntityEvaluation, evaluation.ID, &evaluation.CandidateID, action, actor, before, evaluation); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
 	return evaluation, nil
 }
 
-// GetEvaluationsByCandidate получает все оценки кандидата
-func (s *EvaluationService) GetEvaluationsByCandidate(candidateID int64) ([]models.Evaluation, error) {
+// GetEvaluationsByApplication получает оценки отклика: все или только одного интервьюера
+func (s *EvaluationService) GetEvaluationsByApplication(applicationID int64, evaluator string) ([]models.Evaluation, error) {
+	return loadApplicationEvaluations(s.db, applicationID, evaluator)
+}
+
+// GetEvaluationForm возвращает оценочный лист интервьюера по отклику: критерии вакансии
+// с рубриками и уже поставленные им оценки. Если интервьюер не указан, лист принадлежит автору запроса
+func (s *EvaluationService) GetEvaluationForm(applicationID int64, evaluator, actor string) (*models.EvaluationForm, error) {
+	application, err := loadApplication(s.db, applicationID)
+	if err != nil {
+		return nil, err
+	}
+
+	form := &models.EvaluationForm{
+		ApplicationID: application.ID,
+		CandidateID:   application.CandidateID,
+		JobID:         application.JobID,
+		JobTitle:      application.JobTitle,
+		Evaluator:     evaluatorOrActor(evaluator, actor),
+		Criteria:      []models.EvaluationFormCriterion{},
+	}
+
+	err = s.db.QueryRow("SELECT name FROM candidates WHERE id = ?", application.CandidateID).Scan(&form.CandidateName)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get candidate: %w", err)
+	}
+
+	form.Scale, err = loadJobScale(s.db, application.JobID)
+	if err != nil {
+		return nil, err
+	}
+
+	criteria, err := loadJobCriteria(s.db, application.JobID)
+	if err != nil {
+		return nil, err
+	}
+	evaluations, err := loadApplicationEvaluations(s.db, applicationID, form.Evaluator)
+	if err != nil {
+		return nil, err
+	}
+	byCriterion := make(map[int64]*models.Evaluation, len(evaluations))
+	for i := range evaluations {
+		byCriterion[evaluations[i].CriterionID] = &evaluations[i]
+	}
+
+	for _, criterion := range criteria {
+		form.Criteria = append(form.Criteria, models.EvaluationFormCriterion{
+			Criterion:  criterion,
+			Evaluation: byCriterion[criterion.ID],
+		})
+	}
+
+	return form, nil
+}
+
+// getEvaluationByID получает оценку по ID
+func (s *EvaluationService) getEvaluationByID(id int64) (*models.Evaluation, error) {
 	query := `
-		SELECT e.id, e.candidate_id, e.criterion_id, e.score, e.comments, e.created_at, e.updated_at,
-		       c.name as criterion_name
-		FROM evaluations e
-		JOIN criteria c ON e.criterion_id = c.id
-		WHERE e.candidate_id = ?
-		ORDER BY c.display_order
+		SELECT id, application_id, candidate_id, criterion_id, evaluator, score, comments, created_at, updated_at
+		FROM evaluations
+		WHERE id = ?
 	`
 
-	rows, err := s.db.Query(query, candidateID)
+	var eval models.Evaluation
+	err := s.db.QueryRow(query, id).Scan(
+		&eval.ID, &eval.ApplicationID, &eval.CandidateID, &eval.CriterionID, &eval.Evaluator, &eval.Score,
+		&eval.Comments, &eval.CreatedAt, &eval.UpdatedAt,
+	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query evaluations: %w", err)
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("evaluation not found")
+		}
+		return nil, fmt.Errorf("failed to get evaluation: %w", err)
 	}
-	defer rows.Close()
 
-	var evaluations []models.Evaluation
-	for rows.Next() {
-		var eval models.Evaluation
-		err := rows.Scan(
-			&eval.ID,
-			&eval.CandidateID,
-			&eval.CriterionID,
-			&eval.Score,
-			&eval.Comments,
-			&eval.CreatedAt,
-			&eval.UpdatedAt,
-			&eval.CriterionName,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan evaluation: %w", err)
+	return &eval, nil
+}
+
+// findEvaluation получает оценку интервьюера по критерию отклика, nil если ее нет
+func (s *EvaluationService) findEvaluation(applicationID, criterionID int64, evaluator string) (*models.Evaluation, error) {
+	query := `
+		SELECT id, application_id, candidate_id, criterion_id, evaluator, score, comments, created_at, updated_at
+		FROM evaluations
+		WHERE application_id = ? AND criterion_id = ? AND evaluator = ?
+	`
+
+	var eval models.Evaluation
+	err := s.db.QueryRow(query, applicationID, criterionID, evaluator).Scan(
+		&eval.ID, &eval.ApplicationID, &eval.CandidateID, &eval.CriterionID, &eval.Evaluator, &eval.Score,
+		&eval.Comments, &eval.CreatedAt, &eval.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
 		}
-		evaluations = append(evaluations, eval)
+		return nil, fmt.Errorf("failed to get evaluation: %w", err)
 	}
 
-	return evaluations, nil
+	return &eval, nil
 }
 
 // UpdateEvaluation обновляет существующую оценку
-func (s *EvaluationService) UpdateEvaluation(id int64, evaluation *models.Evaluation) (*models.Evaluation, error) {
+func (s *EvaluationService) UpdateEvaluation(id int64, evaluation *models.Evaluation, actor string) (*models.Evaluation, error) {
+	before, err := s.getEvaluationByID(id)
+	if err != nil {
+		return nil, err
+	}
+	application, err := loadApplication(s.db, before.ApplicationID)
+	if err != nil {
+		return nil, err
+	}
+
+	scale, err := loadJobScale(s.db, application.JobID)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateScore(scale, evaluation.Score); err != nil {
+		return nil, err
+	}
+
 	evaluation.UpdatedAt = time.Now()
 
 	query := `
@@ -99,7 +266,13 @@ func (s *EvaluationService) UpdateEvaluation(id int64, evaluation *models.Evalua
 		WHERE id = ?
 	`
 
-	_, err := s.db.Exec(query,
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(query,
 		evaluation.Score,
 		evaluation.Comments,
 		evaluation.UpdatedAt,
@@ -110,39 +283,112 @@ func (s *EvaluationService) UpdateEvaluation(id int64, evaluation *models.Evalua
 	}
 
 	evaluation.ID = id
+	evaluation.ApplicationID = before.ApplicationID
+	evaluation.CandidateID = before.CandidateID
+	evaluation.CriterionID = before.CriterionID
+	evaluation.Evaluator = before.Evaluator
+	if _, err := s.recordRevision(tx, application, actor); err != nil {
+		return nil, err
+	}
+
+	if err := rejectKnockedOut(tx, s.audit, application.ID, actor); err != nil {
+		return nil, err
+	}
+
+	if err := s.audit.Record(tx, AuditEntityEvaluation, id, &before.CandidateID, AuditActionUpdate, actor, before, evaluation); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
 	return evaluation, nil
 }
 
 // DeleteEvaluation удаляет оценку
-func (s *EvaluationService) DeleteEvaluation(id int64) error {
+func (s *EvaluationService) DeleteEvaluation(id int64, actor string) error {
+	before, err := s.getEvaluationByID(id)
+	if err != nil {
+		return err
+	}
+	application, err := loadApplication(s.db, before.ApplicationID)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
 	query := `DELETE FROM evaluations WHERE id = ?`
 
-	_, err := s.db.Exec(query, id)
+	_, err = tx.Exec(query, id)
 	if err != nil {
 		return fmt.Errorf("failed to delete evaluation: %w", err)
 	}
 
-	return nil
+	if _, err := s.recordRevision(tx, application, actor); err != nil {
+		return err
+	}
+
+	if err := rejectKnockedOut(tx, s.audit, application.ID, actor); err != nil {
+		return err
+	}
+
+	if err := s.audit.Record(tx, AuditEntityEvaluation, id, &before.CandidateID, AuditActionDelete, actor, before, nil); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
-// SaveCandidateEvaluations сохраняет все оценки кандидата одной транзакцией
-func (s *EvaluationService) SaveCandidateEvaluations(candidateID int64, evaluations []models.Evaluation) error {
-	tx, err := s.db.Begin()
+// SaveApplicationEvaluations сохраняет оценочный лист интервьюера по отклику одной транзакцией.
+// Если интервьюер не указан, лист принадлежит автору изменения. Оценки других интервьюеров
+// не затрагиваются, а каждое сохранение фиксируется новой ревизией. Если кандидат не прошел
+// отсеивающий критерий, а вакансия отказывает автоматически, отклик переводится в отказ
+func (s *EvaluationService) SaveApplicationEvaluations(applicationID int64, evaluator string, evaluations []models.Evaluation, actor string) error {
+	evaluator = evaluatorOrActor(evaluator, actor)
+
+	application, err := loadApplication(s.db, applicationID)
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return err
 	}
-	defer tx.Rollback()
 
-	// Удаляем существующие оценки кандидата
-	_, err = tx.Exec("DELETE FROM evaluations WHERE candidate_id = ?", candidateID)
+	if err := checkJobCriteria(s.db, application.JobID, evaluations); err != nil {
+		return err
+	}
+
+	scale, err := loadJobScale(s.db, application.JobID)
 	if err != nil {
-		return fmt.Errorf("failed to delete existing evaluations: %w", err)
+		return err
+	}
+	for _, eval := range evaluations {
+		if err := validateScore(scale, eval.Score); err != nil {
+			return fmt.Errorf("criterion %d: %w", eval.CriterionID, err)
+		}
 	}
 
-	// Вставляем новые оценки
+	before, err := s.GetEvaluationsByApplication(applicationID, evaluator)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
 	stmt, err := tx.Prepare(`
-		INSERT INTO evaluations (candidate_id, criterion_id, score, comments, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?)
+		INSERT INTO evaluations (application_id, candidate_id, criterion_id, evaluator, score, comments, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(application_id, criterion_id, evaluator) DO UPDATE SET
+			score = excluded.score,
+			comments = excluded.comments,
+			updated_at = excluded.updated_at
 	`)
 	if err != nil {
 		return fmt.Errorf("failed to prepare statement: %w", err)
@@ -150,37 +396,93 @@ func (s *EvaluationService) SaveCandidateEvaluations(candidateID int64, evaluati
 	defer stmt.Close()
 
 	now := time.Now()
+	criterionIDs := make([]any, 0, len(evaluations)+2)
+	criterionIDs = append(criterionIDs, applicationID, evaluator)
 	for _, eval := range evaluations {
 		_, err = stmt.Exec(
-			candidateID,
+			applicationID,
+			application.CandidateID,
 			eval.CriterionID,
+			evaluator,
 			eval.Score,
 			eval.Comments,
 			now,
 			now,
 		)
 		if err != nil {
-			return fmt.Errorf("failed to insert evaluation: %w", err)
+			return fmt.Errorf("failed to save evaluation: %w", err)
+		}
+		criterionIDs = append(criterionIDs, eval.CriterionID)
+	}
+
+	// Удаляем оценки интервьюера по критериям, которых нет в новом наборе
+	deleteQuery := "DELETE FROM evaluations WHERE application_id = ? AND evaluator = ?"
+	if len(evaluations) > 0 {
+		deleteQuery += " AND criterion_id NOT IN (?" + strings.Repeat(", ?", len(evaluations)-1) + ")"
+	}
+	if _, err := tx.Exec(deleteQuery, criterionIDs...); err != nil {
+		return fmt.Errorf("failed to delete removed evaluations: %w", err)
+	}
+
+	revision, err := s.recordRevision(tx, application, actor)
+	if err != nil {
+		return err
+	}
+
+	after := []models.EvaluationRevisionItem{}
+	for _, item := range revision.Evaluations {
+		if item.Evaluator == evaluator {
+			after = append(after, item)
 		}
 	}
 
+	if err := s.audit.Record(tx, AuditEntityApplicationEvaluations, applicationID, &application.CandidateID, AuditActionUpdate, actor, before, after); err != nil {
+		return err
+	}
+
+	if err := rejectKnockedOut(tx, s.audit, applicationID, actor); err != nil {
+		return err
+	}
+
 	return tx.Commit()
 }
 
-// GetEvaluationsSummary получает сводку оценок для сравнения кандидатов
-func (s *EvaluationService) GetEvaluationsSummary(jobID int64) ([]models.EvaluationSummary, error) {
+// checkJobCriteria проверяет, что все оценки ставятся по критериям вакансии отклика
+func checkJobCriteria(q querier, jobID int64, evaluations []models.Evaluation) error {
+	criteria, err := loadJobCriteria(q, jobID)
+	if err != nil {
+		return err
+	}
+	known := make(map[int64]bool, len(criteria))
+	for _, criterion := range criteria {
+		known[criterion.ID] = true
+	}
+	for _, eval := range evaluations {
+		if !known[eval.CriterionID] {
+			return fmt.Errorf("%w: criterion %d does not belong to job %d", ErrInvalidCriterion, eval.CriterionID, jobID)
+		}
+	}
+	return nil
+}
+
+// GetEvaluationsSummary получает сводку оценок для сравнения кандидатов. Оценки интервьюеров
+// сводятся по каждому критерию в среднее, медиану и разброс; итоговая оценка - среднее
+// по критериям, поэтому критерий с несколькими интервьюерами не весит больше остальных.
+// Кандидаты упорядочены методом ранжирования из options; не прошедшие отсеивающие критерии
+// идут в конце сводки независимо от оценки, а с ExcludeKnockedOut не попадают в нее вовсе
+func (s *EvaluationService) GetEvaluationsSummary(jobID int64, options SummaryOptions) ([]models.EvaluationSummary, error) {
+	method, err := checkRankingMethod(options.Method)
+	if err != nil {
+		return nil, err
+	}
+
 	query := `
-		SELECT 
-			c.id,
-			c.name,
-			j.title,
-			COALESCE(AVG(e.score), 0) as avg_score
-		FROM candidates c
-		JOIN jobs j ON c.job_id = j.id
-		LEFT JOIN evaluations e ON c.id = e.candidate_id
-		WHERE c.job_id = ?
-		GROUP BY c.id, c.name, j.title
-		ORDER BY avg_score DESC
+		SELECT a.id, c.id, c.name, j.title
+		FROM applications a
+		JOIN candidates c ON a.candidate_id = c.id
+		JOIN jobs j ON a.job_id = j.id
+		WHERE a.job_id = ?
+		ORDER BY a.id
 	`
 
 	rows, err := s.db.Query(query, jobID)
@@ -193,30 +495,409 @@ func (s *EvaluationService) GetEvaluationsSummary(jobID int64) ([]models.Evaluat
 	for rows.Next() {
 		var summary models.EvaluationSummary
 		err := rows.Scan(
+			&summary.ApplicationID,
 			&summary.CandidateID,
 			&summary.CandidateName,
 			&summary.JobTitle,
-			&summary.AverageScore,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan summary: %w", err)
 		}
+		summaries = append(summaries, summary)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read summary: %w", err)
+	}
+
+	if len(summaries) == 0 {
+		return summaries, nil
+	}
+
+	scale, err := loadJobScale(s.db, jobID)
+	if err != nil {
+		return nil, err
+	}
+	criteria, err := loadJobCriteria(s.db, jobID)
+	if err != nil {
+		return nil, err
+	}
+	weights := make(map[int64]float64, len(criteria))
+	for _, criterion := range criteria {
+		weights[criterion.ID] = criterion.Weight
+	}
+
+	// Поправка на строгость считается по истории оценок интервьюеров во всех вакансиях
+	var profiles map[string]evaluatorProfile
+	var pool evaluatorProfile
+	if options.Calibrate {
+		ratings, err := loadCalibrationRatings(s.db)
+		if err != nil {
+			return nil, err
+		}
+		profiles, pool = evaluatorProfiles(ratings)
+	}
+
+	for i := range summaries {
+		summary := &summaries[i]
 
-		// Получаем детальные оценки для каждого кандидата
-		evaluations, err := s.GetEvaluationsByCandidate(summary.CandidateID)
+		// Получаем детальные оценки для каждого отклика
+		evaluations, err := s.GetEvaluationsByApplication(summary.ApplicationID, "")
 		if err != nil {
 			return nil, err
 		}
 		summary.Evaluations = evaluations
+		summary.Evaluators = evaluatorNames(evaluations)
+		summary.Criteria = summarizeCriteria(evaluations, scale)
+		summary.Scale = scale
+		summary.Knockouts = knockoutFailures(criteria, summary.Criteria)
+		summary.KnockoutFailed = len(summary.Knockouts) > 0
 
 		// Формируем данные для диаграммы
-		summary.ChartData = make(map[string]int)
-		for _, eval := range evaluations {
-			summary.ChartData[eval.CriterionName] = eval.Score
+		summary.ChartData = make(map[string]float64)
+		total, weightedTotal, totalWeight := 0.0, 0.0, 0.0
+		for j := range summary.Criteria {
+			criterion := &summary.Criteria[j]
+			criterion.Weight = weights[criterion.CriterionID]
+			summary.ChartData[criterion.CriterionName] = criterion.Mean
+			total += criterion.Mean
+			weightedTotal += criterion.Weight * criterion.Mean
+			totalWeight += criterion.Weight
+			if criterion.Disagreement {
+				summary.HasDisagreement = true
+			}
 		}
+		if len(summary.Criteria) > 0 {
+			summary.AverageScore = total / float64(len(summary.Criteria))
+		}
+		if totalWeight > 0 {
+			summary.WeightedScore = weightedTotal / totalWeight
+			summary.NormalizedScore = normalizeScore(scale, summary.WeightedScore)
+		}
+		if options.Calibrate {
+			calibrateSummary(summary, profiles, pool, weights)
+		}
+	}
 
-		summaries = append(summaries, summary)
+	if options.ExcludeKnockedOut {
+		passed := summaries[:0]
+		for _, summary := range summaries {
+			if !summary.KnockoutFailed {
+				passed = append(passed, summary)
+			}
+		}
+		summaries = passed
+	}
+
+	if err := rankSummaries(s.db, jobID, method, criteria, summaries); err != nil {
+		return nil, err
 	}
 
 	return summaries, nil
 }
+
+// summarizeCriteria сводит оценки интервьюеров по каждому критерию. Оценки должны
+// быть упорядочены по критериям, как их возвращает loadApplicationEvaluations
+func summarizeCriteria(evaluations []models.Evaluation, scale models.ScoringScale) []models.CriterionScoreSummary {
+	criteria := []models.CriterionScoreSummary{}
+	for _, eval := range evaluations {
+		if len(criteria) == 0 || criteria[len(criteria)-1].CriterionID != eval.CriterionID {
+			criteria = append(criteria, models.CriterionScoreSummary{
+				CriterionID:   eval.CriterionID,
+				CriterionName: eval.CriterionName,
+				Scores:        []models.EvaluatorScore{},
+			})
+		}
+		criterion := &criteria[len(criteria)-1]
+		criterion.Scores = append(criterion.Scores, models.EvaluatorScore{
+			Evaluator: eval.Evaluator,
+			Score:     eval.Score,
+			Comments:  eval.Comments,
+		})
+	}
+
+	for i := range criteria {
+		criterion := &criteria[i]
+		scores := make([]float64, len(criterion.Scores))
+		criterion.Min, criterion.Max = criterion.Scores[0].Score, criterion.Scores[0].Score
+		for j, score := range criterion.Scores {
+			scores[j] = float64(score.Score)
+			criterion.Min = min(criterion.Min, score.Score)
+			criterion.Max = max(criterion.Max, score.Score)
+		}
+
+		criterion.Count = len(scores)
+		criterion.Mean = stats.Mean(scores)
+		criterion.Normalized = normalizeScore(scale, criterion.Mean)
+		criterion.Median = stats.Median(scores)
+		criterion.StdDev = stats.StdDev(scores)
+		criterion.Spread = criterion.Max - criterion.Min
+		criterion.Disagreement = criterion.Count > 1 && isDisagreement(scale, criterion.Spread)
+	}
+
+	return criteria
+}
+
+// evaluatorNames возвращает интервьюеров, оценивших отклик, по алфавиту
+func evaluatorNames(evaluations []models.Evaluation) []string {
+	seen := make(map[string]bool)
+	names := []string{}
+	for _, eval := range evaluations {
+		if !seen[eval.Evaluator] {
+			seen[eval.Evaluator] = true
+			names = append(names, eval.Evaluator)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// evaluatorOrActor возвращает интервьюера, а если он не указан - автора изменения
+func evaluatorOrActor(evaluator, actor string) string {
+	if evaluator = strings.TrimSpace(evaluator); evaluator != "" {
+		return evaluator
+	}
+	return actor
+}
+
+// recordRevision сохраняет текущие оценки отклика как новую ревизию
+func (s *EvaluationService) recordRevision(tx *sql.Tx, application *models.Application, actor string) (*models.EvaluationRevision, error) {
+	revision := models.EvaluationRevision{
+		ApplicationID: application.ID,
+		CandidateID:   application.CandidateID,
+		Actor:         actor,
+		CreatedAt:     time.Now(),
+		Evaluations:   []models.EvaluationRevisionItem{},
+	}
+
+	err := tx.QueryRow(`
+		INSERT INTO evaluation_revisions (application_id, candidate_id, revision, actor, created_at)
+		VALUES (?, ?, COALESCE((SELECT MAX(revision) FROM evaluation_revisions WHERE application_id = ?), 0) + 1, ?, ?)
+		RETURNING id, revision
+	`, application.ID, application.CandidateID, application.ID, actor, revision.CreatedAt).Scan(&revision.ID, &revision.Revision)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create evaluation revision: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO evaluation_revision_items (revision_id, criterion_id, evaluator, score, comments)
+		SELECT ?, criterion_id, evaluator, score, comments
+		FROM evaluations
+		WHERE application_id = ?
+	`, revision.ID, application.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save evaluation revision: %w", err)
+	}
+
+	rows, err := tx.Query(`
+		SELECT i.criterion_id, COALESCE(c.name, ''), i.evaluator, i.score, COALESCE(i.comments, '')
+		FROM evaluation_revision_items i
+		LEFT JOIN criteria c ON i.criterion_id = c.id
+		WHERE i.revision_id = ?
+		ORDER BY c.display_order, i.criterion_id, i.evaluator
+	`, revision.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query evaluation revision: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item models.EvaluationRevisionItem
+		if err := rows.Scan(&item.CriterionID, &item.CriterionName, &item.Evaluator, &item.Score, &item.Comments); err != nil {
+			return nil, fmt.Errorf("failed to scan evaluation revision item: %w", err)
+		}
+		revision.Evaluations = append(revision.Evaluations, item)
+	}
+
+	return &revision, rows.Err()
+}
+
+// GetEvaluationHistory получает все ревизии оценок отклика, начиная с последней
+func (s *EvaluationService) GetEvaluationHistory(applicationID int64) ([]models.EvaluationRevision, error) {
+	rows, err := s.db.Query(`
+		SELECT id, application_id, candidate_id, revision, actor, created_at
+		FROM evaluation_revisions
+		WHERE application_id = ?
+		ORDER BY revision DESC
+	`, applicationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query evaluation revisions: %w", err)
+	}
+	defer rows.Close()
+
+	revisions := []models.EvaluationRevision{}
+	index := make(map[int64]int)
+	for rows.Next() {
+		var revision models.EvaluationRevision
+		err := rows.Scan(&revision.ID, &revision.ApplicationID, &revision.CandidateID, &revision.Revision, &revision.Actor, &revision.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan evaluation revision: %w", err)
+		}
+		revision.Evaluations = []models.EvaluationRevisionItem{}
+		index[revision.ID] = len(revisions)
+		revisions = append(revisions, revision)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read evaluation revisions: %w", err)
+	}
+
+	itemRows, err := s.db.Query(`
+		SELECT i.revision_id, i.criterion_id, COALESCE(c.name, ''), i.evaluator, i.score, COALESCE(i.comments, '')
+		FROM evaluation_revision_items i
+		JOIN evaluation_revisions r ON i.revision_id = r.id
+		LEFT JOIN criteria c ON i.criterion_id = c.id
+		WHERE r.application_id = ?
+		ORDER BY c.display_order, i.criterion_id, i.evaluator
+	`, applicationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query evaluation revision items: %w", err)
+	}
+	defer itemRows.Close()
+
+	for itemRows.Next() {
+		var revisionID int64
+		var item models.EvaluationRevisionItem
+		if err := itemRows.Scan(&revisionID, &item.CriterionID, &item.CriterionName, &item.Evaluator, &item.Score, &item.Comments); err != nil {
+			return nil, fmt.Errorf("failed to scan evaluation revision item: %w", err)
+		}
+		if i, ok := index[revisionID]; ok {
+			revisions[i].Evaluations = append(revisions[i].Evaluations, item)
+		}
+	}
+
+	return revisions, itemRows.Err()
+}
+
+// DiffEvaluationRevisions сравнивает две ревизии оценок отклика по каждому критерию и интервьюеру
+func (s *EvaluationService) DiffEvaluationRevisions(applicationID int64, fromRevision, toRevision int) (*models.EvaluationDiff, error) {
+	application, err := loadApplication(s.db, applicationID)
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := s.GetEvaluationHistory(applicationID)
+	if err != nil {
+		return nil, err
+	}
+
+	var from, to *models.EvaluationRevision
+	for i := range history {
+		if history[i].Revision == fromRevision {
+			from = &history[i]
+		}
+		if history[i].Revision == toRevision {
+			to = &history[i]
+		}
+	}
+	if from == nil {
+		return nil, fmt.Errorf("revision %d not found", fromRevision)
+	}
+	if to == nil {
+		return nil, fmt.Errorf("revision %d not found", toRevision)
+	}
+
+	diff := &models.EvaluationDiff{
+		ApplicationID: applicationID,
+		CandidateID:   application.CandidateID,
+		FromRevision:  fromRevision,
+		ToRevision:    toRevision,
+		Criteria:      []models.EvaluationCriterionDiff{},
+	}
+
+	type itemKey struct {
+		criterionID int64
+		evaluator   string
+	}
+	fromItems := make(map[itemKey]models.EvaluationRevisionItem)
+	for _, item := range from.Evaluations {
+		fromItems[itemKey{item.CriterionID, item.Evaluator}] = item
+	}
+	toItems := make(map[itemKey]models.EvaluationRevisionItem)
+	for _, item := range to.Evaluations {
+		toItems[itemKey{item.CriterionID, item.Evaluator}] = item
+	}
+
+	// Сохраняем порядок критериев: сначала из новой ревизии, затем удаленные
+	for _, item := range to.Evaluations {
+		toScore := item.Score
+		entry := models.EvaluationCriterionDiff{
+			CriterionID:   item.CriterionID,
+			CriterionName: item.CriterionName,
+			Evaluator:     item.Evaluator,
+			Status:        "added",
+			ToScore:       &toScore,
+			ToComments:    item.Comments,
+		}
+		if old, ok := fromItems[itemKey{item.CriterionID, item.Evaluator}]; ok {
+			fromScore := old.Score
+			entry.FromScore = &fromScore
+			entry.FromComments = old.Comments
+			entry.Delta = toScore - fromScore
+			entry.Status = "unchanged"
+			if entry.Delta != 0 || old.Comments != item.Comments {
+				entry.Status = "changed"
+			}
+		}
+		diff.Criteria = append(diff.Criteria, entry)
+	}
+	for _, item := range from.Evaluations {
+		if _, ok := toItems[itemKey{item.CriterionID, item.Evaluator}]; ok {
+			continue
+		}
+		fromScore := item.Score
+		diff.Criteria = append(diff.Criteria, models.EvaluationCriterionDiff{
+			CriterionID:   item.CriterionID,
+			CriterionName: item.CriterionName,
+			Evaluator:     item.Evaluator,
+			Status:        "removed",
+			FromScore:     &fromScore,
+			FromComments:  item.Comments,
+		})
+	}
+
+	return diff, nil
+}
+
+// loadApplicationEvaluations читает оценки отклика в порядке критериев и интервьюеров.
+// Пустой evaluator означает оценки всех интервьюеров
+func loadApplicationEvaluations(q querier, applicationID int64, evaluator string) ([]models.Evaluation, error) {
+	query := `
+		SELECT e.id, e.application_id, e.candidate_id, e.criterion_id, e.evaluator, e.score, e.comments, e.created_at, e.updated_at,
+		       c.name as criterion_name
+		FROM evaluations e
+		JOIN criteria c ON e.criterion_id = c.id
+		WHERE e.application_id = ? AND (? = '' OR e.evaluator = ?)
+		ORDER BY c.display_order, c.id, e.evaluator
+	`
+
+	rows, err := q.Query(query, applicationID, evaluator, evaluator)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query evaluations: %w", err)
+	}
+	defer rows.Close()
+
+	var evaluations []models.Evaluation
+	for rows.Next() {
+		var eval models.Evaluation
+		err := rows.Scan(
+			&eval.ID,
+			&eval.ApplicationID,
+			&eval.CandidateID,
+			&eval.CriterionID,
+			&eval.Evaluator,
+			&eval.Score,
+			&eval.Comments,
+			&eval.CreatedAt,
+			&eval.UpdatedAt,
+			&eval.CriterionName,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan evaluation: %w", err)
+		}
+		evaluations = append(evaluations, eval)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read evaluations: %w", err)
+	}
+
+	return evaluations, nil
+}
@@ -0,0 +1,534 @@
+package services
+
+import (
+	"choizee/internal/database"
+	"choizee/internal/models"
+	"choizee/internal/stats"
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Сила, с которой критерий или вопрос отделяет принятых от отказников
+const (
+	DiscriminationStrong           = "strong"
+	DiscriminationModerate         = "moderate"
+	DiscriminationWeak             = "weak"
+	DiscriminationInsufficientData = "insufficient_data"
+
+	// minOutcomeGroup принятых и столько же отказников нужно, чтобы судить о критерии или вопросе
+	minOutcomeGroup = 2
+	// minRatedHires принятых с оценкой работы нужно для корреляции с ней
+	minRatedHires = 3
+
+	// Пороги для критериев - по |2·AUC - 1| (D Сомерса), для вопросов - по |фи|
+	strongCriterionPower   = 0.4
+	moderateCriterionPower = 0.2
+	strongQuestionPower    = 0.3
+	moderateQuestionPower  = 0.1
+)
+
+type InsightsService struct {
+	db        *database.DB
+	templates *TemplateService
+}
+
+func NewInsightsService(db *database.DB, templates *TemplateService) *InsightsService {
+	return &InsightsService{db: db, templates: templates}
+}
+
+// insightApplication отклик с итогом найма
+type insightApplication struct {
+	id          int64
+	jobID       int64
+	hired       bool
+	performance *int
+}
+
+// insightGroup критерий или вопрос отчета. В отчете по всем вакансиям объединяет
+// одноименные критерии (одинаковые вопросы) разных вакансий
+type insightGroup struct {
+	key           string
+	id            int64
+	name          string
+	criterionName string
+	jobs          map[int64]bool
+}
+
+// GetJobInsights оценивает, какие критерии и вопросы вакансии отличают принятых от отказников
+func (s *InsightsService) GetJobInsights(jobID int64) (*models.ValidityInsights, error) {
+	insights := &models.ValidityInsights{JobID: jobID, Jobs: 1}
+	err := s.db.QueryRow("SELECT title FROM jobs WHERE id = ?", jobID).Scan(&insights.JobTitle)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("job not found")
+		}
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+
+	if err := s.fillInsights(insights, jobID); err != nil {
+		return nil, err
+	}
+	return insights, nil
+}
+
+// GetInsights строит тот же отчет по всем вакансиям: критерии объединяются по названию,
+// вопросы - по тексту, как в шаблонах вакансий
+func (s *InsightsService) GetInsights() (*models.ValidityInsights, error) {
+	insights := &models.ValidityInsights{}
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM jobs").Scan(&insights.Jobs); err != nil {
+		return nil, fmt.Errorf("failed to count jobs: %w", err)
+	}
+
+	if err := s.fillInsights(insights, 0); err != nil {
+		return nil, err
+	}
+	return insights, nil
+}
+
+// fillInsights считает отчет по вакансии jobID (0 - по всем вакансиям)
+func (s *InsightsService) fillInsights(insights *models.ValidityInsights, jobID int64) error {
+	applications, err := loadInsightApplications(s.db, jobID)
+	if err != nil {
+		return err
+	}
+	for _, application := range applications {
+		if application.hired {
+			insights.Hired++
+			if application.performance != nil {
+				insights.RatedHires++
+			}
+		} else {
+			insights.Rejected++
+		}
+	}
+
+	templates, err := s.templates.GetAllTemplates()
+	if err != nil {
+		return err
+	}
+	criterionTemplates := make(map[string][]string)
+	questionTemplates := make(map[string][]string)
+	for _, template := range templates {
+		for _, name := range template.Criteria {
+			criterionTemplates[insightKey(name)] = append(criterionTemplates[insightKey(name)], template.ID)
+		}
+		for _, group := range template.Questions {
+			for _, text := range group.Questions {
+				questionTemplates[insightKey(text)] = append(questionTemplates[insightKey(text)], template.ID)
+			}
+		}
+	}
+
+	criteria, scores, err := loadCriterionScores(s.db, jobID, applications)
+	if err != nil {
+		return err
+	}
+	insights.Criteria = make([]models.CriterionValidity, len(criteria))
+	for i, group := range criteria {
+		validity := criterionValidity(applications, scores[group.key])
+		validity.CriterionID = group.id
+		validity.CriterionName = group.name
+		validity.Templates = templatesOrEmpty(criterionTemplates[insightKey(group.name)])
+		insights.Criteria[i] = validity
+	}
+	sort.SliceStable(insights.Criteria, func(i, j int) bool {
+		return criterionPower(insights.Criteria[i]) > criterionPower(insights.Criteria[j])
+	})
+	for i := range insights.Criteria {
+		insights.Criteria[i].Rank = i + 1
+	}
+
+	questions, asked, err := loadQuestionUsage(s.db, jobID)
+	if err != nil {
+		return err
+	}
+	insights.Questions = make([]models.QuestionValidity, len(questions))
+	for i, group := range questions {
+		validity := questionValidity(applications, group.jobs, asked[group.key])
+		validity.QuestionID = group.id
+		validity.CriterionName = group.criterionName
+		validity.Text = group.name
+		validity.Templates = templatesOrEmpty(questionTemplates[insightKey(group.name)])
+		insights.Questions[i] = validity
+	}
+	sort.SliceStable(insights.Questions, func(i, j int) bool {
+		return questionPower(insights.Questions[i]) > questionPower(insights.Questions[j])
+	})
+	for i := range insights.Questions {
+		insights.Questions[i].Rank = i + 1
+	}
+
+	return nil
+}
+
+// criterionValidity сравнивает оценки принятых и отказников по критерию. scores - средние
+// оценки откликов в процентах шкалы
+func criterionValidity(applications []insightApplication, scores map[int64]float64) models.CriterionValidity {
+	validity := models.CriterionValidity{Discrimination: DiscriminationInsufficientData}
+
+	var hired, rejected, values, outcomes, rated, performance []float64
+	for _, application := range applications {
+		score, ok := scores[application.id]
+		if !ok {
+			continue
+		}
+		values = append(values, score)
+		if application.hired {
+			hired = append(hired, score)
+			outcomes = append(outcomes, 1)
+			if application.performance != nil {
+				rated = append(rated, score)
+				performance = append(performance, float64(*application.performance))
+			}
+		} else {
+			rejected = append(rejected, score)
+			outcomes = append(outcomes, 0)
+		}
+	}
+	validity.Hired, validity.Rejected = len(hired), len(rejected)
+	validity.PerformanceRated = len(rated)
+
+	if len(hired) > 0 {
+		validity.HiredMean = optional(stats.Mean(hired), true)
+	}
+	if len(rejected) > 0 {
+		validity.RejectedMean = optional(stats.Mean(rejected), true)
+	}
+	if len(rated) >= minRatedHires {
+		validity.PerformanceCorrelation = optional(stats.Pearson(rated, performance))
+	}
+	if len(hired) < minOutcomeGroup || len(rejected) < minOutcomeGroup {
+		return validity
+	}
+
+	difference := *validity.HiredMean - *validity.RejectedMean
+	validity.Difference = &difference
+	validity.Correlation = optional(stats.Pearson(values, outcomes))
+	validity.AUC = optional(stats.AUC(hired, rejected))
+
+	power := math.Abs(2*(*validity.AUC) - 1)
+	switch {
+	case power >= strongCriterionPower:
+		validity.Discrimination = DiscriminationStrong
+	case power >= moderateCriterionPower:
+		validity.Discrimination = DiscriminationModerate
+	default:
+		validity.Discrimination = DiscriminationWeak
+	}
+	return validity
+}
+
+// questionValidity сравнивает, как часто вопрос задавали принятым и отказникам
+// вакансий jobs, где он есть. asked - отклики, ответившие на вопрос
+func questionValidity(applications []insightApplication, jobs map[int64]bool, asked map[int64]bool) models.QuestionValidity {
+	validity := models.QuestionValidity{Discrimination: DiscriminationInsufficientData}
+
+	var usage, outcomes, ratedUsage, performance []float64
+	for _, application := range applications {
+		if !jobs[application.jobID] {
+			continue
+		}
+		used := 0.0
+		if asked[application.id] {
+			used = 1
+		}
+		usage = append(usage, used)
+		if application.hired {
+			validity.Hired++
+			validity.HiredAsked += int(used)
+			outcomes = append(outcomes, 1)
+			if application.performance != nil {
+				ratedUsage = append(ratedUsage, used)
+				performance = append(performance, float64(*application.performance))
+			}
+		} else {
+			validity.Rejected++
+			validity.RejectedAsked += int(used)
+			outcomes = append(outcomes, 0)
+		}
+	}
+	validity.PerformanceRated = len(ratedUsage)
+
+	if validity.Hired > 0 {
+		validity.HiredUsage = optional(percent(validity.HiredAsked, validity.Hired), true)
+	}
+	if validity.Rejected > 0 {
+		validity.RejectedUsage = optional(percent(validity.RejectedAsked, validity.Rejected), true)
+	}
+	if len(ratedUsage) >= minRatedHires {
+		validity.PerformanceCorrelation = optional(stats.Pearson(ratedUsage, performance))
+	}
+	if validity.Hired < minOutcomeGroup || validity.Rejected < minOutcomeGroup {
+		return validity
+	}
+
+	// Вопрос, который задают всем или никому, не отличает принятых от отказников
+	validity.Correlation = optional(stats.Pearson(usage, outcomes))
+	power := 0.0
+	if validity.Correlation != nil {
+		power = math.Abs(*validity.Correlation)
+	}
+	switch {
+	case power >= strongQuestionPower:
+		validity.Discrimination = DiscriminationStrong
+	case power >= moderateQuestionPower:
+		validity.Discrimination = DiscriminationModerate
+	default:
+		validity.Discrimination = DiscriminationWeak
+	}
+	return validity
+}
+
+// criterionPower возвращает D Сомерса критерия для сортировки; без данных - -1
+func criterionPower(validity models.CriterionValidity) float64 {
+	if validity.AUC == nil {
+		return -1
+	}
+	return math.Abs(2*(*validity.AUC) - 1)
+}
+
+// questionPower возвращает |фи| вопроса для сортировки; без данных - -1
+func questionPower(validity models.QuestionValidity) float64 {
+	switch {
+	case validity.Discrimination == DiscriminationInsufficientData:
+		return -1
+	case validity.Correlation == nil:
+		return 0
+	}
+	return math.Abs(*validity.Correlation)
+}
+
+// optional возвращает указатель на значение или nil, если ok = false
+func optional(value float64, ok bool) *float64 {
+	if !ok {
+		return nil
+	}
+	return &value
+}
+
+// insightKey приводит название критерия или текст вопроса к виду для сравнения
+func insightKey(text string) string {
+	return strings.ToLower(strings.Join(strings.Fields(text), " "))
+}
+
+// templatesOrEmpty возвращает список шаблонов, пустой вместо nil
+func templatesOrEmpty(templates []string) []string {
+	if templates == nil {
+		return []string{}
+	}
+	return templates
+}
+
+// groupKey возвращает ключ критерия или вопроса: в отчете по вакансии - ID, по всем вакансиям - текст
+func groupKey(jobID, id int64, text string) string {
+	if jobID != 0 {
+		return strconv.FormatInt(id, 10)
+	}
+	return insightKey(text)
+}
+
+// loadInsightApplications загружает отклики с итогом найма вакансии jobID (0 - всех вакансий)
+func loadInsightApplications(q querier, jobID int64) ([]insightApplication, error) {
+	rows, err := q.Query(`
+		SELECT a.id, a.job_id, a.stage, a.performance_rating
+		FROM applications a
+		JOIN jobs j ON j.id = a.job_id
+		WHERE a.stage IN (?, ?) AND (? = 0 OR a.job_id = ?)
+		ORDER BY a.id
+	`, StageHired, StageRejected, jobID, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get applications: %w", err)
+	}
+	defer rows.Close()
+
+	var applications []insightApplication
+	for rows.Next() {
+		var application insightApplication
+		var stage string
+		var rating sql.NullInt64
+		if err := rows.Scan(&application.id, &application.jobID, &stage, &rating); err != nil {
+			return nil, fmt.Errorf("failed to scan application: %w", err)
+		}
+		application.hired = stage == StageHired
+		application.performance = performanceRating(rating)
+		applications = append(applications, application)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read applications: %w", err)
+	}
+
+	return applications, nil
+}
+
+// loadCriterionScores загружает критерии и средние оценки откликов с итогом по ним
+// в процентах шкалы: scores[ключ критерия][ID отклика]
+func loadCriterionScores(q querier, jobID int64, applications []insightApplication) ([]*insightGroup, map[string]map[int64]float64, error) {
+	rows, err := q.Query(`
+		SELECT c.id, c.job_id, c.name
+		FROM criteria c
+		JOIN jobs j ON j.id = c.job_id
+		WHERE ? = 0 OR c.job_id = ?
+		ORDER BY c.job_id, c.display_order, c.id
+	`, jobID, jobID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get criteria: %w", err)
+	}
+	defer rows.Close()
+
+	var groups []*insightGroup
+	byKey := make(map[string]*insightGroup)
+	criterionKeys := make(map[int64]string)
+	for rows.Next() {
+		var id, criterionJobID int64
+		var name string
+		if err := rows.Scan(&id, &criterionJobID, &name); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan criterion: %w", err)
+		}
+		key := groupKey(jobID, id, name)
+		group, ok := byKey[key]
+		if !ok {
+			group = &insightGroup{key: key, name: name, jobs: make(map[int64]bool)}
+			if jobID != 0 {
+				group.id = id
+			}
+			byKey[key] = group
+			groups = append(groups, group)
+		}
+		group.jobs[criterionJobID] = true
+		criterionKeys[id] = key
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to read criteria: %w", err)
+	}
+
+	outcomes := make(map[int64]bool, len(applications))
+	for _, application := range applications {
+		outcomes[application.id] = true
+	}
+
+	evaluationRows, err := q.Query(`
+		SELECT e.application_id, e.criterion_id, a.job_id, e.score
+		FROM evaluations e
+		JOIN applications a ON a.id = e.application_id
+		WHERE a.stage IN (?, ?) AND (? = 0 OR a.job_id = ?)
+	`, StageHired, StageRejected, jobID, jobID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get evaluations: %w", err)
+	}
+	defer evaluationRows.Close()
+
+	// Сначала все оценки отклика по ключу критерия, затем их среднее
+	collected := make(map[string]map[int64][]float64)
+	scales := make(map[int64]models.ScoringScale)
+	for evaluationRows.Next() {
+		var applicationID, criterionID, applicationJobID int64
+		var score int
+		if err := evaluationRows.Scan(&applicationID, &criterionID, &applicationJobID, &score); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan evaluation: %w", err)
+		}
+		key, ok := criterionKeys[criterionID]
+		if !ok || !outcomes[applicationID] {
+			continue
+		}
+		scale, ok := scales[applicationJobID]
+		if !ok {
+			if scale, err = loadJobScale(q, applicationJobID); err != nil {
+				return nil, nil, err
+			}
+			scales[applicationJobID] = scale
+		}
+		if collected[key] == nil {
+			collected[key] = make(map[int64][]float64)
+		}
+		collected[key][applicationID] = append(collected[key][applicationID], normalizeScore(scale, float64(score)))
+	}
+	if err := evaluationRows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to read evaluations: %w", err)
+	}
+
+	scores := make(map[string]map[int64]float64, len(collected))
+	for key, byApplication := range collected {
+		scores[key] = make(map[int64]float64, len(byApplication))
+		for applicationID, values := range byApplication {
+			scores[key][applicationID] = stats.Mean(values)
+		}
+	}
+
+	return groups, scores, nil
+}
+
+// loadQuestionUsage загружает вопросы и отклики, ответившие на них: asked[ключ вопроса][ID отклика]
+func loadQuestionUsage(q querier, jobID int64) ([]*insightGroup, map[string]map[int64]bool, error) {
+	rows, err := q.Query(`
+		SELECT q.id, q.job_id, q.text, COALESCE(c.name, '')
+		FROM questions q
+		JOIN jobs j ON j.id = q.job_id
+		LEFT JOIN criteria c ON c.id = q.criterion_id
+		WHERE ? = 0 OR q.job_id = ?
+		ORDER BY q.job_id, c.display_order, q.id
+	`, jobID, jobID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get questions: %w", err)
+	}
+	defer rows.Close()
+
+	var groups []*insightGroup
+	byKey := make(map[string]*insightGroup)
+	questionKeys := make(map[int64]string)
+	for rows.Next() {
+		var id, questionJobID int64
+		var text, criterionName string
+		if err := rows.Scan(&id, &questionJobID, &text, &criterionName); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan question: %w", err)
+		}
+		key := groupKey(jobID, id, text)
+		group, ok := byKey[key]
+		if !ok {
+			group = &insightGroup{key: key, name: text, criterionName: criterionName, jobs: make(map[int64]bool)}
+			if jobID != 0 {
+				group.id = id
+			}
+			byKey[key] = group
+			groups = append(groups, group)
+		}
+		group.jobs[questionJobID] = true
+		questionKeys[id] = key
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to read questions: %w", err)
+	}
+
+	answerRows, err := q.Query(`
+		SELECT application_id, question_id FROM answers
+		WHERE application_id IS NOT NULL AND TRIM(COALESCE(answer_text, '')) != ''
+	`)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get answers: %w", err)
+	}
+	defer answerRows.Close()
+
+	asked := make(map[string]map[int64]bool)
+	for answerRows.Next() {
+		var applicationID, questionID int64
+		if err := answerRows.Scan(&applicationID, &questionID); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan answer: %w", err)
+		}
+		key, ok := questionKeys[questionID]
+		if !ok {
+			continue
+		}
+		if asked[key] == nil {
+			asked[key] = make(map[int64]bool)
+		}
+		asked[key][applicationID] = true
+	}
+	if err := answerRows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to read answers: %w", err)
+	}
+
+	return groups, asked, nil
+}
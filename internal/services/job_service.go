@@ -8,21 +8,41 @@ import (
 )
 
 type JobService struct {
-	db *database.DB
+	db    *database.DB
+	audit *AuditService
 }
 
-func NewJobService(db *database.DB) *JobService {
-	return &JobService{db: db}
+func NewJobService(db *database.DB, audit *AuditService) *JobService {
+	return &JobService{db: db, audit: audit}
 }
 
 // CreateJob создает новую вакансию
-func (s *JobService) CreateJob(job *models.Job) (*models.Job, error) {
+func (s *JobService) CreateJob(job *models.Job, actor string) (*models.Job, error) {
+	scale, err := scaleFromRequest(job.ScoringScale)
+	if err != nil {
+		return nil, err
+	}
+	levels, err := scaleLevelsColumn(scale)
+	if err != nil {
+		return nil, err
+	}
+	knockoutAction, err := knockoutActionFromRequest(job.KnockoutAction, DefaultKnockoutAction)
+	if err != nil {
+		return nil, err
+	}
+
 	query := `
-		INSERT INTO jobs (title, description, requirements, criteria) 
-		VALUES (?, ?, ?, ?)
+		INSERT INTO jobs (title, description, requirements, criteria, scale_type, scale_levels, knockout_action) 
+		VALUES (?, ?, ?, ?, ?, ?, ?)
 	`
-	
-	result, err := s.db.Exec(query, job.Title, job.Description, job.Requirements, job.Criteria)
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(query, job.Title, job.Description, job.Requirements, job.Criteria, scale.Type, levels, knockoutAction)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create job: %w", err)
 	}
@@ -33,23 +53,125 @@ func (s *JobService) CreateJob(job *models.Job) (*models.Job, error) {
 	}
 
 	job.ID = id
-	return s.GetJobByID(id)
+	created, err := loadJob(tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.audit.Record(tx, AuditEntityJob, id, nil, AuditActionCreate, actor, nil, created); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return created, nil
+}
+
+// CreateJobFromTemplate создает вакансию по шаблону вместе с критериями, их рубриками
+// и вопросами для интервью
+func (s *JobService) CreateJobFromTemplate(template *JobTemplate, actor string) (*models.Job, error) {
+	scale, err := scaleFromRequest(template.ScoringScale)
+	if err != nil {
+		return nil, err
+	}
+	levels, err := scaleLevelsColumn(scale)
+	if err != nil {
+		return nil, err
+	}
+
+	rubrics := make(map[string][]models.RubricLevel, len(template.Rubrics))
+	for _, rubric := range template.Rubrics {
+		rubrics[rubric.Criterion], err = normalizeRubric(scale, rubric.Levels)
+		if err != nil {
+			return nil, fmt.Errorf("template rubric for '%s': %w", rubric.Criterion, err)
+		}
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var jobID int64
+	err = tx.QueryRow(`
+		INSERT INTO jobs (title, description, requirements, criteria, scale_type, scale_levels)
+		VALUES (?, ?, ?, '[]', ?, ?)
+		RETURNING id
+	`, template.Title, template.Description, template.Requirements, scale.Type, levels).Scan(&jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create job: %w", err)
+	}
+
+	criterionIDs := make(map[string]int64, len(template.Criteria))
+	for i, name := range template.Criteria {
+		rubric, err := rubricColumn(rubrics[name])
+		if err != nil {
+			return nil, err
+		}
+		var criterionID int64
+		err = tx.QueryRow(
+			"INSERT INTO criteria (job_id, name, display_order, weight, rubric) VALUES (?, ?, ?, ?, ?) RETURNING id",
+			jobID, name, i, DefaultCriterionWeight, rubric,
+		).Scan(&criterionID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create criterion: %w", err)
+		}
+		criterionIDs[name] = criterionID
+	}
+
+	for _, group := range template.Questions {
+		criterionID, ok := criterionIDs[group.Criterion]
+		if !ok {
+			continue
+		}
+		for _, text := range group.Questions {
+			_, err := tx.Exec("INSERT INTO questions (job_id, criterion_id, text) VALUES (?, ?, ?)", jobID, criterionID, text)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create question: %w", err)
+			}
+		}
+	}
+
+	created, err := loadJob(tx, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.audit.Record(tx, AuditEntityJob, jobID, nil, AuditActionCreate, actor, nil, created); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return created, nil
 }
 
 // GetJobByID получает вакансию по ID
 func (s *JobService) GetJobByID(id int64) (*models.Job, error) {
+	return loadJob(s.db, id)
+}
+
+// loadJob получает вакансию по ID через db или транзакцию
+func loadJob(q querier, id int64) (*models.Job, error) {
 	query := `
-		SELECT id, title, description, requirements, criteria, created_at, updated_at 
+		SELECT id, title, description, requirements, criteria, created_at, updated_at, scale_type, scale_levels, knockout_action 
 		FROM jobs 
 		WHERE id = ?
 	`
-	
+
 	var job models.Job
-	err := s.db.QueryRow(query, id).Scan(
-		&job.ID, &job.Title, &job.Description, &job.Requirements, 
-		&job.Criteria, &job.CreatedAt, &job.UpdatedAt,
+	var scaleType string
+	var scaleLevels sql.NullString
+	err := q.QueryRow(query, id).Scan(
+		&job.ID, &job.Title, &job.Description, &job.Requirements,
+		&job.Criteria, &job.CreatedAt, &job.UpdatedAt, &scaleType, &scaleLevels, &job.KnockoutAction,
 	)
-	
+
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("job not found")
@@ -57,17 +179,23 @@ func (s *JobService) GetJobByID(id int64) (*models.Job, error) {
 		return nil, fmt.Errorf("failed to get job: %w", err)
 	}
 
+	scale, err := scaleFromColumns(scaleType, scaleLevels)
+	if err != nil {
+		return nil, err
+	}
+	job.ScoringScale = &scale
+
 	return &job, nil
 }
 
 // GetAllJobs получает все вакансии
 func (s *JobService) GetAllJobs() ([]models.Job, error) {
 	query := `
-		SELECT id, title, description, requirements, criteria, created_at, updated_at 
+		SELECT id, title, description, requirements, criteria, created_at, updated_at, scale_type, scale_levels, knockout_action 
 		FROM jobs 
 		ORDER BY created_at DESC
 	`
-	
+
 	rows, err := s.db.Query(query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get jobs: %w", err)
@@ -77,13 +205,20 @@ func (s *JobService) GetAllJobs() ([]models.Job, error) {
 	var jobs []models.Job
 	for rows.Next() {
 		var job models.Job
+		var scaleType string
+		var scaleLevels sql.NullString
 		err := rows.Scan(
 			&job.ID, &job.Title, &job.Description, &job.Requirements,
-			&job.Criteria, &job.CreatedAt, &job.UpdatedAt,
+			&job.Criteria, &job.CreatedAt, &job.UpdatedAt, &scaleType, &scaleLevels, &job.KnockoutAction,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan job: %w", err)
 		}
+		scale, err := scaleFromColumns(scaleType, scaleLevels)
+		if err != nil {
+			return nil, err
+		}
+		job.ScoringScale = &scale
 		jobs = append(jobs, job)
 	}
 
@@ -91,26 +226,143 @@ func (s *JobService) GetAllJobs() ([]models.Job, error) {
 }
 
 // UpdateJob обновляет вакансию
-func (s *JobService) UpdateJob(id int64, job *models.Job) (*models.Job, error) {
+func (s *JobService) UpdateJob(id int64, job *models.Job, actor string) (*models.Job, error) {
+	before, err := s.GetJobByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Без шкалы в запросе шкала вакансии не меняется
+	scale := *before.ScoringScale
+	if job.ScoringScale != nil {
+		scale, err = scaleFromRequest(job.ScoringScale)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.checkJobFitsScale(id, scale); err != nil {
+			return nil, err
+		}
+	}
+	levels, err := scaleLevelsColumn(scale)
+	if err != nil {
+		return nil, err
+	}
+	knockoutAction, err := knockoutActionFromRequest(job.KnockoutAction, before.KnockoutAction)
+	if err != nil {
+		return nil, err
+	}
+
 	query := `
 		UPDATE jobs 
-		SET title = ?, description = ?, requirements = ?, criteria = ?
+		SET title = ?, description = ?, requirements = ?, criteria = ?, scale_type = ?, scale_levels = ?, knockout_action = ?
 		WHERE id = ?
 	`
-	
-	_, err := s.db.Exec(query, job.Title, job.Description, job.Requirements, job.Criteria, id)
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(query, job.Title, job.Description, job.Requirements, job.Criteria, scale.Type, levels, knockoutAction, id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update job: %w", err)
 	}
 
-	return s.GetJobByID(id)
+	updated, err := loadJob(tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.audit.Record(tx, AuditEntityJob, id, nil, AuditActionUpdate, actor, before, updated); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return updated, nil
+}
+
+// checkJobFitsScale проверяет, что оценки по вакансии, рубрики и пороги ее критериев допустимы в новой шкале
+func (s *JobService) checkJobFitsScale(jobID int64, scale models.ScoringScale) error {
+	rows, err := s.db.Query(`
+		SELECT e.score, COUNT(*)
+		FROM evaluations e
+		JOIN applications a ON a.id = e.application_id
+		WHERE a.job_id = ?
+		GROUP BY e.score
+	`, jobID)
+	if err != nil {
+		return fmt.Errorf("failed to check evaluations: %w", err)
+	}
+	defer rows.Close()
+
+	misfits := 0
+	for rows.Next() {
+		var score, count int
+		if err := rows.Scan(&score, &count); err != nil {
+			return fmt.Errorf("failed to scan evaluation score: %w", err)
+		}
+		if validateScore(scale, score) != nil {
+			misfits += count
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read evaluation scores: %w", err)
+	}
+
+	if misfits > 0 {
+		return fmt.Errorf("%w: %d evaluations do not fit the %s scale", ErrInvalidScale, misfits, scale.Type)
+	}
+
+	criteria, err := loadJobCriteria(s.db, jobID)
+	if err != nil {
+		return err
+	}
+	for _, criterion := range criteria {
+		if _, err := normalizeRubric(scale, criterion.Rubric); err != nil {
+			return fmt.Errorf("%w: rubric of criterion '%s' does not fit the %s scale", ErrInvalidScale, criterion.Name, scale.Type)
+		}
+		if err := validateKnockout(scale, &criterion); err != nil {
+			return fmt.Errorf("%w: min_score of criterion '%s' does not fit the %s scale", ErrInvalidScale, criterion.Name, scale.Type)
+		}
+	}
+	return nil
+}
+
+// scaleFromRequest собирает шкалу из запроса; без шкалы - шкала по умолчанию
+func scaleFromRequest(scale *models.ScoringScale) (models.ScoringScale, error) {
+	if scale == nil {
+		return NewScoringScale(DefaultScaleType, nil)
+	}
+	return NewScoringScale(scale.Type, scale.Levels)
 }
 
-// DeleteJob удаляет вакансию
-func (s *JobService) DeleteJob(id int64) error {
+// DeleteJob удаляет вакансию вместе с откликами на нее, критериями, вопросами и этапами
+func (s *JobService) DeleteJob(id int64, actor string) error {
+	before, err := s.GetJobByID(id)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := deleteApplications(tx, "job_id = ?", id); err != nil {
+		return err
+	}
+	if err := deleteJobData(tx, id); err != nil {
+		return err
+	}
+
 	query := `DELETE FROM jobs WHERE id = ?`
-	
-	result, err := s.db.Exec(query, id)
+
+	result, err := tx.Exec(query, id)
 	if err != nil {
 		return fmt.Errorf("failed to delete job: %w", err)
 	}
@@ -124,13 +376,35 @@ func (s *JobService) DeleteJob(id int64) error {
 		return fmt.Errorf("job not found")
 	}
 
+	if err := s.audit.Record(tx, AuditEntityJob, id, nil, AuditActionDelete, actor, before, nil); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// deleteJobData удаляет критерии, вопросы, попарные сравнения критериев и этапы вакансии.
+// Внешние ключи SQLite не включены, поэтому ON DELETE CASCADE не срабатывает.
+// Кандидаты остаются: это данные человека, у которого могут быть отклики на другие вакансии
+func deleteJobData(tx *sql.Tx, jobID int64) error {
+	queries := []string{
+		"DELETE FROM criterion_comparisons WHERE job_id = ?",
+		"DELETE FROM questions WHERE job_id = ?",
+		"DELETE FROM criteria WHERE job_id = ?",
+		"DELETE FROM job_stages WHERE job_id = ?",
+	}
+	for _, query := range queries {
+		if _, err := tx.Exec(query, jobID); err != nil {
+			return fmt.Errorf("failed to delete job data: %w", err)
+		}
+	}
 	return nil
 }
 
 // GetJobCandidatesCount получает количество кандидатов для вакансии
 func (s *JobService) GetJobCandidatesCount(jobID int64) (int, error) {
-	query := `SELECT COUNT(*) FROM candidates WHERE job_id = ?`
-	
+	query := `SELECT COUNT(*) FROM applications WHERE job_id = ?`
+
 	var count int
 	err := s.db.QueryRow(query, jobID).Scan(&count)
 	if err != nil {
@@ -138,4 +412,4 @@ func (s *JobService) GetJobCandidatesCount(jobID int64) (int, error) {
 	}
 
 	return count, nil
-} 
\ No newline at end of file
+}
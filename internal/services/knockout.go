@@ -0,0 +1,142 @@
+package services
+
+import (
+	"choizee/internal/models"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Действия вакансии с кандидатом, не прошедшим отсеивающий критерий
+const (
+	KnockoutFlag   = "flag"   // Только отметить в сводке оценок
+	KnockoutReject = "reject" // Отметить и перевести отклик в отказ
+
+	DefaultKnockoutAction = KnockoutFlag
+)
+
+// ErrInvalidKnockout возвращается при некорректном пороге отсеивающего критерия или действии вакансии
+var ErrInvalidKnockout = errors.New("invalid knockout")
+
+// knockoutActionFromRequest проверяет действие вакансии; пустое значение заменяется на fallback
+func knockoutActionFromRequest(action, fallback string) (string, error) {
+	switch action {
+	case "":
+		return fallback, nil
+	case KnockoutFlag, KnockoutReject:
+		return action, nil
+	}
+	return "", fmt.Errorf("%w: unknown knockout action %q", ErrInvalidKnockout, action)
+}
+
+// applyKnockoutUpdate применяет к критерию изменения признака must_have и порога.
+// Порог без признака делает критерий отсеивающим; порог проверяется по шкале вакансии
+func applyKnockoutUpdate(scale models.ScoringScale, criterion *models.Criterion, mustHave *bool, minScore *int) error {
+	if minScore != nil {
+		criterion.MinScore = *minScore
+		criterion.MustHave = true
+	}
+	if mustHave != nil {
+		criterion.MustHave = *mustHave
+	}
+	return validateKnockout(scale, criterion)
+}
+
+// validateKnockout проверяет порог отсеивающего критерия по шкале; у обычного критерия порог сбрасывается
+func validateKnockout(scale models.ScoringScale, criterion *models.Criterion) error {
+	if !criterion.MustHave {
+		criterion.MinScore = 0
+		return nil
+	}
+	if err := validateScore(scale, criterion.MinScore); err != nil {
+		return fmt.Errorf("%w: min_score: %v", ErrInvalidKnockout, err)
+	}
+	return nil
+}
+
+// minScoreColumn возвращает значение поля min_score: порог или NULL для обычного критерия
+func minScoreColumn(criterion models.Criterion) any {
+	if !criterion.MustHave {
+		return nil
+	}
+	return criterion.MinScore
+}
+
+// knockoutFailures находит отсеивающие критерии, средняя оценка по которым ниже порога.
+// Критерий без оценок не считается проваленным
+func knockoutFailures(criteria []models.Criterion, summaries []models.CriterionScoreSummary) []models.KnockoutFailure {
+	means := make(map[int64]float64, len(summaries))
+	for _, summary := range summaries {
+		means[summary.CriterionID] = summary.Mean
+	}
+
+	failures := []models.KnockoutFailure{}
+	for _, criterion := range criteria {
+		mean, ok := means[criterion.ID]
+		if !criterion.MustHave || !ok || mean >= float64(criterion.MinScore) {
+			continue
+		}
+		failures = append(failures, models.KnockoutFailure{
+			CriterionID:   criterion.ID,
+			CriterionName: criterion.Name,
+			MinScore:      criterion.MinScore,
+			Score:         mean,
+		})
+	}
+	return failures
+}
+
+// rejectKnockedOut переводит отклик в отказ, если вакансия отказывает автоматически, а кандидат
+// не прошел отсеивающий критерий. Нанятые и уже отклоненные кандидаты не затрагиваются
+func rejectKnockedOut(tx *sql.Tx, audit *AuditService, applicationID int64, actor string) error {
+	application, err := loadApplication(tx, applicationID)
+	if err != nil {
+		return err
+	}
+	if isOutcomeStage(application.Stage) {
+		return nil
+	}
+
+	var action string
+	if err := tx.QueryRow("SELECT knockout_action FROM jobs WHERE id = ?", application.JobID).Scan(&action); err != nil {
+		return fmt.Errorf("failed to get knockout action: %w", err)
+	}
+	if action != KnockoutReject {
+		return nil
+	}
+
+	scale, err := loadJobScale(tx, application.JobID)
+	if err != nil {
+		return err
+	}
+	criteria, err := loadJobCriteria(tx, application.JobID)
+	if err != nil {
+		return err
+	}
+	evaluations, err := loadApplicationEvaluations(tx, applicationID, "")
+	if err != nil {
+		return err
+	}
+
+	failures := knockoutFailures(criteria, summarizeCriteria(evaluations, scale))
+	if len(failures) == 0 {
+		return nil
+	}
+
+	stages, err := loadJobStages(tx, application.JobID)
+	if err != nil {
+		return err
+	}
+	if err := checkStageTransition(stages, application.Stage, StageRejected); err != nil {
+		return err
+	}
+
+	names := make([]string, len(failures))
+	for i, failure := range failures {
+		names[i] = fmt.Sprintf("%s (%.1f < %d)", failure.CriterionName, failure.Score, failure.MinScore)
+	}
+	reason := "Не пройдены отсеивающие критерии: " + strings.Join(names, ", ")
+
+	return changeApplicationStage(tx, audit, application, StageRejected, -1, reason, actor)
+}
@@ -0,0 +1,550 @@
+package services
+
+import (
+	"choizee/internal/database"
+	"choizee/internal/models"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+)
+
+// Системные этапы найма, которые есть в воронке каждой вакансии
+const (
+	StageNew      = "new"
+	StageHired    = "hired"
+	StageRejected = "rejected"
+)
+
+// systemStages нельзя удалить; new всегда первый, hired и rejected - итоговые
+var systemStages = []string{StageNew, StageHired, StageRejected}
+
+var stageKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,31}$`)
+
+var (
+	// ErrInvalidStageTransition возвращается при попытке недопустимого перехода между этапами
+	ErrInvalidStageTransition = errors.New("invalid stage transition")
+	// ErrInvalidPipeline возвращается при некорректном изменении воронки вакансии
+	ErrInvalidPipeline = errors.New("invalid pipeline")
+)
+
+// querier позволяет читать данные как через подключение, так и внутри транзакции
+type querier interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+type PipelineService struct {
+	db    *database.DB
+	audit *AuditService
+}
+
+func NewPipelineService(db *database.DB, audit *AuditService) *PipelineService {
+	return &PipelineService{db: db, audit: audit}
+}
+
+// GetJobStages возвращает этапы воронки вакансии в порядке отображения
+func (s *PipelineService) GetJobStages(jobID int64) ([]models.JobStage, error) {
+	return loadJobStages(s.db, jobID)
+}
+
+// GetStageByID получает этап по ID
+func (s *PipelineService) GetStageByID(id int64) (*models.JobStage, error) {
+	return loadStage(s.db, id)
+}
+
+// loadStage получает этап по ID через db или транзакцию
+func loadStage(q querier, id int64) (*models.JobStage, error) {
+	query := `
+		SELECT id, job_id, key, name, display_order, created_at, updated_at
+		FROM job_stages
+		WHERE id = ?
+	`
+
+	var stage models.JobStage
+	err := q.QueryRow(query, id).Scan(
+		&stage.ID, &stage.JobID, &stage.Key, &stage.Name, &stage.DisplayOrder,
+		&stage.CreatedAt, &stage.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("stage not found")
+		}
+		return nil, fmt.Errorf("failed to get stage: %w", err)
+	}
+	stage.System = slices.Contains(systemStages, stage.Key)
+
+	return &stage, nil
+}
+
+// CreateStage добавляет этап в воронку вакансии перед итоговыми этапами
+func (s *PipelineService) CreateStage(jobID int64, stage models.JobStage, actor string) (*models.JobStage, error) {
+	if !stageKeyPattern.MatchString(stage.Key) {
+		return nil, fmt.Errorf("%w: stage key must match %s", ErrInvalidPipeline, stageKeyPattern)
+	}
+	if stage.Name == "" {
+		return nil, fmt.Errorf("%w: stage name is required", ErrInvalidPipeline)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stages, err := loadJobStages(tx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if len(stages) == 0 {
+		return nil, fmt.Errorf("job not found")
+	}
+	for _, existing := range stages {
+		if existing.Key == stage.Key {
+			return nil, fmt.Errorf("%w: stage %q already exists", ErrInvalidPipeline, stage.Key)
+		}
+	}
+
+	err = tx.QueryRow(
+		"INSERT INTO job_stages (job_id, key, name) VALUES (?, ?, ?) RETURNING id",
+		jobID, stage.Key, stage.Name,
+	).Scan(&stage.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stage: %w", err)
+	}
+
+	// Новый этап встает перед первым итоговым
+	var ids []int64
+	inserted := false
+	for _, existing := range stages {
+		if !inserted && isOutcomeStage(existing.Key) {
+			ids = append(ids, stage.ID)
+			inserted = true
+		}
+		ids = append(ids, existing.ID)
+	}
+	if !inserted {
+		ids = append(ids, stage.ID)
+	}
+	if err := updateStagesOrder(tx, jobID, ids); err != nil {
+		return nil, err
+	}
+
+	created, err := loadStage(tx, stage.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.audit.Record(tx, AuditEntityJobStage, created.ID, nil, AuditActionCreate, actor, nil, created); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return created, nil
+}
+
+// UpdateStage переименовывает этап
+func (s *PipelineService) UpdateStage(id int64, update models.JobStageUpdate, actor string) (*models.JobStage, error) {
+	before, err := s.GetStageByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if update.Name == "" {
+		return nil, fmt.Errorf("%w: stage name is required", ErrInvalidPipeline)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("UPDATE job_stages SET name = ? WHERE id = ?", update.Name, id); err != nil {
+		return nil, fmt.Errorf("failed to update stage: %w", err)
+	}
+
+	updated, err := loadStage(tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.audit.Record(tx, AuditEntityJobStage, id, nil, AuditActionUpdate, actor, before, updated); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return updated, nil
+}
+
+// DeleteStage удаляет этап, на котором нет кандидатов. Системные этапы удалить нельзя
+func (s *PipelineService) DeleteStage(id int64, actor string) error {
+	before, err := s.GetStageByID(id)
+	if err != nil {
+		return err
+	}
+	if before.System {
+		return fmt.Errorf("%w: system stage %q cannot be deleted", ErrInvalidPipeline, before.Key)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var candidateCount int
+	err = tx.QueryRow("SELECT COUNT(*) FROM applications WHERE job_id = ? AND stage = ?", before.JobID, before.Key).Scan(&candidateCount)
+	if err != nil {
+		return fmt.Errorf("failed to check candidates count: %w", err)
+	}
+	if candidateCount > 0 {
+		return fmt.Errorf("%w: cannot delete stage: %d candidates are on it", ErrInvalidPipeline, candidateCount)
+	}
+
+	if _, err := tx.Exec("DELETE FROM job_stages WHERE id = ?", id); err != nil {
+		return fmt.Errorf("failed to delete stage: %w", err)
+	}
+
+	if err := s.audit.Record(tx, AuditEntityJobStage, id, nil, AuditActionDelete, actor, before, nil); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ReorderStages обновляет порядок этапов воронки. Список должен содержать все этапы вакансии,
+// new остается первым, а hired и rejected идут после всех остальных этапов
+func (s *PipelineService) ReorderStages(jobID int64, stageIDs []int64, actor string) error {
+	before, err := s.GetJobStages(jobID)
+	if err != nil {
+		return err
+	}
+
+	byID := make(map[int64]models.JobStage, len(before))
+	for _, stage := range before {
+		byID[stage.ID] = stage
+	}
+	if len(stageIDs) != len(before) {
+		return fmt.Errorf("%w: all %d stages of the job must be listed", ErrInvalidPipeline, len(before))
+	}
+
+	seen := make(map[int64]bool, len(stageIDs))
+	outcome := false
+	for i, id := range stageIDs {
+		stage, ok := byID[id]
+		if !ok || seen[id] {
+			return fmt.Errorf("%w: unknown or duplicate stage %d", ErrInvalidPipeline, id)
+		}
+		seen[id] = true
+
+		switch {
+		case stage.Key == StageNew && i != 0:
+			return fmt.Errorf("%w: stage %q must be first", ErrInvalidPipeline, StageNew)
+		case isOutcomeStage(stage.Key):
+			outcome = true
+		case outcome:
+			return fmt.Errorf("%w: stage %q must precede %q and %q", ErrInvalidPipeline, stage.Key, StageHired, StageRejected)
+		}
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := updateStagesOrder(tx, jobID, stageIDs); err != nil {
+		return err
+	}
+
+	after, err := loadJobStages(tx, jobID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.audit.Record(tx, AuditEntityJobStages, jobID, nil, AuditActionUpdate, actor, before, after); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetBoard возвращает канбан-доску вакансии: отклики кандидатов, сгруппированные по этапам
+func (s *PipelineService) GetBoard(jobID int64) (*models.Board, error) {
+	stages, err := s.GetJobStages(jobID)
+	if err != nil {
+		return nil, err
+	}
+	if len(stages) == 0 {
+		return nil, fmt.Errorf("job not found")
+	}
+
+	board := &models.Board{JobID: jobID, Columns: make([]models.BoardColumn, len(stages))}
+	columns := make(map[string]*models.BoardColumn, len(stages))
+	for i, stage := range stages {
+		board.Columns[i] = models.BoardColumn{Stage: stage, Candidates: []models.CandidateWithJob{}}
+		columns[stage.Key] = &board.Columns[i]
+	}
+
+	query := `
+		SELECT c.id, a.job_id, c.name, c.email, c.phone, c.description, c.created_at, c.updated_at,
+		       j.title, a.id, a.stage, a.stage_position
+		FROM applications a
+		JOIN candidates c ON c.id = a.candidate_id
+		JOIN jobs j ON j.id = a.job_id
+		WHERE a.job_id = ?
+		ORDER BY a.stage_position ASC, a.created_at ASC, a.id ASC
+	`
+
+	rows, err := s.db.Query(query, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get candidates: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var c models.CandidateWithJob
+		err := rows.Scan(
+			&c.ID, &c.JobID, &c.Name, &c.Email, &c.Phone, &c.Description, &c.CreatedAt, &c.UpdatedAt,
+			&c.JobTitle, &c.ApplicationID, &c.Stage, &c.StagePosition,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan candidate: %w", err)
+		}
+
+		column, ok := columns[c.Stage]
+		if !ok {
+			continue
+		}
+		column.Candidates = append(column.Candidates, c)
+		column.Count++
+		board.Total++
+	}
+
+	return board, nil
+}
+
+// MoveApplication перемещает карточку отклика на доске: на другой этап по правилам
+// переходов или на другую позицию в той же колонке
+func (s *PipelineService) MoveApplication(applicationID int64, request *models.CandidateMoveRequest, actor string) (*models.Application, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	before, err := loadApplication(tx, applicationID)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.Stage == "" {
+		request.Stage = before.Stage
+	}
+	if request.Stage != before.Stage {
+		stages, err := loadJobStages(tx, before.JobID)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkStageTransition(stages, before.Stage, request.Stage); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := changeApplicationStage(tx, s.audit, before, request.Stage, request.Position, request.Reason, actor); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return loadApplication(s.db, applicationID)
+}
+
+// isOutcomeStage сообщает, что этап завершает воронку
+func isOutcomeStage(key string) bool {
+	return key == StageHired || key == StageRejected
+}
+
+// loadJobStages читает этапы вакансии в порядке отображения
+func loadJobStages(q querier, jobID int64) ([]models.JobStage, error) {
+	query := `
+		SELECT id, job_id, key, name, display_order, created_at, updated_at
+		FROM job_stages
+		WHERE job_id = ?
+		ORDER BY display_order ASC, id ASC
+	`
+
+	rows, err := q.Query(query, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stages: %w", err)
+	}
+	defer rows.Close()
+
+	stages := []models.JobStage{}
+	for rows.Next() {
+		var stage models.JobStage
+		err := rows.Scan(
+			&stage.ID, &stage.JobID, &stage.Key, &stage.Name, &stage.DisplayOrder,
+			&stage.CreatedAt, &stage.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan stage: %w", err)
+		}
+		stage.System = slices.Contains(systemStages, stage.Key)
+		stages = append(stages, stage)
+	}
+
+	return stages, nil
+}
+
+// updateStagesOrder записывает порядок этапов по позициям в списке
+func updateStagesOrder(tx *sql.Tx, jobID int64, stageIDs []int64) error {
+	for i, id := range stageIDs {
+		_, err := tx.Exec("UPDATE job_stages SET display_order = ? WHERE id = ? AND job_id = ? AND display_order != ?", i, id, jobID, i)
+		if err != nil {
+			return fmt.Errorf("failed to update stage order: %w", err)
+		}
+	}
+	return nil
+}
+
+// checkStageTransition проверяет переход по воронке вакансии. Разрешены переходы
+// вперед (в том числе сразу через несколько этапов), на шаг назад, отказ с любого
+// этапа кроме hired и возврат отказника на этап new
+func checkStageTransition(stages []models.JobStage, from, to string) error {
+	// Рабочие этапы по порядку, за ними hired
+	var pipeline []string
+	for _, stage := range stages {
+		if !isOutcomeStage(stage.Key) {
+			pipeline = append(pipeline, stage.Key)
+		}
+	}
+	pipeline = append(pipeline, StageHired)
+
+	known := to == StageRejected || slices.Contains(pipeline, to)
+	switch {
+	case !known:
+		return fmt.Errorf("%w: unknown stage %q", ErrInvalidStageTransition, to)
+	case from == to:
+		return fmt.Errorf("%w: candidate is already on stage %q", ErrInvalidStageTransition, to)
+	case from == StageHired:
+		return fmt.Errorf("%w: %s is final", ErrInvalidStageTransition, StageHired)
+	case to == StageRejected:
+		return nil
+	case from == StageRejected:
+		if to == StageNew {
+			return nil
+		}
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidStageTransition, from, to)
+	}
+
+	fromIndex := slices.Index(pipeline, from)
+	toIndex := slices.Index(pipeline, to)
+	if fromIndex < 0 || toIndex > fromIndex || toIndex == fromIndex-1 {
+		// С неизвестного этапа (например, после смены вакансии) разрешаем любой переход
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s -> %s", ErrInvalidStageTransition, from, to)
+}
+
+// changeApplicationStage ставит отклик на этап toStage в позицию position колонки
+// (вне диапазона - в конец), перенумеровывает затронутые колонки и записывает
+// переход в историю, если этап меняется
+func changeApplicationStage(tx *sql.Tx, audit *AuditService, before *models.Application, toStage string, position int, reason, actor string) error {
+	column, err := stageColumn(tx, before.JobID, toStage, before.ID)
+	if err != nil {
+		return err
+	}
+	if position < 0 || position > len(column) {
+		position = len(column)
+	}
+	column = slices.Insert(column, position, before.ID)
+
+	// Условие на текущий этап защищает от одновременных переходов
+	result, err := tx.Exec("UPDATE applications SET stage = ? WHERE id = ? AND stage = ?", toStage, before.ID, before.Stage)
+	if err != nil {
+		return fmt.Errorf("failed to update application stage: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w: application stage was changed concurrently", ErrInvalidStageTransition)
+	}
+
+	if err := renumberColumn(tx, column); err != nil {
+		return err
+	}
+
+	if toStage != before.Stage {
+		// Закрываем промежуток в колонке, из которой ушел отклик
+		previous, err := stageColumn(tx, before.JobID, before.Stage, before.ID)
+		if err != nil {
+			return err
+		}
+		if err := renumberColumn(tx, previous); err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO candidate_stage_transitions (application_id, candidate_id, from_stage, to_stage, actor, reason)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`, before.ID, before.CandidateID, before.Stage, toStage, actor, reason)
+		if err != nil {
+			return fmt.Errorf("failed to record stage transition: %w", err)
+		}
+	}
+
+	after, err := loadApplication(tx, before.ID)
+	if err != nil {
+		return err
+	}
+
+	return audit.Record(tx, AuditEntityApplication, before.ID, &before.CandidateID, AuditActionUpdate, actor, before, after)
+}
+
+// stageColumn возвращает ID откликов колонки по порядку, исключая отклик exclude
+func stageColumn(q querier, jobID int64, stage string, exclude int64) ([]int64, error) {
+	rows, err := q.Query(`
+		SELECT id FROM applications
+		WHERE job_id = ? AND stage = ? AND id != ?
+		ORDER BY stage_position ASC, created_at ASC, id ASC
+	`, jobID, stage, exclude)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stage column: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan application id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+// renumberColumn записывает позиции откликов в колонке по порядку списка
+func renumberColumn(tx *sql.Tx, applicationIDs []int64) error {
+	for i, id := range applicationIDs {
+		_, err := tx.Exec("UPDATE applications SET stage_position = ? WHERE id = ? AND stage_position != ?", i, id, i)
+		if err != nil {
+			return fmt.Errorf("failed to update application position: %w", err)
+		}
+	}
+	return nil
+}
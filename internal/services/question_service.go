@@ -8,21 +8,28 @@ import (
 )
 
 type QuestionService struct {
-	db *database.DB
+	db    *database.DB
+	audit *AuditService
 }
 
-func NewQuestionService(db *database.DB) *QuestionService {
-	return &QuestionService{db: db}
+func NewQuestionService(db *database.DB, audit *AuditService) *QuestionService {
+	return &QuestionService{db: db, audit: audit}
 }
 
 // CreateQuestion создает новый вопрос
-func (s *QuestionService) CreateQuestion(question *models.Question) (*models.Question, error) {
+func (s *QuestionService) CreateQuestion(question *models.Question, actor string) (*models.Question, error) {
 	query := `
 		INSERT INTO questions (job_id, criterion_id, text) 
 		VALUES (?, ?, ?)
 	`
 
-	result, err := s.db.Exec(query, question.JobID, question.CriterionID, question.Text)
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(query, question.JobID, question.CriterionID, question.Text)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create question: %w", err)
 	}
@@ -33,11 +40,29 @@ func (s *QuestionService) CreateQuestion(question *models.Question) (*models.Que
 	}
 
 	question.ID = id
-	return s.GetQuestionByID(id)
+	created, err := loadQuestion(tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.audit.Record(tx, AuditEntityQuestion, id, nil, AuditActionCreate, actor, nil, created); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return created, nil
 }
 
 // GetQuestionByID получает вопрос по ID
 func (s *QuestionService) GetQuestionByID(id int64) (*models.Question, error) {
+	return loadQuestion(s.db, id)
+}
+
+// loadQuestion получает вопрос по ID через db или транзакцию
+func loadQuestion(q querier, id int64) (*models.Question, error) {
 	query := `
 		SELECT q.id, q.job_id, q.criterion_id, q.text, q.created_at, q.updated_at,
 		       c.name as criterion_name
@@ -47,7 +72,7 @@ func (s *QuestionService) GetQuestionByID(id int64) (*models.Question, error) {
 	`
 
 	var question models.Question
-	err := s.db.QueryRow(query, id).Scan(
+	err := q.QueryRow(query, id).Scan(
 		&question.ID, &question.JobID, &question.CriterionID, &question.Text,
 		&question.CreatedAt, &question.UpdatedAt, &question.CriterionName,
 	)
@@ -130,26 +155,61 @@ func (s *QuestionService) GetCriterionQuestions(criterionID int64) ([]models.Que
 }
 
 // UpdateQuestion обновляет вопрос
-func (s *QuestionService) UpdateQuestion(id int64, question *models.Question) (*models.Question, error) {
+func (s *QuestionService) UpdateQuestion(id int64, question *models.Question, actor string) (*models.Question, error) {
+	before, err := s.GetQuestionByID(id)
+	if err != nil {
+		return nil, err
+	}
+
 	query := `
 		UPDATE questions 
 		SET criterion_id = ?, text = ?
 		WHERE id = ?
 	`
 
-	_, err := s.db.Exec(query, question.CriterionID, question.Text, id)
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(query, question.CriterionID, question.Text, id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update question: %w", err)
 	}
 
-	return s.GetQuestionByID(id)
+	updated, err := loadQuestion(tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.audit.Record(tx, AuditEntityQuestion, id, nil, AuditActionUpdate, actor, before, updated); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return updated, nil
 }
 
 // DeleteQuestion удаляет вопрос
-func (s *QuestionService) DeleteQuestion(id int64) error {
+func (s *QuestionService) DeleteQuestion(id int64, actor string) error {
+	before, err := s.GetQuestionByID(id)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
 	query := `DELETE FROM questions WHERE id = ?`
 
-	result, err := s.db.Exec(query, id)
+	result, err := tx.Exec(query, id)
 	if err != nil {
 		return fmt.Errorf("failed to delete question: %w", err)
 	}
@@ -163,7 +223,11 @@ func (s *QuestionService) DeleteQuestion(id int64) error {
 		return fmt.Errorf("question not found")
 	}
 
-	return nil
+	if err := s.audit.Record(tx, AuditEntityQuestion, id, nil, AuditActionDelete, actor, before, nil); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 // GetQuestionsWithCriteria получает вопросы с полной информацией о критериях
@@ -0,0 +1,348 @@
+package services
+
+import (
+	"choizee/internal/database"
+	"choizee/internal/mcda"
+	"choizee/internal/models"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Методы ранжирования кандидатов в сводке оценок
+const (
+	RankingWeighted = "weighted" // Взвешенное среднее оценок по критериям
+	RankingTOPSIS   = "topsis"   // Близость к идеальному кандидату среди откликов вакансии
+	RankingAHP      = "ahp"      // Метод анализа иерархий с весами из попарных сравнений критериев
+
+	DefaultRankingMethod = RankingWeighted
+)
+
+// Шкала Саати для попарных сравнений: от 1/9 до 9. Допуск позволяет передавать
+// дроби вроде 0.111 вместо 1/9; CR до 0.1 считается приемлемым
+const (
+	maxComparisonValue    = 9.0
+	comparisonTolerance   = 1e-3
+	acceptableConsistency = 0.1
+)
+
+// ErrInvalidRanking возвращается при неизвестном методе ранжирования или некорректных сравнениях критериев
+var ErrInvalidRanking = errors.New("invalid ranking")
+
+type RankingService struct {
+	db    *database.DB
+	audit *AuditService
+}
+
+func NewRankingService(db *database.DB, audit *AuditService) *RankingService {
+	return &RankingService{db: db, audit: audit}
+}
+
+// GetJobAHPWeights возвращает матрицу попарных сравнений критериев вакансии и выведенные из нее веса
+func (s *RankingService) GetJobAHPWeights(jobID int64) (*models.AHPWeights, error) {
+	var exists int
+	err := s.db.QueryRow("SELECT 1 FROM jobs WHERE id = ?", jobID).Scan(&exists)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("job not found")
+		}
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+
+	criteria, err := loadJobCriteria(s.db, jobID)
+	if err != nil {
+		return nil, err
+	}
+	return jobAHPWeights(s.db, jobID, criteria)
+}
+
+// UpdateJobComparisons полностью заменяет попарные сравнения критериев вакансии.
+// Пара может быть задана в любом порядке, но только один раз
+func (s *RankingService) UpdateJobComparisons(jobID int64, comparisons []models.CriterionComparison, actor string) (*models.AHPWeights, error) {
+	before, err := s.GetJobAHPWeights(jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[int64]bool, len(before.Criteria))
+	for _, criterion := range before.Criteria {
+		known[criterion.CriterionID] = true
+	}
+
+	type pair struct{ a, b int64 }
+	seen := make(map[pair]bool, len(comparisons))
+	normalized := make([]models.CriterionComparison, 0, len(comparisons))
+	for _, comparison := range comparisons {
+		if comparison.CriterionA == comparison.CriterionB {
+			return nil, fmt.Errorf("%w: criterion %d is compared with itself", ErrInvalidRanking, comparison.CriterionA)
+		}
+		for _, id := range []int64{comparison.CriterionA, comparison.CriterionB} {
+			if !known[id] {
+				return nil, fmt.Errorf("%w: criterion %d does not belong to the job", ErrInvalidRanking, id)
+			}
+		}
+		value := comparison.Value
+		if math.IsNaN(value) || value < 1/maxComparisonValue-comparisonTolerance || value > maxComparisonValue {
+			return nil, fmt.Errorf("%w: comparison value must be between 1/9 and 9", ErrInvalidRanking)
+		}
+
+		// Храним пару с меньшим ID первым, обращая значение
+		if comparison.CriterionA > comparison.CriterionB {
+			comparison = models.CriterionComparison{CriterionA: comparison.CriterionB, CriterionB: comparison.CriterionA, Value: 1 / value}
+		}
+		key := pair{comparison.CriterionA, comparison.CriterionB}
+		if seen[key] {
+			return nil, fmt.Errorf("%w: criteria %d and %d are compared twice", ErrInvalidRanking, key.a, key.b)
+		}
+		seen[key] = true
+		normalized = append(normalized, comparison)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM criterion_comparisons WHERE job_id = ?", jobID); err != nil {
+		return nil, fmt.Errorf("failed to delete criterion comparisons: %w", err)
+	}
+	for _, comparison := range normalized {
+		_, err := tx.Exec(
+			"INSERT INTO criterion_comparisons (job_id, criterion_a_id, criterion_b_id, value) VALUES (?, ?, ?, ?)",
+			jobID, comparison.CriterionA, comparison.CriterionB, comparison.Value,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to save criterion comparison: %w", err)
+		}
+	}
+
+	if err := s.audit.Record(tx, AuditEntityCriterionComparisons, jobID, nil, AuditActionUpdate, actor, before.Comparisons, normalized); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return s.GetJobAHPWeights(jobID)
+}
+
+// jobAHPWeights строит матрицу попарных сравнений критериев и выводит из нее веса.
+// Пары без заданного сравнения берутся из отношения весов критериев, поэтому без
+// сравнений AHP дает те же веса, что и взвешенное среднее
+func jobAHPWeights(q querier, jobID int64, criteria []models.Criterion) (*models.AHPWeights, error) {
+	rows, err := q.Query(`
+		SELECT criterion_a_id, criterion_b_id, value
+		FROM criterion_comparisons
+		WHERE job_id = ?
+		ORDER BY criterion_a_id, criterion_b_id
+	`, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get criterion comparisons: %w", err)
+	}
+	defer rows.Close()
+
+	index := make(map[int64]int, len(criteria))
+	for i, criterion := range criteria {
+		index[criterion.ID] = i
+	}
+
+	matrix := make([][]float64, len(criteria))
+	for i := range matrix {
+		matrix[i] = make([]float64, len(criteria))
+		for j := range matrix[i] {
+			matrix[i][j] = criteria[i].Weight / criteria[j].Weight
+		}
+	}
+
+	weights := &models.AHPWeights{
+		JobID:       jobID,
+		Criteria:    []models.AHPCriterionWeight{},
+		Matrix:      matrix,
+		Comparisons: []models.CriterionComparison{},
+	}
+	for rows.Next() {
+		var comparison models.CriterionComparison
+		if err := rows.Scan(&comparison.CriterionA, &comparison.CriterionB, &comparison.Value); err != nil {
+			return nil, fmt.Errorf("failed to scan criterion comparison: %w", err)
+		}
+		a, okA := index[comparison.CriterionA]
+		b, okB := index[comparison.CriterionB]
+		if !okA || !okB {
+			continue
+		}
+		matrix[a][b] = comparison.Value
+		matrix[b][a] = 1 / comparison.Value
+		weights.Comparisons = append(weights.Comparisons, comparison)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read criterion comparisons: %w", err)
+	}
+
+	priorities, lambdaMax := mcda.PriorityVector(matrix)
+	for i, criterion := range criteria {
+		weights.Criteria = append(weights.Criteria, models.AHPCriterionWeight{
+			CriterionID:   criterion.ID,
+			CriterionName: criterion.Name,
+			Weight:        priorities[i],
+		})
+	}
+	weights.LambdaMax = lambdaMax
+	weights.ConsistencyRatio = mcda.ConsistencyRatio(len(criteria), lambdaMax)
+	weights.Consistent = weights.ConsistencyRatio <= acceptableConsistency
+
+	return weights, nil
+}
+
+// checkRankingMethod проверяет метод ранжирования; пустой метод - метод по умолчанию
+func checkRankingMethod(method string) (string, error) {
+	switch method {
+	case "":
+		return DefaultRankingMethod, nil
+	case RankingWeighted, RankingTOPSIS, RankingAHP:
+		return method, nil
+	}
+	return "", fmt.Errorf("%w: unknown method %q", ErrInvalidRanking, method)
+}
+
+// rankSummaries упорядочивает сводку методом method и объясняет место каждого кандидата
+// вкладом критериев. Сравниваются критерии, которые оценены хотя бы у одного кандидата;
+// неоцененный у кандидата критерий в TOPSIS и AHP считается минимумом шкалы.
+// Кандидаты, не прошедшие отсеивающие критерии, остаются в конце сводки
+func rankSummaries(q querier, jobID int64, method string, criteria []models.Criterion, summaries []models.EvaluationSummary) error {
+	// Оцененные критерии в порядке отображения и средние оценки кандидатов по ним
+	means := make([]map[int64]float64, len(summaries))
+	evaluated := make(map[int64]bool)
+	for i, summary := range summaries {
+		means[i] = make(map[int64]float64, len(summary.Criteria))
+		for _, criterion := range summary.Criteria {
+			means[i][criterion.CriterionID] = criterion.Mean
+			evaluated[criterion.CriterionID] = true
+		}
+	}
+	var columns []models.Criterion
+	for _, criterion := range criteria {
+		if evaluated[criterion.ID] {
+			columns = append(columns, criterion)
+		}
+	}
+
+	// Матрица в процентах шкалы, чтобы доли и расстояния не зависели от ее начала
+	matrix := make([][]float64, len(summaries))
+	for i, summary := range summaries {
+		matrix[i] = make([]float64, len(columns))
+		for j, criterion := range columns {
+			if mean, ok := means[i][criterion.ID]; ok {
+				matrix[i][j] = normalizeScore(summary.Scale, mean)
+			}
+		}
+	}
+
+	contributions := func(i int, weights, values []float64) []models.CriterionContribution {
+		result := make([]models.CriterionContribution, len(columns))
+		for j, criterion := range columns {
+			result[j] = models.CriterionContribution{
+				CriterionID:   criterion.ID,
+				CriterionName: criterion.Name,
+				Weight:        weights[j],
+				Contribution:  values[j],
+			}
+			if mean, ok := means[i][criterion.ID]; ok {
+				result[j].Value = &mean
+			}
+		}
+		return result
+	}
+
+	switch method {
+	case RankingWeighted:
+		for i := range summaries {
+			// Взвешенная оценка считается по оцененным у кандидата критериям
+			total := 0.0
+			weights := make([]float64, len(columns))
+			for j, criterion := range columns {
+				if _, ok := means[i][criterion.ID]; ok {
+					weights[j] = criterion.Weight
+					total += criterion.Weight
+				}
+			}
+			values := make([]float64, len(columns))
+			for j, criterion := range columns {
+				if total > 0 {
+					weights[j] /= total
+				}
+				values[j] = weights[j] * means[i][criterion.ID]
+			}
+			summaries[i].Ranking = models.CandidateRanking{
+				Method:        method,
+				Score:         summaries[i].WeightedScore,
+				Contributions: contributions(i, weights, values),
+			}
+		}
+
+	case RankingTOPSIS:
+		weights := make([]float64, len(columns))
+		for j, criterion := range columns {
+			weights[j] = criterion.Weight
+		}
+		solution := mcda.TOPSIS(matrix, weights)
+		for i := range summaries {
+			alternative := solution.Alternatives[i]
+			summaries[i].Ranking = models.CandidateRanking{
+				Method:            method,
+				Score:             alternative.Closeness,
+				IdealDistance:     &alternative.IdealDistance,
+				AntiIdealDistance: &alternative.AntiIdealDistance,
+				Contributions:     contributions(i, solution.Weights, alternative.Contributions),
+			}
+		}
+
+	case RankingAHP:
+		ahp, err := jobAHPWeights(q, jobID, criteria)
+		if err != nil {
+			return err
+		}
+		priorityOf := make(map[int64]float64, len(ahp.Criteria))
+		for _, criterion := range ahp.Criteria {
+			priorityOf[criterion.CriterionID] = criterion.Weight
+		}
+		weights := make([]float64, len(columns))
+		for j, criterion := range columns {
+			weights[j] = priorityOf[criterion.ID]
+		}
+		priorities, parts := mcda.AHPSynthesis(matrix, weights)
+		weights = mcda.NormalizeWeights(weights)
+		for i := range summaries {
+			summaries[i].Ranking = models.CandidateRanking{
+				Method:           method,
+				Score:            priorities[i],
+				ConsistencyRatio: &ahp.ConsistencyRatio,
+				Contributions:    contributions(i, weights, parts[i]),
+			}
+		}
+
+	default:
+		return fmt.Errorf("%w: unknown method %q", ErrInvalidRanking, method)
+	}
+
+	sort.SliceStable(summaries, func(i, j int) bool {
+		if summaries[i].KnockoutFailed != summaries[j].KnockoutFailed {
+			return !summaries[i].KnockoutFailed
+		}
+		if summaries[i].Ranking.Score != summaries[j].Ranking.Score {
+			return summaries[i].Ranking.Score > summaries[j].Ranking.Score
+		}
+		if summaries[i].WeightedScore != summaries[j].WeightedScore {
+			return summaries[i].WeightedScore > summaries[j].WeightedScore
+		}
+		return summaries[i].AverageScore > summaries[j].AverageScore
+	})
+	for i := range summaries {
+		summaries[i].Ranking.Rank = i + 1
+	}
+
+	return nil
+}
@@ -0,0 +1,199 @@
+package services
+
+import (
+	"choizee/internal/database"
+	"choizee/internal/models"
+	"choizee/internal/stats"
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Пороги альфы Криппендорфа: от 0.8 оценкам можно доверять, от 0.667 - делать
+// осторожные выводы, ниже критерий сформулирован слишком размыто
+const (
+	ReliabilityReliable         = "reliable"
+	ReliabilityTentative        = "tentative"
+	ReliabilityUnreliable       = "unreliable"
+	ReliabilityInsufficientData = "insufficient_data"
+
+	reliableAlpha  = 0.8
+	tentativeAlpha = 0.667
+)
+
+type ReliabilityService struct {
+	db *database.DB
+}
+
+func NewReliabilityService(db *database.DB) *ReliabilityService {
+	return &ReliabilityService{db: db}
+}
+
+// unitRating оценка интервьюера в составе объекта оценки (кандидат и критерий)
+type unitRating struct {
+	applicationID int64
+	evaluator     string
+	score         int
+}
+
+// GetJobReliability считает согласованность интервьюеров по каждому критерию вакансии
+// и по всем критериям вместе, а также находит наиболее расходящиеся пары интервьюеров
+func (s *ReliabilityService) GetJobReliability(jobID int64) (*models.JobReliability, error) {
+	report := &models.JobReliability{
+		JobID:    jobID,
+		Criteria: []models.CriterionReliability{},
+		Pairs:    []models.EvaluatorPair{},
+	}
+
+	err := s.db.QueryRow("SELECT title FROM jobs WHERE id = ?", jobID).Scan(&report.JobTitle)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("job not found")
+		}
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+
+	criteria, err := loadJobCriteria(s.db, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(`
+		SELECT e.criterion_id, e.application_id, e.evaluator, e.score
+		FROM evaluations e
+		JOIN applications a ON a.id = e.application_id
+		WHERE a.job_id = ?
+		ORDER BY e.criterion_id, e.application_id, e.evaluator
+	`, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query evaluations: %w", err)
+	}
+	defer rows.Close()
+
+	// Объекты оценки по критериям: оценки одного кандидата по одному критерию
+	units := make(map[int64][][]unitRating)
+	var lastCriterionID, lastApplicationID int64
+	for rows.Next() {
+		var criterionID int64
+		var rating unitRating
+		if err := rows.Scan(&criterionID, &rating.applicationID, &rating.evaluator, &rating.score); err != nil {
+			return nil, fmt.Errorf("failed to scan evaluation: %w", err)
+		}
+		criterionUnits := units[criterionID]
+		if len(criterionUnits) == 0 || criterionID != lastCriterionID || rating.applicationID != lastApplicationID {
+			criterionUnits = append(criterionUnits, nil)
+		}
+		criterionUnits[len(criterionUnits)-1] = append(criterionUnits[len(criterionUnits)-1], rating)
+		units[criterionID] = criterionUnits
+		lastCriterionID, lastApplicationID = criterionID, rating.applicationID
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read evaluations: %w", err)
+	}
+
+	var all [][]unitRating
+	for _, criterion := range criteria {
+		report.Criteria = append(report.Criteria, models.CriterionReliability{
+			CriterionID:         criterion.ID,
+			CriterionName:       criterion.Name,
+			ReliabilityMeasures: reliabilityMeasures(units[criterion.ID]),
+		})
+		all = append(all, units[criterion.ID]...)
+	}
+	report.Overall = reliabilityMeasures(all)
+	report.Pairs = evaluatorPairs(all)
+
+	return report, nil
+}
+
+// reliabilityMeasures считает меры согласованности по объектам оценки
+func reliabilityMeasures(units [][]unitRating) models.ReliabilityMeasures {
+	measures := models.ReliabilityMeasures{Level: ReliabilityInsufficientData}
+
+	values := make([][]float64, 0, len(units))
+	applications := make(map[int64]bool)
+	evaluators := make(map[string]bool)
+	for _, unit := range units {
+		if len(unit) < 2 {
+			continue
+		}
+		scores := make([]float64, len(unit))
+		for i, rating := range unit {
+			scores[i] = float64(rating.score)
+			evaluators[rating.evaluator] = true
+		}
+		applications[unit[0].applicationID] = true
+		values = append(values, scores)
+		measures.Ratings += len(unit)
+	}
+	measures.Candidates = len(applications)
+	measures.Evaluators = len(evaluators)
+
+	if icc, ok := stats.ICC(values); ok {
+		measures.ICC = &icc
+	}
+	if alpha, ok := stats.KrippendorffAlpha(values); ok {
+		measures.Alpha = &alpha
+		switch {
+		case alpha >= reliableAlpha:
+			measures.Level = ReliabilityReliable
+		case alpha >= tentativeAlpha:
+			measures.Level = ReliabilityTentative
+		default:
+			measures.Level = ReliabilityUnreliable
+		}
+	}
+
+	return measures
+}
+
+// evaluatorPairs сравнивает каждую пару интервьюеров на оценках, которые оба поставили
+// одному кандидату по одному критерию. Пары упорядочены по среднему модулю расхождения
+func evaluatorPairs(units [][]unitRating) []models.EvaluatorPair {
+	type pairKey struct{ a, b string }
+	pairs := make(map[pairKey]*models.EvaluatorPair)
+	sums := make(map[pairKey]*[2]float64) // Сумма модулей разностей и сумма разностей
+
+	for _, unit := range units {
+		// Оценки объекта упорядочены по интервьюеру, поэтому в паре A всегда раньше B
+		for i := 0; i < len(unit); i++ {
+			for j := i + 1; j < len(unit); j++ {
+				key := pairKey{unit[i].evaluator, unit[j].evaluator}
+				pair, ok := pairs[key]
+				if !ok {
+					pair = &models.EvaluatorPair{EvaluatorA: key.a, EvaluatorB: key.b}
+					pairs[key] = pair
+					sums[key] = &[2]float64{}
+				}
+				diff := unit[i].score - unit[j].score
+				pair.Shared++
+				pair.MaxDiff = max(pair.MaxDiff, int(math.Abs(float64(diff))))
+				sums[key][0] += math.Abs(float64(diff))
+				sums[key][1] += float64(diff)
+			}
+		}
+	}
+
+	result := make([]models.EvaluatorPair, 0, len(pairs))
+	for key, pair := range pairs {
+		pair.MeanAbsDiff = sums[key][0] / float64(pair.Shared)
+		pair.MeanDiff = sums[key][1] / float64(pair.Shared)
+		result = append(result, *pair)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].MeanAbsDiff != result[j].MeanAbsDiff {
+			return result[i].MeanAbsDiff > result[j].MeanAbsDiff
+		}
+		if result[i].Shared != result[j].Shared {
+			return result[i].Shared > result[j].Shared
+		}
+		if result[i].EvaluatorA != result[j].EvaluatorA {
+			return result[i].EvaluatorA < result[j].EvaluatorA
+		}
+		return result[i].EvaluatorB < result[j].EvaluatorB
+	})
+
+	return result
+}
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"choizee/internal/database"
+	"choizee/internal/models"
+)
+
+type ReportService struct {
+	db          *database.DB
+	comparisons *ComparisonService
+}
+
+func NewReportService(db *database.DB, comparisons *ComparisonService) *ReportService {
+	return &ReportService{db: db, comparisons: comparisons}
+}
+
+// GetCandidateReport собирает карточку кандидата по отклику: оценки по критериям
+// с комментариями интервьюеров, данные радар-диаграммы и ответы на вопросы
+func (s *ReportService) GetCandidateReport(applicationID int64) (*models.CandidateReport, error) {
+	application, err := loadApplication(s.db, applicationID)
+	if err != nil {
+		return nil, err
+	}
+
+	comparison, summaries, err := s.comparisons.compare(application.JobID, []int64{application.CandidateID})
+	if err != nil {
+		return nil, err
+	}
+	stageNames, err := s.stageNames(application.JobID)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := s.candidateReport(comparison, summaries[0], 0, stageNames)
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// GetCandidateChart возвращает средние оценки кандидата по критериям вакансии отклика
+// в виде сравнения из одного кандидата - по нему строится диаграмма
+func (s *ReportService) GetCandidateChart(applicationID int64) (*models.CandidateComparison, error) {
+	application, err := loadApplication(s.db, applicationID)
+	if err != nil {
+		return nil, err
+	}
+
+	comparison, _, err := s.comparisons.compare(application.JobID, []int64{application.CandidateID})
+	if err != nil {
+		return nil, err
+	}
+	return comparison, nil
+}
+
+// GetJobReport собирает отчет по вакансии: сравнение всех кандидатов
+// и карточку каждого из них в порядке сводки
+func (s *ReportService) GetJobReport(jobID int64) (*models.JobReport, error) {
+	comparison, summaries, err := s.comparisons.compare(jobID, nil)
+	if err != nil {
+		return nil, err
+	}
+	stageNames, err := s.stageNames(jobID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range comparison.Candidates {
+		if name, ok := stageNames[comparison.Candidates[i].Stage]; ok {
+			comparison.Candidates[i].Stage = name
+		}
+	}
+
+	report := &models.JobReport{
+		Comparison: *comparison,
+		Candidates: make([]models.CandidateReport, 0, len(summaries)),
+	}
+	for i, summary := range summaries {
+		candidate, err := s.candidateReport(comparison, summary, i, stageNames)
+		if err != nil {
+			return nil, err
+		}
+		report.Candidates = append(report.Candidates, candidate)
+	}
+
+	return report, nil
+}
+
+// candidateReport дополняет сводку кандидата данными кандидата и отклика
+// и его ответами из сравнения; position - место кандидата в сравнении
+func (s *ReportService) candidateReport(comparison *models.CandidateComparison, summary *models.EvaluationSummary, position int, stageNames map[string]string) (models.CandidateReport, error) {
+	candidate, err := loadCandidate(s.db, summary.CandidateID)
+	if err != nil {
+		return models.CandidateReport{}, err
+	}
+	application, err := loadApplication(s.db, summary.ApplicationID)
+	if err != nil {
+		return models.CandidateReport{}, err
+	}
+
+	report := models.CandidateReport{
+		Candidate:   *candidate,
+		Application: *application,
+		StageName:   application.Stage,
+		Summary:     *summary,
+		Questions:   make([]models.ReportQuestion, 0, len(comparison.Questions)),
+	}
+	if name, ok := stageNames[application.Stage]; ok {
+		report.StageName = name
+	}
+	for _, question := range comparison.Questions {
+		report.Questions = append(report.Questions, models.ReportQuestion{
+			CriterionName: question.CriterionName,
+			Text:          question.Text,
+			AnswerText:    question.Answers[position].AnswerText,
+		})
+	}
+
+	return report, nil
+}
+
+// stageNames возвращает названия этапов вакансии по их ключам
+func (s *ReportService) stageNames(jobID int64) (map[string]string, error) {
+	stages, err := loadJobStages(s.db, jobID)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]string, len(stages))
+	for _, stage := range stages {
+		names[stage.Key] = stage.Name
+	}
+	return names, nil
+}
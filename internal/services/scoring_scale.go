@@ -0,0 +1,183 @@
+package services
+
+import (
+	"choizee/internal/models"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Типы шкал оценок вакансии
+const (
+	ScaleFivePoint = "five_point" // 1-5
+	ScaleTenPoint  = "ten_point"  // 1-10
+	ScaleFourPoint = "four_point" // 1-4 без середины: интервьюер должен склониться к "да" или "нет"
+	ScalePassFail  = "pass_fail"  // 0 - не пройдено, 1 - пройдено
+	ScaleCustom    = "custom"     // Собственные уровни с подписями
+)
+
+// DefaultScaleType шкала вакансии, если шкала не выбрана
+const DefaultScaleType = ScaleTenPoint
+
+// maxCustomScaleLevels ограничивает число уровней собственной шкалы
+const maxCustomScaleLevels = 20
+
+var (
+	// ErrInvalidScale возвращается при некорректной шкале оценок вакансии
+	ErrInvalidScale = errors.New("invalid scoring scale")
+	// ErrInvalidScore возвращается, если оценка не соответствует шкале вакансии
+	ErrInvalidScore = errors.New("invalid score")
+)
+
+// presetScales предустановленные шкалы в порядке отображения
+var presetScales = []models.ScoringScale{
+	{Type: ScaleFivePoint, Min: 1, Max: 5},
+	{Type: ScaleTenPoint, Min: 1, Max: 10},
+	{Type: ScaleFourPoint, Min: 1, Max: 4, Levels: []models.ScaleLevel{
+		{Value: 1, Label: "Точно нет"},
+		{Value: 2, Label: "Скорее нет"},
+		{Value: 3, Label: "Скорее да"},
+		{Value: 4, Label: "Точно да"},
+	}},
+	{Type: ScalePassFail, Min: 0, Max: 1, Levels: []models.ScaleLevel{
+		{Value: 0, Label: "Не пройдено"},
+		{Value: 1, Label: "Пройдено"},
+	}},
+}
+
+// ScoringScales возвращает предустановленные шкалы оценок
+func ScoringScales() []models.ScoringScale {
+	scales := make([]models.ScoringScale, len(presetScales))
+	for i, scale := range presetScales {
+		scales[i] = copyScale(scale)
+	}
+	return scales
+}
+
+// NewScoringScale собирает шкалу по типу. Уровни учитываются только у собственной шкалы:
+// у предустановленных они фиксированы, пустой тип означает шкалу по умолчанию
+func NewScoringScale(scaleType string, levels []models.ScaleLevel) (models.ScoringScale, error) {
+	if scaleType == "" {
+		scaleType = DefaultScaleType
+	}
+
+	for _, scale := range presetScales {
+		if scale.Type == scaleType {
+			return copyScale(scale), nil
+		}
+	}
+	if scaleType != ScaleCustom {
+		return models.ScoringScale{}, fmt.Errorf("%w: unknown scale type %q", ErrInvalidScale, scaleType)
+	}
+
+	if len(levels) < 2 || len(levels) > maxCustomScaleLevels {
+		return models.ScoringScale{}, fmt.Errorf("%w: custom scale must have from 2 to %d levels", ErrInvalidScale, maxCustomScaleLevels)
+	}
+
+	scale := models.ScoringScale{Type: ScaleCustom, Levels: make([]models.ScaleLevel, 0, len(levels))}
+	seen := make(map[int]bool)
+	for _, level := range levels {
+		level.Label = strings.TrimSpace(level.Label)
+		if level.Label == "" {
+			return models.ScoringScale{}, fmt.Errorf("%w: level %d has no label", ErrInvalidScale, level.Value)
+		}
+		if level.Value < 0 || level.Value > 100 {
+			return models.ScoringScale{}, fmt.Errorf("%w: level values must be between 0 and 100", ErrInvalidScale)
+		}
+		if seen[level.Value] {
+			return models.ScoringScale{}, fmt.Errorf("%w: duplicate level value %d", ErrInvalidScale, level.Value)
+		}
+		seen[level.Value] = true
+		scale.Levels = append(scale.Levels, level)
+	}
+
+	sort.Slice(scale.Levels, func(i, j int) bool {
+		return scale.Levels[i].Value < scale.Levels[j].Value
+	})
+	scale.Min = scale.Levels[0].Value
+	scale.Max = scale.Levels[len(scale.Levels)-1].Value
+
+	return scale, nil
+}
+
+// copyScale копирует шкалу, чтобы изменения уровней не затронули предустановленные шкалы
+func copyScale(scale models.ScoringScale) models.ScoringScale {
+	if scale.Levels != nil {
+		scale.Levels = append([]models.ScaleLevel(nil), scale.Levels...)
+	}
+	return scale
+}
+
+// validateScore проверяет, что оценка - допустимое значение шкалы
+func validateScore(scale models.ScoringScale, score int) error {
+	if len(scale.Levels) > 0 {
+		for _, level := range scale.Levels {
+			if level.Value == score {
+				return nil
+			}
+		}
+		values := make([]string, len(scale.Levels))
+		for i, level := range scale.Levels {
+			values[i] = fmt.Sprint(level.Value)
+		}
+		return fmt.Errorf("%w: %d is not on the %s scale (allowed: %s)", ErrInvalidScore, score, scale.Type, strings.Join(values, ", "))
+	}
+
+	if score < scale.Min || score > scale.Max {
+		return fmt.Errorf("%w: score must be between %d and %d", ErrInvalidScore, scale.Min, scale.Max)
+	}
+	return nil
+}
+
+// normalizeScore переводит значение шкалы в проценты шкалы: минимум - 0, максимум - 100
+func normalizeScore(scale models.ScoringScale, value float64) float64 {
+	if scale.Max == scale.Min {
+		return 0
+	}
+	return (value - float64(scale.Min)) / float64(scale.Max-scale.Min) * 100
+}
+
+// isDisagreement сообщает, что разброс оценок по критерию не меньше трети шкалы
+func isDisagreement(scale models.ScoringScale, spread int) bool {
+	return spread*3 >= scale.Max-scale.Min
+}
+
+// loadJobScale загружает шкалу оценок вакансии
+func loadJobScale(q querier, jobID int64) (models.ScoringScale, error) {
+	var scaleType string
+	var levels sql.NullString
+	err := q.QueryRow("SELECT scale_type, scale_levels FROM jobs WHERE id = ?", jobID).Scan(&scaleType, &levels)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.ScoringScale{}, fmt.Errorf("job not found")
+		}
+		return models.ScoringScale{}, fmt.Errorf("failed to get scoring scale: %w", err)
+	}
+	return scaleFromColumns(scaleType, levels)
+}
+
+// scaleFromColumns восстанавливает шкалу из полей scale_type и scale_levels вакансии
+func scaleFromColumns(scaleType string, levels sql.NullString) (models.ScoringScale, error) {
+	var customLevels []models.ScaleLevel
+	if scaleType == ScaleCustom && levels.Valid {
+		if err := json.Unmarshal([]byte(levels.String), &customLevels); err != nil {
+			return models.ScoringScale{}, fmt.Errorf("failed to decode scale levels: %w", err)
+		}
+	}
+	return NewScoringScale(scaleType, customLevels)
+}
+
+// scaleLevelsColumn возвращает значение scale_levels: JSON уровней собственной шкалы или NULL
+func scaleLevelsColumn(scale models.ScoringScale) (any, error) {
+	if scale.Type != ScaleCustom {
+		return nil, nil
+	}
+	data, err := json.Marshal(scale.Levels)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode scale levels: %w", err)
+	}
+	return string(data), nil
+}
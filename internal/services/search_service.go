@@ -0,0 +1,201 @@
+package services
+
+import (
+	"choizee/internal/database"
+	"choizee/internal/models"
+	"choizee/internal/search"
+	"fmt"
+	"slices"
+)
+
+const (
+	SearchTypeJob       = "job"
+	SearchTypeCandidate = "candidate"
+	SearchTypeQuestion  = "question"
+	SearchTypeAnswer    = "answer"
+)
+
+// SearchTypes типы сущностей в порядке вывода групп
+var SearchTypes = []string{SearchTypeJob, SearchTypeCandidate, SearchTypeQuestion, SearchTypeAnswer}
+
+const (
+	defaultSearchLimit = 10
+	maxSearchLimit     = 50
+	// snippetWords длина фрагмента с подсветкой в словах
+	snippetWords = 24
+)
+
+// searchSource описывает FTS5-индекс одного типа сущностей.
+// Запрос возвращает id, job_id, candidate_id, question_id, заголовок,
+// оценку bm25 и затем исходный текст полей из fields
+type searchSource struct {
+	entity    string
+	table     string
+	from      string
+	columns   string
+	jobFilter string
+	fields    []string
+}
+
+var searchSources = map[string]searchSource{
+	SearchTypeJob: {
+		entity:    SearchTypeJob,
+		table:     "jobs_fts",
+		from:      "jobs_fts JOIN jobs j ON j.id = jobs_fts.rowid",
+		columns:   "j.id, j.id, 0, 0, j.title, bm25(jobs_fts, 10.0, 2.0, 2.0), j.title, COALESCE(j.description, ''), COALESCE(j.requirements, '')",
+		jobFilter: "j.id = ?",
+		fields:    []string{"title", "description", "requirements"},
+	},
+	SearchTypeCandidate: {
+		entity:    SearchTypeCandidate,
+		table:     "candidates_fts",
+		from:      "candidates_fts JOIN candidates c ON c.id = candidates_fts.rowid",
+		columns:   "c.id, c.job_id, c.id, 0, c.name, bm25(candidates_fts, 10.0, 5.0, 1.0, 1.0), c.name, COALESCE(c.email, ''), COALESCE(c.description, ''), COALESCE(c.resume_text, '')",
+		jobFilter: "EXISTS (SELECT 1 FROM applications ap WHERE ap.candidate_id = c.id AND ap.job_id = ?)",
+		fields:    []string{"name", "email", "description", "resume_text"},
+	},
+	SearchTypeQuestion: {
+		entity:    SearchTypeQuestion,
+		table:     "questions_fts",
+		from:      "questions_fts JOIN questions q ON q.id = questions_fts.rowid",
+		columns:   "q.id, q.job_id, 0, q.id, q.text, bm25(questions_fts), q.text",
+		jobFilter: "q.job_id = ?",
+		fields:    []string{"text"},
+	},
+	SearchTypeAnswer: {
+		entity: SearchTypeAnswer,
+		table:  "answers_fts",
+		from: `answers_fts JOIN answers a ON a.id = answers_fts.rowid
+			LEFT JOIN applications ap ON ap.id = a.application_id
+			LEFT JOIN candidates c ON c.id = a.candidate_id`,
+		columns:   "a.id, COALESCE(ap.job_id, 0), a.candidate_id, a.question_id, COALESCE(c.name, ''), bm25(answers_fts), COALESCE(a.answer_text, '')",
+		jobFilter: "ap.job_id = ?",
+		fields:    []string{"answer_text"},
+	},
+}
+
+type SearchService struct {
+	db *database.DB
+}
+
+func NewSearchService(db *database.DB) *SearchService {
+	return &SearchService{db: db}
+}
+
+// Search выполняет полнотекстовый поиск и группирует результаты по типам сущностей.
+// Внутри группы результаты упорядочены по релевантности (bm25)
+func (s *SearchService) Search(filter models.SearchFilter) (*models.SearchResponse, error) {
+	response := &models.SearchResponse{
+		Query:  filter.Query,
+		Groups: []models.SearchGroup{},
+	}
+
+	query := search.ParseQuery(filter.Query)
+	if query.Empty() {
+		return response, nil
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+	if limit > maxSearchLimit {
+		limit = maxSearchLimit
+	}
+
+	for _, entity := range SearchTypes {
+		if len(filter.Types) > 0 && !slices.Contains(filter.Types, entity) {
+			continue
+		}
+
+		group, err := s.searchSource(searchSources[entity], query, filter.JobID, limit)
+		if err != nil {
+			return nil, err
+		}
+		if group.Total == 0 {
+			continue
+		}
+
+		response.Total += group.Total
+		response.Groups = append(response.Groups, *group)
+	}
+
+	return response, nil
+}
+
+// searchSource ищет по индексу одного типа сущностей
+func (s *SearchService) searchSource(source searchSource, query search.Query, jobID int64, limit int) (*models.SearchGroup, error) {
+	where := source.table + " MATCH ?"
+	args := []any{query.MatchExpression()}
+	if jobID != 0 {
+		where += " AND " + source.jobFilter
+		args = append(args, jobID)
+	}
+
+	group := &models.SearchGroup{Type: source.entity, Results: []models.SearchResult{}}
+
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s", source.from, where)
+	if err := s.db.QueryRow(countQuery, args...).Scan(&group.Total); err != nil {
+		return nil, fmt.Errorf("failed to count %s search results: %w", source.entity, err)
+	}
+	if group.Total == 0 {
+		return group, nil
+	}
+
+	resultsQuery := fmt.Sprintf("SELECT %s FROM %s WHERE %s ORDER BY 6 LIMIT ?", source.columns, source.from, where)
+	rows, err := s.db.Query(resultsQuery, append(args, limit)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search %s: %w", source.entity, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var result models.SearchResult
+		var rank float64
+		texts := make([]string, len(source.fields))
+
+		dest := []any{&result.ID, &result.JobID, &result.CandidateID, &result.QuestionID, &result.Title, &rank}
+		for i := range texts {
+			dest = append(dest, &texts[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("failed to scan %s search result: %w", source.entity, err)
+		}
+
+		// bm25 возвращает отрицательные значения: чем меньше, тем релевантнее
+		result.Score = -rank
+		result.Field, result.Snippet = bestSnippet(query, source.fields, texts)
+		group.Results = append(group.Results, result)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s search results: %w", source.entity, err)
+	}
+
+	return group, nil
+}
+
+// bestSnippet выбирает поле с наибольшим числом совпадений и строит по нему фрагмент
+func bestSnippet(query search.Query, fields, texts []string) (string, string) {
+	best, bestCount := -1, 0
+	for i, text := range texts {
+		if count := query.CountMatches(text); count > bestCount {
+			best, bestCount = i, count
+		}
+	}
+
+	if best < 0 {
+		// Совпадение есть в индексе, но не подсвечивается: показываем начало первого непустого поля
+		for i, text := range texts {
+			if text != "" {
+				best = i
+				break
+			}
+		}
+		if best < 0 {
+			return "", ""
+		}
+	}
+
+	return fields[best], query.Snippet(texts[best], snippetWords)
+}
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"choizee/internal/models"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -16,6 +17,8 @@ type JobTemplate struct {
 	Requirements string                  `json:"requirements"`
 	Criteria     []string                `json:"criteria"`
 	Questions    []TemplateQuestionGroup `json:"questions"`
+	Rubrics      []TemplateRubric        `json:"rubrics,omitempty"`       // Рубрики по умолчанию, копируются в критерии вакансии
+	ScoringScale *models.ScoringScale    `json:"scoring_scale,omitempty"` // Шкала вакансии, по умолчанию 1-10
 }
 
 type TemplateQuestionGroup struct {
@@ -23,6 +26,12 @@ type TemplateQuestionGroup struct {
 	Questions []string `json:"questions"`
 }
 
+// TemplateRubric рубрика критерия шаблона
+type TemplateRubric struct {
+	Criterion string               `json:"criterion"`
+	Levels    []models.RubricLevel `json:"levels"`
+}
+
 type TemplatesData struct {
 	Templates []JobTemplate `json:"templates"`
 }
@@ -0,0 +1,94 @@
+package stats
+
+// Меры согласованности оценщиков. Данные передаются как набор объектов оценки
+// (units): для каждого кандидата - оценки всех интервьюеров, поставивших ее.
+// Интервьюеры могут оценивать разных кандидатов, поэтому число оценок
+// у объектов различается. Объекты с одной оценкой не несут информации
+// о согласованности и не учитываются
+
+// pairable оставляет объекты, у которых есть хотя бы две оценки
+func pairable(units [][]float64) [][]float64 {
+	result := make([][]float64, 0, len(units))
+	for _, unit := range units {
+		if len(unit) >= 2 {
+			result = append(result, unit)
+		}
+	}
+	return result
+}
+
+// ICC возвращает внутриклассовую корреляцию ICC(1) - однофакторную модель со случайными
+// эффектами, которая допускает разное число оценщиков у объектов (размер группы
+// берется скорректированным средним n0). Значение близко к 1, когда различия между
+// кандидатами намного больше различий между оценками одного кандидата.
+// ok = false, если объектов меньше двух или разброса нет вовсе
+func ICC(units [][]float64) (value float64, ok bool) {
+	units = pairable(units)
+	if len(units) < 2 {
+		return 0, false
+	}
+
+	total, count, sumSquares := 0.0, 0, 0.0
+	for _, unit := range units {
+		for _, v := range unit {
+			total += v
+			count++
+		}
+		sumSquares += float64(len(unit) * len(unit))
+	}
+	grand := total / float64(count)
+
+	var between, within float64
+	for _, unit := range units {
+		m := Mean(unit)
+		between += float64(len(unit)) * (m - grand) * (m - grand)
+		for _, v := range unit {
+			within += (v - m) * (v - m)
+		}
+	}
+
+	subjects := float64(len(units))
+	msBetween := between / (subjects - 1)
+	msWithin := within / float64(count-len(units))
+	n0 := (float64(count) - sumSquares/float64(count)) / (subjects - 1)
+
+	denominator := msBetween + (n0-1)*msWithin
+	if denominator == 0 {
+		return 0, false
+	}
+	return (msBetween - msWithin) / denominator, true
+}
+
+// KrippendorffAlpha возвращает альфу Криппендорфа с интервальной метрикой:
+// 1 - отношение наблюдаемого расхождения внутри объектов к ожидаемому расхождению
+// между всеми оценками. 1 - полное согласие, 0 - согласие на уровне случайного.
+// ok = false, если парных оценок нет или все оценки одинаковы
+func KrippendorffAlpha(units [][]float64) (value float64, ok bool) {
+	units = pairable(units)
+	if len(units) == 0 {
+		return 0, false
+	}
+
+	var observed, sum, sumSquares float64
+	n := 0
+	for _, unit := range units {
+		m := float64(len(unit))
+		var unitSum, unitSquares float64
+		for _, v := range unit {
+			unitSum += v
+			unitSquares += v * v
+		}
+		// Сумма квадратов разностей по всем упорядоченным парам оценок: 2(m·Σv² - (Σv)²)
+		observed += 2 * (m*unitSquares - unitSum*unitSum) / (m - 1)
+		sum += unitSum
+		sumSquares += unitSquares
+		n += len(unit)
+	}
+
+	values := float64(n)
+	expected := 2 * (values*sumSquares - sum*sum) / (values * (values - 1))
+	if expected == 0 {
+		return 0, false
+	}
+	return 1 - (observed/values)/expected, true
+}
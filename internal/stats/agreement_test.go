@@ -0,0 +1,91 @@
+package stats
+
+import (
+	"math"
+	"testing"
+)
+
+// shroutFleiss - 6 объектов, 4 оценщика (Shrout, Fleiss, 1979); ICC(1,1) = 0.17
+var shroutFleiss = [][]float64{
+	{9, 2, 5, 8},
+	{6, 1, 3, 2},
+	{8, 4, 6, 8},
+	{7, 1, 2, 6},
+	{10, 5, 6, 9},
+	{6, 2, 4, 7},
+}
+
+// krippendorff - пример с пропусками из "Computing Krippendorff's Alpha-Reliability"
+// (Krippendorff, 2011): 4 оценщика, 12 объектов; интервальная альфа 0.849.
+// Последний объект с единственной оценкой не учитывается
+var krippendorff = [][]float64{
+	{1, 1, 1},
+	{2, 2, 3, 2},
+	{3, 3, 3, 3},
+	{3, 3, 3, 3},
+	{2, 2, 2, 2},
+	{1, 2, 3, 4},
+	{4, 4, 4, 4},
+	{1, 1, 2, 1},
+	{2, 2, 2, 2},
+	{5, 5, 5},
+	{1, 1},
+	{3},
+}
+
+func TestICC(t *testing.T) {
+	tests := []struct {
+		name  string
+		units [][]float64
+		want  float64
+		ok    bool
+	}{
+		{name: "Shrout and Fleiss", units: shroutFleiss, want: 0.165741768405475, ok: true},
+		// MSB = 57, MSW = 0.6, n0 = 26/9
+		{name: "unbalanced", units: [][]float64{{1, 2}, {4, 5, 6}, {8, 9, 9, 10}}, want: 18.0 / 19, ok: true},
+		{name: "perfect agreement", units: [][]float64{{1, 1}, {3, 3, 3}, {5, 5}}, want: 1, ok: true},
+		{name: "single rating units ignored", units: [][]float64{{1, 1}, {2}, {4}}},
+		{name: "no variance", units: [][]float64{{2, 2}, {2, 2}}},
+		{name: "empty"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ICC(tt.units)
+			if ok != tt.ok {
+				t.Fatalf("ICC() ok = %v, want %v", ok, tt.ok)
+			}
+			if ok && math.Abs(got-tt.want) > tolerance {
+				t.Errorf("ICC() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKrippendorffAlpha(t *testing.T) {
+	tests := []struct {
+		name  string
+		units [][]float64
+		want  float64
+		ok    bool
+	}{
+		{name: "Krippendorff 2011", units: krippendorff, want: 0.849107142857143, ok: true},
+		{name: "perfect agreement", units: [][]float64{{1, 1}, {3, 3, 3}, {5, 5}}, want: 1, ok: true},
+		// Внутри объектов оценки расходятся сильнее, чем в среднем по всем оценкам
+		{name: "systematic disagreement", units: [][]float64{{1, 2}, {1, 2}}, want: -0.5, ok: true},
+		{name: "no pairable units", units: [][]float64{{1}, {2}}},
+		{name: "all ratings equal", units: [][]float64{{3, 3}, {3, 3, 3}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := KrippendorffAlpha(tt.units)
+			if ok != tt.ok {
+				t.Fatalf("KrippendorffAlpha() ok = %v, want %v", ok, tt.ok)
+			}
+			if ok && math.Abs(got-tt.want) > tolerance {
+				t.Errorf("KrippendorffAlpha() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package stats
+
+import "math"
+
+// Pearson возвращает коэффициент корреляции Пирсона между x и y одинаковой длины.
+// Если одна из величин принимает только значения 0 и 1, это точечно-бисериальная
+// корреляция, если обе - коэффициент фи. ok = false, если пар меньше двух
+// или одна из величин не меняется
+func Pearson(x, y []float64) (value float64, ok bool) {
+	if len(x) != len(y) || len(x) < 2 {
+		return 0, false
+	}
+
+	mx, my := Mean(x), Mean(y)
+	var covariance, varianceX, varianceY float64
+	for i := range x {
+		dx, dy := x[i]-mx, y[i]-my
+		covariance += dx * dy
+		varianceX += dx * dx
+		varianceY += dy * dy
+	}
+	if varianceX == 0 || varianceY == 0 {
+		return 0, false
+	}
+	return covariance / math.Sqrt(varianceX*varianceY), true
+}
+
+// AUC возвращает площадь под ROC-кривой: вероятность того, что случайное значение из positive
+// больше случайного значения из negative (равные значения считаются за половину).
+// 0.5 - значения не разделяют группы, 1 - все positive выше всех negative, 0 - наоборот.
+// ok = false, если одна из групп пуста
+func AUC(positive, negative []float64) (value float64, ok bool) {
+	if len(positive) == 0 || len(negative) == 0 {
+		return 0, false
+	}
+
+	wins := 0.0
+	for _, p := range positive {
+		for _, n := range negative {
+			switch {
+			case p > n:
+				wins++
+			case p == n:
+				wins += 0.5
+			}
+		}
+	}
+	return wins / float64(len(positive)*len(negative)), true
+}
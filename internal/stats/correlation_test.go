@@ -0,0 +1,62 @@
+package stats
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPearson(t *testing.T) {
+	tests := []struct {
+		name string
+		x, y []float64
+		want float64
+		ok   bool
+	}{
+		{name: "positive", x: []float64{1, 2, 3, 4, 5}, y: []float64{2, 4, 5, 4, 5}, want: math.Sqrt(0.6), ok: true},
+		{name: "perfect negative", x: []float64{1, 2, 3}, y: []float64{6, 4, 2}, want: -1, ok: true},
+		// Точечно-бисериальная корреляция с бинарной y
+		{name: "point-biserial", x: []float64{1, 2, 3, 4}, y: []float64{0, 0, 1, 1}, want: 0.894427190999916, ok: true},
+		{name: "constant", x: []float64{1, 2, 3}, y: []float64{1, 1, 1}},
+		{name: "length mismatch", x: []float64{1, 2}, y: []float64{1}},
+		{name: "single pair", x: []float64{1}, y: []float64{2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Pearson(tt.x, tt.y)
+			if ok != tt.ok {
+				t.Fatalf("Pearson() ok = %v, want %v", ok, tt.ok)
+			}
+			if ok && math.Abs(got-tt.want) > tolerance {
+				t.Errorf("Pearson() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAUC(t *testing.T) {
+	tests := []struct {
+		name               string
+		positive, negative []float64
+		want               float64
+		ok                 bool
+	}{
+		{name: "separated", positive: []float64{4, 5}, negative: []float64{1, 2, 3}, want: 1, ok: true},
+		{name: "reversed", positive: []float64{1}, negative: []float64{2, 3}, want: 0, ok: true},
+		// 3 победы и одна ничья из 4 пар
+		{name: "ties", positive: []float64{3, 4}, negative: []float64{1, 3}, want: 0.875, ok: true},
+		{name: "empty group", positive: []float64{1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := AUC(tt.positive, tt.negative)
+			if ok != tt.ok {
+				t.Fatalf("AUC() ok = %v, want %v", ok, tt.ok)
+			}
+			if ok && math.Abs(got-tt.want) > tolerance {
+				t.Errorf("AUC() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package stats
+
+import (
+	"math"
+	"testing"
+)
+
+const tolerance = 1e-9
+
+func TestMean(t *testing.T) {
+	tests := []struct {
+		values []float64
+		want   float64
+	}{
+		{nil, 0},
+		{[]float64{4}, 4},
+		{[]float64{1, 2, 3, 4}, 2.5},
+	}
+
+	for _, tt := range tests {
+		if got := Mean(tt.values); math.Abs(got-tt.want) > tolerance {
+			t.Errorf("Mean(%v) = %v, want %v", tt.values, got, tt.want)
+		}
+	}
+}
+
+func TestMedian(t *testing.T) {
+	tests := []struct {
+		values []float64
+		want   float64
+	}{
+		{nil, 0},
+		{[]float64{3, 1, 2}, 2},
+		{[]float64{4, 1, 3, 2}, 2.5},
+	}
+
+	for _, tt := range tests {
+		values := append([]float64(nil), tt.values...)
+		if got := Median(values); math.Abs(got-tt.want) > tolerance {
+			t.Errorf("Median(%v) = %v, want %v", tt.values, got, tt.want)
+		}
+		for i := range values {
+			if values[i] != tt.values[i] {
+				t.Fatalf("Median(%v) modified its argument", tt.values)
+			}
+		}
+	}
+}
+
+func TestStdDev(t *testing.T) {
+	tests := []struct {
+		values []float64
+		want   float64
+	}{
+		{nil, 0},
+		{[]float64{5, 5, 5}, 0},
+		{[]float64{2, 4, 4, 4, 5, 5, 7, 9}, 2},
+	}
+
+	for _, tt := range tests {
+		if got := StdDev(tt.values); math.Abs(got-tt.want) > tolerance {
+			t.Errorf("StdDev(%v) = %v, want %v", tt.values, got, tt.want)
+		}
+	}
+}
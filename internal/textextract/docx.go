@@ -0,0 +1,92 @@
+package textextract
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// docxBodyParts части документа Word, из которых берется текст, в порядке вывода
+var docxBodyParts = []string{"word/document.xml", "word/footnotes.xml", "word/endnotes.xml"}
+
+// maxDOCXPartSize ограничивает размер распакованной части документа
+const maxDOCXPartSize = 64 << 20
+
+// extractDOCX читает текст абзацев из XML-частей документа Word
+func extractDOCX(data []byte) (string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", fmt.Errorf("failed to open docx: %w", err)
+	}
+
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, file := range archive.File {
+		files[file.Name] = file
+	}
+	if files[docxBodyParts[0]] == nil {
+		return "", fmt.Errorf("failed to open docx: %s not found", docxBodyParts[0])
+	}
+
+	var b strings.Builder
+	for _, name := range docxBodyParts {
+		file := files[name]
+		if file == nil {
+			continue
+		}
+		if err := readDOCXPart(file, &b); err != nil {
+			return "", err
+		}
+		b.WriteString("\n")
+	}
+
+	return b.String(), nil
+}
+
+// readDOCXPart пишет текст одной части документа: w:t - текст, w:tab - табуляция,
+// w:br и w:cr - перенос строки, конец w:p - конец абзаца. Удаленный
+// при рецензировании текст (w:delText) пропускается
+func readDOCXPart(file *zip.File, b *strings.Builder) error {
+	r, err := file.Open()
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", file.Name, err)
+	}
+	defer r.Close()
+
+	decoder := xml.NewDecoder(io.LimitReader(r, maxDOCXPartSize))
+	inText := false
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", file.Name, err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				b.WriteString("\t")
+			case "br", "cr":
+				b.WriteString("\n")
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				b.WriteString("\n")
+			}
+		case xml.CharData:
+			if inText {
+				b.Write(t)
+			}
+		}
+	}
+}
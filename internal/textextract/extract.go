@@ -0,0 +1,145 @@
+// Package textextract извлекает простой текст из документов (PDF, DOCX, TXT)
+// без внешних зависимостей
+package textextract
+
+import (
+	"errors"
+	"mime"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxTextLength ограничивает длину извлеченного текста в байтах
+const MaxTextLength = 1 << 20
+
+const (
+	ContentTypePDF  = "application/pdf"
+	ContentTypeDOCX = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+	ContentTypeText = "text/plain"
+)
+
+var (
+	// ErrUnsupportedFormat возвращается для форматов, из которых текст не извлекается
+	ErrUnsupportedFormat = errors.New("unsupported document format")
+	// ErrNoText возвращается, если в документе не нашлось текста (например, скан)
+	ErrNoText = errors.New("document contains no text")
+)
+
+// Supported сообщает, извлекается ли текст из документов этого MIME-типа
+func Supported(contentType string) bool {
+	switch mediaType(contentType) {
+	case ContentTypePDF, ContentTypeDOCX, ContentTypeText:
+		return true
+	}
+	return false
+}
+
+// Extract возвращает текст документа с нормализованными пробелами и переносами строк
+func Extract(contentType string, data []byte) (string, error) {
+	var text string
+	var err error
+
+	switch mediaType(contentType) {
+	case ContentTypePDF:
+		text, err = extractPDF(data)
+	case ContentTypeDOCX:
+		text, err = extractDOCX(data)
+	case ContentTypeText:
+		text = decodePlainText(data)
+	default:
+		return "", ErrUnsupportedFormat
+	}
+	if err != nil {
+		return "", err
+	}
+
+	text = normalize(text)
+	if text == "" {
+		return "", ErrNoText
+	}
+	return text, nil
+}
+
+func mediaType(contentType string) string {
+	if parsed, _, err := mime.ParseMediaType(contentType); err == nil {
+		return parsed
+	}
+	return contentType
+}
+
+// normalize схлопывает пробелы внутри строк, убирает пустые строки подряд
+// и обрезает текст до MaxTextLength
+func normalize(text string) string {
+	var b strings.Builder
+	blank := 0
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.Join(strings.FieldsFunc(line, unicode.IsSpace), " ")
+		if line == "" {
+			blank++
+			continue
+		}
+		if b.Len() > 0 {
+			if blank > 0 {
+				b.WriteString("\n\n")
+			} else {
+				b.WriteString("\n")
+			}
+		}
+		blank = 0
+		b.WriteString(line)
+		if b.Len() >= MaxTextLength {
+			break
+		}
+	}
+
+	result := b.String()
+	if len(result) > MaxTextLength {
+		result = result[:MaxTextLength]
+		for !utf8.ValidString(result) {
+			result = result[:len(result)-1]
+		}
+	}
+	return result
+}
+
+// decodePlainText декодирует текстовый файл: UTF-8 (в том числе с BOM),
+// а при недопустимых последовательностях - Windows-1251
+func decodePlainText(data []byte) string {
+	text := strings.TrimPrefix(string(data), "\uFEFF")
+	if utf8.ValidString(text) {
+		return text
+	}
+
+	runes := make([]rune, len(data))
+	for i, c := range data {
+		runes[i] = decodeWindows1251(c)
+	}
+	return string(runes)
+}
+
+// decodeWindows1251 переводит байт кодировки Windows-1251 в символ Unicode.
+// Из верхней половины таблицы поддерживаются кириллица и основные знаки
+func decodeWindows1251(c byte) rune {
+	switch {
+	case c < 0x80:
+		return rune(c)
+	case c >= 0xC0:
+		return rune(c) - 0xC0 + 'А'
+	case c == 0xA8:
+		return 'Ё'
+	case c == 0xB8:
+		return 'ё'
+	case c == 0xB9:
+		return '№'
+	case c == 0x96:
+		return '–'
+	case c == 0x97:
+		return '—'
+	case c == 0xAB:
+		return '«'
+	case c == 0xBB:
+		return '»'
+	}
+	return unicode.ReplacementChar
+}
@@ -0,0 +1,531 @@
+package textextract
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/zlib"
+	"encoding/ascii85"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// Ограничения разбора одного документа. Без них небольшой поддельный файл
+// (вложенные формы, многократные ссылки на сжатый поток) разбирается минутами
+const (
+	// maxDecodedSize ограничивает общий размер распакованных потоков документа
+	maxDecodedSize = 64 << 20
+	// maxPDFTokens ограничивает общее число прочитанных значений PDF
+	maxPDFTokens = 4 << 20
+	// maxPDFObjects ограничивает число объектов документа
+	maxPDFObjects = 1 << 16
+	// maxPDFNesting ограничивает вложенность массивов и словарей
+	maxPDFNesting = 32
+	// maxFormDepth ограничивает вложенность форм (XObject), чтобы не зациклиться
+	maxFormDepth = 8
+	// maxPDFTextSize ограничивает объем текста до нормализации: дальше он все равно обрезается
+	maxPDFTextSize = 4 * MaxTextLength
+)
+
+var (
+	// ErrEncryptedPDF возвращается для зашифрованных PDF: их текст без пароля не прочитать
+	ErrEncryptedPDF = errors.New("encrypted pdf is not supported")
+	// ErrPDFTooComplex возвращается, если разбор документа превысил ограничения
+	ErrPDFTooComplex = errors.New("pdf is too complex")
+)
+
+var pdfObjectHeader = regexp.MustCompile(`(\d+)\s+(\d+)\s+obj\b`)
+
+// pdfDocument хранит косвенные объекты файла. Перекрестные ссылки (xref) не читаются:
+// объекты находятся сканированием, поэтому разбираются и файлы с поврежденной таблицей
+type pdfDocument struct {
+	objects    map[int]any
+	fonts      map[pdfRef]*pdfFont
+	endstreams []int
+	budget     pdfBudget
+}
+
+// pdfBudget - оставшийся запас работы над документом. После первого превышения
+// разбор останавливается, а документ считается слишком сложным
+type pdfBudget struct {
+	tokens   int
+	decoded  int
+	exceeded bool
+}
+
+func (b *pdfBudget) takeToken() bool {
+	if b.tokens <= 0 {
+		b.exceeded = true
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+func (b *pdfBudget) takeDecoded(size int) bool {
+	if size > b.decoded {
+		b.exceeded = true
+		return false
+	}
+	b.decoded -= size
+	return true
+}
+
+// lexer возвращает лексер, расходующий запас документа
+func (d *pdfDocument) lexer(data []byte, pos int) *pdfLexer {
+	return &pdfLexer{data: data, pos: pos, budget: &d.budget}
+}
+
+// extractPDF извлекает текст страниц PDF в порядке дерева страниц
+func extractPDF(data []byte) (string, error) {
+	if !bytes.HasPrefix(bytes.TrimLeft(data, " \r\n\t"), []byte("%PDF")) {
+		return "", fmt.Errorf("failed to parse pdf: missing header")
+	}
+
+	doc := &pdfDocument{
+		objects: map[int]any{},
+		fonts:   map[pdfRef]*pdfFont{},
+		budget:  pdfBudget{tokens: maxPDFTokens, decoded: maxDecodedSize},
+	}
+	doc.scanObjects(data)
+	doc.expandObjectStreams()
+	if doc.budget.exceeded {
+		return "", ErrPDFTooComplex
+	}
+
+	if bytes.Contains(data, []byte("/Encrypt")) {
+		for _, object := range doc.objects {
+			if dict, ok := object.(pdfDict); ok && dict["Filter"] == pdfName("Standard") && dict["O"] != nil {
+				return "", ErrEncryptedPDF
+			}
+		}
+	}
+
+	var out bytes.Buffer
+	for _, page := range doc.pages() {
+		if out.Len() >= maxPDFTextSize {
+			break
+		}
+		doc.writePage(&out, page)
+		out.WriteString("\n\n")
+	}
+	if doc.budget.exceeded {
+		return "", ErrPDFTooComplex
+	}
+	return out.String(), nil
+}
+
+// scanObjects находит все объекты "N G obj ... endobj". Если объект встречается
+// несколько раз (инкрементальные обновления), действует последнее определение
+func (d *pdfDocument) scanObjects(data []byte) {
+	matches := pdfObjectHeader.FindAllSubmatchIndex(data, maxPDFObjects+1)
+	if len(matches) > maxPDFObjects {
+		d.budget.exceeded = true
+		return
+	}
+
+	// Концы потоков ищутся один раз: поиск от каждого заголовка до endstream
+	// на файле без endstream занимает квадратичное время
+	for pos := 0; ; {
+		i := bytes.Index(data[pos:], []byte("endstream"))
+		if i < 0 {
+			break
+		}
+		d.endstreams = append(d.endstreams, pos+i)
+		pos += i + len("endstream")
+	}
+
+	for _, match := range matches {
+		num, err := strconv.Atoi(string(data[match[2]:match[3]]))
+		if err != nil {
+			continue
+		}
+
+		lexer := d.lexer(data, match[1])
+		value, err := lexer.value()
+		if err != nil {
+			continue
+		}
+
+		if dict, ok := value.(pdfDict); ok {
+			saved := lexer.pos
+			if keyword, _ := lexer.value(); keyword == pdfKeyword("stream") {
+				value = pdfStream{dict: dict, raw: d.streamData(data, lexer.pos, dict)}
+			} else {
+				lexer.pos = saved
+			}
+		}
+		d.objects[num] = value
+	}
+}
+
+// streamData возвращает данные потока, начинающиеся после ключевого слова stream.
+// Длина берется из /Length, если она задана числом и сходится с endstream,
+// иначе данные заканчиваются на ближайшем endstream
+func (d *pdfDocument) streamData(data []byte, pos int, dict pdfDict) []byte {
+	if pos < len(data) && data[pos] == '\r' {
+		pos++
+	}
+	if pos < len(data) && data[pos] == '\n' {
+		pos++
+	}
+
+	if length, ok := dict["Length"].(float64); ok {
+		end := pos + int(length)
+		if end >= pos && end <= len(data) &&
+			bytes.HasPrefix(bytes.TrimLeft(data[end:], " \r\n\t"), []byte("endstream")) {
+			return data[pos:end]
+		}
+	}
+
+	next := sort.SearchInts(d.endstreams, pos)
+	if next == len(d.endstreams) {
+		return data[pos:]
+	}
+	raw := data[pos:d.endstreams[next]]
+	raw = bytes.TrimSuffix(raw, []byte("\n"))
+	raw = bytes.TrimSuffix(raw, []byte("\r"))
+	return raw
+}
+
+// expandObjectStreams добавляет объекты, упакованные в потоки /Type /ObjStm
+func (d *pdfDocument) expandObjectStreams() {
+	var streams []pdfStream
+	for _, object := range d.objects {
+		if stream, ok := object.(pdfStream); ok && stream.dict["Type"] == pdfName("ObjStm") {
+			streams = append(streams, stream)
+		}
+	}
+
+	for _, stream := range streams {
+		data, err := d.decodeStream(stream)
+		if err != nil {
+			continue
+		}
+		count, _ := d.resolve(stream.dict["N"]).(float64)
+		first, _ := d.resolve(stream.dict["First"]).(float64)
+
+		header := d.lexer(data, 0)
+		for i := 0; i < int(count); i++ {
+			num, err1 := header.value()
+			offset, err2 := header.value()
+			if err1 != nil || err2 != nil {
+				break
+			}
+			n, ok1 := num.(float64)
+			o, ok2 := offset.(float64)
+			if !ok1 || !ok2 {
+				break
+			}
+			if _, exists := d.objects[int(n)]; exists {
+				continue
+			}
+			if len(d.objects) >= maxPDFObjects {
+				d.budget.exceeded = true
+				return
+			}
+
+			lexer := d.lexer(data, int(first)+int(o))
+			if lexer.pos < 0 || lexer.pos >= len(data) {
+				continue
+			}
+			if value, err := lexer.value(); err == nil {
+				d.objects[int(n)] = value
+			}
+		}
+	}
+}
+
+// resolve заменяет косвенную ссылку объектом
+func (d *pdfDocument) resolve(value any) any {
+	for i := 0; i < 16; i++ {
+		ref, ok := value.(pdfRef)
+		if !ok {
+			return value
+		}
+		value = d.objects[ref.num]
+	}
+	return nil
+}
+
+func (d *pdfDocument) dict(value any) pdfDict {
+	switch v := d.resolve(value).(type) {
+	case pdfDict:
+		return v
+	case pdfStream:
+		return v.dict
+	}
+	return nil
+}
+
+func (d *pdfDocument) array(value any) pdfArray {
+	switch v := d.resolve(value).(type) {
+	case pdfArray:
+		return v
+	case nil:
+		return nil
+	default:
+		return pdfArray{v}
+	}
+}
+
+// pages возвращает страницы в порядке дерева страниц каталога. Если дерево
+// не найдено, страницы берутся в порядке номеров объектов
+func (d *pdfDocument) pages() []pdfDict {
+	var pages []pdfDict
+	visited := map[int]bool{}
+
+	var walk func(node any)
+	walk = func(node any) {
+		if ref, ok := node.(pdfRef); ok {
+			if visited[ref.num] {
+				return
+			}
+			visited[ref.num] = true
+		}
+		dict := d.dict(node)
+		if dict == nil {
+			return
+		}
+		if dict["Type"] == pdfName("Page") || (dict["Kids"] == nil && dict["Contents"] != nil) {
+			pages = append(pages, dict)
+			return
+		}
+		for _, kid := range d.array(dict["Kids"]) {
+			walk(kid)
+		}
+	}
+
+	nums := make([]int, 0, len(d.objects))
+	for num := range d.objects {
+		nums = append(nums, num)
+	}
+	sort.Ints(nums)
+
+	for _, num := range nums {
+		if dict := d.dict(d.objects[num]); dict["Type"] == pdfName("Catalog") {
+			walk(dict["Pages"])
+			break
+		}
+	}
+	if len(pages) > 0 {
+		return pages
+	}
+
+	for _, num := range nums {
+		if dict := d.dict(d.objects[num]); dict["Type"] == pdfName("Page") {
+			pages = append(pages, dict)
+		}
+	}
+	return pages
+}
+
+// inherited ищет атрибут страницы, поднимаясь по /Parent
+func (d *pdfDocument) inherited(page pdfDict, key pdfName) any {
+	for i := 0; page != nil && i < 32; i++ {
+		if value, ok := page[key]; ok {
+			return value
+		}
+		page = d.dict(page["Parent"])
+	}
+	return nil
+}
+
+// writePage пишет текст страницы
+func (d *pdfDocument) writePage(out *bytes.Buffer, page pdfDict) {
+	var content []byte
+	for _, part := range d.array(page["Contents"]) {
+		stream, ok := d.resolve(part).(pdfStream)
+		if !ok {
+			continue
+		}
+		data, err := d.decodeStream(stream)
+		if err != nil {
+			continue
+		}
+		content = append(content, data...)
+		content = append(content, '\n')
+	}
+
+	resources := d.dict(d.inherited(page, "Resources"))
+	d.interpret(out, content, resources, 0)
+}
+
+// decodeStream применяет фильтры потока. Поддерживаются FlateDecode, ASCIIHexDecode
+// и ASCII85Decode; потоки с другими фильтрами (изображения) пропускаются.
+// Каждое декодирование расходует запас распакованных данных документа
+func (d *pdfDocument) decodeStream(stream pdfStream) ([]byte, error) {
+	data := stream.raw
+	for _, filter := range d.array(stream.dict["Filter"]) {
+		var err error
+		switch d.resolve(filter) {
+		case pdfName("FlateDecode"), pdfName("Fl"):
+			data, err = inflate(data, d.budget.decoded)
+		case pdfName("ASCIIHexDecode"), pdfName("AHx"):
+			lexer := &pdfLexer{data: data}
+			data = lexer.hexString()
+		case pdfName("ASCII85Decode"), pdfName("A85"):
+			data, err = decodeASCII85(data)
+		default:
+			return nil, fmt.Errorf("unsupported pdf filter %v", filter)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !d.budget.takeDecoded(len(data)) {
+		return nil, ErrPDFTooComplex
+	}
+	return data, nil
+}
+
+// inflate распаковывает zlib-поток, читая не больше limit+1 байт: превышение limit
+// видно по длине результата. Поврежденный конец потока не считается ошибкой:
+// возвращается все, что удалось распаковать
+func inflate(data []byte, limit int) ([]byte, error) {
+	var r io.ReadCloser
+	r, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		r = flate.NewReader(bytes.NewReader(data))
+	}
+	defer r.Close()
+
+	out, err := io.ReadAll(io.LimitReader(r, int64(limit)+1))
+	if err != nil && len(out) == 0 {
+		return nil, fmt.Errorf("failed to inflate pdf stream: %w", err)
+	}
+	return out, nil
+}
+
+func decodeASCII85(data []byte) ([]byte, error) {
+	data = bytes.TrimPrefix(bytes.TrimSpace(data), []byte("<~"))
+	if end := bytes.Index(data, []byte("~>")); end >= 0 {
+		data = data[:end]
+	}
+	out, err := io.ReadAll(ascii85.NewDecoder(bytes.NewReader(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode pdf stream: %w", err)
+	}
+	return out, nil
+}
+
+// interpret выполняет текстовые операторы потока содержимого. Переходы на новую
+// строку (Td, TD, T*, Tm, ', ") превращаются в переносы, большие отступы в TJ - в пробелы
+func (d *pdfDocument) interpret(out *bytes.Buffer, content []byte, resources pdfDict, depth int) {
+	lexer := d.lexer(content, 0)
+	var operands []any
+	var font *pdfFont
+	var lineY float64
+	hasLine := false
+
+	newLine := func() {
+		if out.Len() > 0 && out.Bytes()[out.Len()-1] != '\n' {
+			out.WriteByte('\n')
+		}
+	}
+	space := func() {
+		if out.Len() > 0 && out.Bytes()[out.Len()-1] != ' ' && out.Bytes()[out.Len()-1] != '\n' {
+			out.WriteByte(' ')
+		}
+	}
+	show := func(value any) {
+		if s, ok := value.(pdfString); ok && out.Len() < maxPDFTextSize {
+			out.WriteString(font.decode(s))
+		}
+	}
+	number := func(i int) float64 {
+		if i < 0 || i >= len(operands) {
+			return 0
+		}
+		n, _ := operands[i].(float64)
+		return n
+	}
+
+	for lexer.pos < len(lexer.data) {
+		value, err := lexer.value()
+		if err != nil {
+			if lexer.pos >= len(lexer.data) {
+				break
+			}
+			operands = operands[:0]
+			continue
+		}
+
+		operator, ok := value.(pdfKeyword)
+		if !ok {
+			operands = append(operands, value)
+			continue
+		}
+
+		switch operator {
+		case "ET":
+			space()
+		case "Tf":
+			if len(operands) >= 2 {
+				if name, ok := operands[0].(pdfName); ok {
+					font = d.font(d.dict(resources["Font"])[name])
+				}
+			}
+		case "Td", "TD":
+			if number(1) != 0 {
+				newLine()
+			} else if number(0) != 0 {
+				space()
+			}
+		case "Tm":
+			y := number(5)
+			if hasLine && y != lineY {
+				newLine()
+			} else {
+				space()
+			}
+			lineY, hasLine = y, true
+		case "T*":
+			newLine()
+		case "Tj":
+			if len(operands) > 0 {
+				show(operands[len(operands)-1])
+			}
+		case "'", "\"":
+			newLine()
+			if len(operands) > 0 {
+				show(operands[len(operands)-1])
+			}
+		case "TJ":
+			if len(operands) == 0 {
+				break
+			}
+			array, _ := operands[len(operands)-1].(pdfArray)
+			for _, item := range array {
+				if adjustment, ok := item.(float64); ok {
+					// Смещение задается в тысячных долях кегля: заметный сдвиг влево - пробел между словами
+					if adjustment < -180 {
+						space()
+					}
+					continue
+				}
+				show(item)
+			}
+		case "Do":
+			if len(operands) > 0 && depth < maxFormDepth {
+				name, _ := operands[0].(pdfName)
+				form, ok := d.resolve(d.dict(resources["XObject"])[name]).(pdfStream)
+				if ok && form.dict["Subtype"] == pdfName("Form") {
+					formResources := d.dict(form.dict["Resources"])
+					if formResources == nil {
+						formResources = resources
+					}
+					if data, err := d.decodeStream(form); err == nil {
+						d.interpret(out, data, formResources, depth+1)
+					}
+				}
+			}
+		case "ID":
+			lexer.skipInlineImage()
+		}
+		operands = operands[:0]
+	}
+}
@@ -0,0 +1,351 @@
+package textextract
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// maxCodespaces ограничивает число диапазонов codespacerange: длина каждого кода
+// строки определяется перебором диапазонов, а в настоящих CMap их единицы
+const maxCodespaces = 64
+
+// pdfFont переводит коды символов строки PDF в Unicode. Приоритет у таблицы
+// ToUnicode; без нее простые шрифты декодируются по /Encoding и /Differences,
+// а составные (Type0) без ToUnicode не декодируются
+type pdfFont struct {
+	composite  bool
+	codespaces []pdfCodespace
+	chars      map[uint32]string
+	ranges     []pdfCharRange
+	encoding   *[256]rune
+}
+
+// pdfCodespace диапазон допустимых кодов заданной длины в байтах
+type pdfCodespace struct {
+	length int
+	lo, hi uint32
+}
+
+// pdfCharRange диапазон bfrange: коды lo..hi переходят в dst со сдвигом
+// последнего символа либо в отдельные строки из values. Диапазоны шрифта
+// упорядочены по lo, чтобы код искался двоичным поиском
+type pdfCharRange struct {
+	lo, hi uint32
+	dst    []rune
+	values []string
+}
+
+// font возвращает декодер шрифта по ссылке из ресурсов страницы
+func (d *pdfDocument) font(value any) *pdfFont {
+	ref, isRef := value.(pdfRef)
+	if isRef {
+		if font, ok := d.fonts[ref]; ok {
+			return font
+		}
+	}
+
+	dict := d.dict(value)
+	if dict == nil {
+		return nil
+	}
+
+	font := &pdfFont{composite: dict["Subtype"] == pdfName("Type0")}
+	if stream, ok := d.resolve(dict["ToUnicode"]).(pdfStream); ok {
+		if data, err := d.decodeStream(stream); err == nil {
+			font.parseCMap(d.lexer(data, 0))
+		}
+	}
+	if !font.composite {
+		font.encoding = d.simpleEncoding(d.resolve(dict["Encoding"]))
+	}
+
+	if isRef {
+		d.fonts[ref] = font
+	}
+	return font
+}
+
+// simpleEncoding строит таблицу кодировки простого шрифта. За основу всегда берется
+// WinAnsiEncoding: в ASCII-части стандартные кодировки совпадают
+func (d *pdfDocument) simpleEncoding(value any) *[256]rune {
+	encoding := winAnsiEncoding()
+
+	dict, ok := value.(pdfDict)
+	if !ok {
+		return encoding
+	}
+
+	code := 0
+	for _, item := range d.array(dict["Differences"]) {
+		switch v := item.(type) {
+		case float64:
+			code = int(v)
+		case pdfName:
+			if code >= 0 && code < 256 {
+				if r, ok := glyphRune(string(v)); ok {
+					encoding[code] = r
+				}
+			}
+			code++
+		}
+	}
+	return encoding
+}
+
+// decode переводит строку PDF в текст
+func (f *pdfFont) decode(s []byte) string {
+	if f == nil {
+		return latin1(s)
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		length := f.codeLength(s[i:])
+		code := uint32(0)
+		for _, c := range s[i : i+length] {
+			code = code<<8 | uint32(c)
+		}
+		i += length
+
+		if text, ok := f.lookup(code); ok {
+			b.WriteString(text)
+			continue
+		}
+		if f.encoding != nil && length == 1 {
+			if r := f.encoding[code]; r != 0 {
+				b.WriteRune(r)
+			}
+		}
+	}
+	return b.String()
+}
+
+// codeLength определяет длину очередного кода по диапазонам codespacerange
+func (f *pdfFont) codeLength(s []byte) int {
+	for _, space := range f.codespaces {
+		if space.length > len(s) {
+			continue
+		}
+		code := uint32(0)
+		for _, c := range s[:space.length] {
+			code = code<<8 | uint32(c)
+		}
+		if code >= space.lo && code <= space.hi {
+			return space.length
+		}
+	}
+	if f.composite && len(s) >= 2 {
+		return 2
+	}
+	return 1
+}
+
+func (f *pdfFont) lookup(code uint32) (string, bool) {
+	if text, ok := f.chars[code]; ok {
+		return text, true
+	}
+	// Последний диапазон, начинающийся не позже кода
+	i := sort.Search(len(f.ranges), func(i int) bool { return f.ranges[i].lo > code }) - 1
+	if i >= 0 && code <= f.ranges[i].hi {
+		r := f.ranges[i]
+		offset := int(code - r.lo)
+		if r.values != nil {
+			if offset < len(r.values) {
+				return r.values[offset], true
+			}
+			return "", false
+		}
+		if len(r.dst) == 0 {
+			return "", false
+		}
+		dst := append([]rune(nil), r.dst...)
+		dst[len(dst)-1] += rune(offset)
+		return string(dst), true
+	}
+	return "", false
+}
+
+// parseCMap читает таблицу ToUnicode: codespacerange, bfchar и bfrange
+func (f *pdfFont) parseCMap(lexer *pdfLexer) {
+	f.chars = map[uint32]string{}
+	var operands []any
+
+	for lexer.pos < len(lexer.data) {
+		value, err := lexer.value()
+		if err != nil {
+			if lexer.pos >= len(lexer.data) {
+				break
+			}
+			continue
+		}
+		keyword, ok := value.(pdfKeyword)
+		if !ok {
+			operands = append(operands, value)
+			continue
+		}
+
+		switch keyword {
+		case "endcodespacerange":
+			for i := 0; i+1 < len(operands); i += 2 {
+				lo, ok1 := operands[i].(pdfString)
+				hi, ok2 := operands[i+1].(pdfString)
+				if ok1 && ok2 && len(lo) > 0 && len(lo) <= 4 && len(f.codespaces) < maxCodespaces {
+					f.codespaces = append(f.codespaces, pdfCodespace{length: len(lo), lo: bytesCode(lo), hi: bytesCode(hi)})
+				}
+			}
+		case "endbfchar":
+			for i := 0; i+1 < len(operands); i += 2 {
+				src, ok1 := operands[i].(pdfString)
+				dst, ok2 := operands[i+1].(pdfString)
+				if ok1 && ok2 {
+					f.chars[bytesCode(src)] = decodeUTF16(dst)
+				}
+			}
+		case "endbfrange":
+			for i := 0; i+2 < len(operands); i += 3 {
+				lo, ok1 := operands[i].(pdfString)
+				hi, ok2 := operands[i+1].(pdfString)
+				if !ok1 || !ok2 {
+					continue
+				}
+				r := pdfCharRange{lo: bytesCode(lo), hi: bytesCode(hi)}
+				switch dst := operands[i+2].(type) {
+				case pdfString:
+					r.dst = []rune(decodeUTF16(dst))
+				case pdfArray:
+					r.values = []string{}
+					for _, item := range dst {
+						s, _ := item.(pdfString)
+						r.values = append(r.values, decodeUTF16(s))
+					}
+				}
+				f.ranges = append(f.ranges, r)
+			}
+		}
+		operands = operands[:0]
+	}
+
+	// При пересекающихся диапазонах действует тот, что начинается позже
+	sort.SliceStable(f.ranges, func(i, j int) bool { return f.ranges[i].lo < f.ranges[j].lo })
+}
+
+func bytesCode(b []byte) uint32 {
+	code := uint32(0)
+	for _, c := range b {
+		code = code<<8 | uint32(c)
+	}
+	return code
+}
+
+// decodeUTF16 декодирует строку UTF-16BE из CMap
+func decodeUTF16(b []byte) string {
+	units := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		units = append(units, uint16(b[i])<<8|uint16(b[i+1]))
+	}
+	if len(b)%2 == 1 {
+		units = append(units, uint16(b[len(b)-1]))
+	}
+	return string(utf16.Decode(units))
+}
+
+func latin1(s []byte) string {
+	encoding := winAnsiEncoding()
+	runes := make([]rune, 0, len(s))
+	for _, c := range s {
+		if r := encoding[c]; r != 0 {
+			runes = append(runes, r)
+		}
+	}
+	return string(runes)
+}
+
+// winAnsiSpecials символы WinAnsiEncoding в диапазоне 0x80-0x9F
+var winAnsiSpecials = map[byte]rune{
+	0x80: '€', 0x82: '‚', 0x83: 'ƒ', 0x84: '„', 0x85: '…', 0x86: '†', 0x87: '‡',
+	0x88: 'ˆ', 0x89: '‰', 0x8A: 'Š', 0x8B: '‹', 0x8C: 'Œ', 0x8E: 'Ž',
+	0x91: '‘', 0x92: '’', 0x93: '“', 0x94: '”', 0x95: '•', 0x96: '–', 0x97: '—',
+	0x98: '˜', 0x99: '™', 0x9A: 'š', 0x9B: '›', 0x9C: 'œ', 0x9E: 'ž', 0x9F: 'Ÿ',
+}
+
+// winAnsiEncoding возвращает новую таблицу WinAnsiEncoding (она изменяется /Differences)
+func winAnsiEncoding() *[256]rune {
+	var encoding [256]rune
+	for c := 0x20; c < 256; c++ {
+		encoding[c] = rune(c)
+	}
+	for c := 0x7F; c < 0xA0; c++ {
+		encoding[c] = 0
+	}
+	for c, r := range winAnsiSpecials {
+		encoding[c] = r
+	}
+	encoding['\t'], encoding['\n'], encoding['\r'] = ' ', ' ', ' '
+	return &encoding
+}
+
+// glyphNames распространенные имена глифов Adobe, не сводящиеся к одной букве или uniXXXX
+var glyphNames = map[string]rune{
+	"space": ' ', "exclam": '!', "quotedbl": '"', "numbersign": '#', "dollar": '$',
+	"percent": '%', "ampersand": '&', "quotesingle": '\'', "quoteright": '’', "quoteleft": '‘',
+	"parenleft": '(', "parenright": ')', "asterisk": '*', "plus": '+', "comma": ',',
+	"hyphen": '-', "minus": '−', "period": '.', "slash": '/', "colon": ':', "semicolon": ';',
+	"less": '<', "equal": '=', "greater": '>', "question": '?', "at": '@',
+	"bracketleft": '[', "backslash": '\\', "bracketright": ']', "underscore": '_',
+	"braceleft": '{', "bar": '|', "braceright": '}', "asciitilde": '~',
+	"zero": '0', "one": '1', "two": '2', "three": '3', "four": '4',
+	"five": '5', "six": '6', "seven": '7', "eight": '8', "nine": '9',
+	"endash": '–', "emdash": '—', "bullet": '•', "ellipsis": '…',
+	"quotedblleft": '“', "quotedblright": '”', "guillemotleft": '«', "guillemotright": '»',
+	"numero": '№', "afii61352": '№', "copyright": '©', "registered": '®', "degree": '°',
+	"fi": 'ﬁ', "fl": 'ﬂ',
+}
+
+// glyphRune переводит имя глифа в символ: uniXXXX, uXXXX, однобуквенные имена,
+// кириллица afii10017-afii10097 и распространенные знаки
+func glyphRune(name string) (rune, bool) {
+	if r, ok := glyphNames[name]; ok {
+		return r, true
+	}
+	if len(name) == 1 {
+		return rune(name[0]), true
+	}
+	if strings.HasPrefix(name, "uni") && len(name) >= 7 {
+		if code, err := strconv.ParseUint(name[3:7], 16, 32); err == nil {
+			return rune(code), true
+		}
+	}
+	if strings.HasPrefix(name, "u") && len(name) >= 5 && len(name) <= 7 {
+		if code, err := strconv.ParseUint(name[1:], 16, 32); err == nil {
+			return rune(code), true
+		}
+	}
+	if strings.HasPrefix(name, "afii") {
+		if code, err := strconv.Atoi(name[4:]); err == nil {
+			return cyrillicGlyph(code)
+		}
+	}
+	return 0, false
+}
+
+// cyrillicGlyph переводит номера глифов afii100xx в кириллицу. Ё и ё стоят
+// в нумерации на месте после Е и е, остальные буквы идут по алфавиту
+func cyrillicGlyph(code int) (rune, bool) {
+	switch {
+	case code == 10023:
+		return 'Ё', true
+	case code == 10071:
+		return 'ё', true
+	case code >= 10017 && code <= 10022:
+		return 'А' + rune(code-10017), true
+	case code >= 10024 && code <= 10049:
+		return 'Ж' + rune(code-10024), true
+	case code >= 10065 && code <= 10070:
+		return 'а' + rune(code-10065), true
+	case code >= 10072 && code <= 10097:
+		return 'ж' + rune(code-10072), true
+	}
+	return 0, false
+}
@@ -0,0 +1,339 @@
+package textextract
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+)
+
+// Значения объектов PDF после разбора
+type (
+	pdfName    string
+	pdfKeyword string
+	pdfString  []byte
+	pdfArray   []any
+	pdfDict    map[pdfName]any
+	pdfRef     struct{ num, gen int }
+	pdfStream  struct {
+		dict pdfDict
+		raw  []byte
+	}
+)
+
+var errPDFSyntax = errors.New("pdf syntax error")
+
+// pdfLexer разбирает синтаксис PDF: числа, имена, строки, массивы, словари,
+// ссылки и ключевые слова (в потоках содержимого это операторы). Если задан budget,
+// каждое прочитанное значение расходует его
+type pdfLexer struct {
+	data   []byte
+	pos    int
+	depth  int
+	budget *pdfBudget
+}
+
+func isPDFSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\f' || c == 0
+}
+
+func isPDFDelimiter(c byte) bool {
+	switch c {
+	case '(', ')', '<', '>', '[', ']', '{', '}', '/', '%':
+		return true
+	}
+	return false
+}
+
+// skipSpace пропускает пробелы и комментарии
+func (l *pdfLexer) skipSpace() {
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		if isPDFSpace(c) {
+			l.pos++
+			continue
+		}
+		if c == '%' {
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+			continue
+		}
+		return
+	}
+}
+
+// regular читает последовательность обычных символов до пробела или разделителя
+func (l *pdfLexer) regular() []byte {
+	start := l.pos
+	for l.pos < len(l.data) && !isPDFSpace(l.data[l.pos]) && !isPDFDelimiter(l.data[l.pos]) {
+		l.pos++
+	}
+	return l.data[start:l.pos]
+}
+
+// value читает следующее значение. Закрывающие скобки массивов и словарей
+// возвращаются как ключевые слова "]" и ">>"
+func (l *pdfLexer) value() (any, error) {
+	if l.budget != nil && !l.budget.takeToken() {
+		// Разбор прекращается целиком: циклы чтения останавливаются на конце данных
+		l.pos = len(l.data)
+		return nil, ErrPDFTooComplex
+	}
+
+	l.skipSpace()
+	if l.pos >= len(l.data) {
+		return nil, errPDFSyntax
+	}
+
+	c := l.data[l.pos]
+	switch {
+	case c == '/':
+		l.pos++
+		return pdfName(decodeNameEscapes(l.regular())), nil
+	case c == '(':
+		l.pos++
+		return l.literalString(), nil
+	case c == '<' && l.pos+1 < len(l.data) && l.data[l.pos+1] == '<':
+		l.pos += 2
+		return l.dict()
+	case c == '<':
+		l.pos++
+		return l.hexString(), nil
+	case c == '>' && l.pos+1 < len(l.data) && l.data[l.pos+1] == '>':
+		l.pos += 2
+		return pdfKeyword(">>"), nil
+	case c == '[':
+		l.pos++
+		return l.array()
+	case c == ']', c == '{', c == '}', c == ')', c == '>':
+		l.pos++
+		return pdfKeyword([]byte{c}), nil
+	}
+
+	token := l.regular()
+	if len(token) == 0 {
+		l.pos++
+		return nil, errPDFSyntax
+	}
+	if number, err := strconv.ParseFloat(string(token), 64); err == nil {
+		return l.numberOrRef(token, number), nil
+	}
+
+	switch string(token) {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+	return pdfKeyword(token), nil
+}
+
+// numberOrRef распознает косвенную ссылку "num gen R" после целого числа
+func (l *pdfLexer) numberOrRef(token []byte, number float64) any {
+	num, err := strconv.Atoi(string(token))
+	if err != nil {
+		return number
+	}
+
+	saved := l.pos
+	l.skipSpace()
+	gen, err := strconv.Atoi(string(l.regular()))
+	if err == nil {
+		l.skipSpace()
+		if l.pos < len(l.data) && l.data[l.pos] == 'R' &&
+			(l.pos+1 == len(l.data) || isPDFSpace(l.data[l.pos+1]) || isPDFDelimiter(l.data[l.pos+1])) {
+			l.pos++
+			return pdfRef{num: num, gen: gen}
+		}
+	}
+	l.pos = saved
+	return number
+}
+
+func (l *pdfLexer) array() (pdfArray, error) {
+	l.depth++
+	defer func() { l.depth-- }()
+	if l.depth > maxPDFNesting {
+		return nil, errPDFSyntax
+	}
+
+	array := pdfArray{}
+	for {
+		v, err := l.value()
+		if err != nil {
+			return array, err
+		}
+		if v == pdfKeyword("]") {
+			return array, nil
+		}
+		array = append(array, v)
+	}
+}
+
+func (l *pdfLexer) dict() (pdfDict, error) {
+	l.depth++
+	defer func() { l.depth-- }()
+	if l.depth > maxPDFNesting {
+		return nil, errPDFSyntax
+	}
+
+	dict := pdfDict{}
+	for {
+		key, err := l.value()
+		if err != nil {
+			return dict, err
+		}
+		if key == pdfKeyword(">>") {
+			return dict, nil
+		}
+		name, ok := key.(pdfName)
+		if !ok {
+			continue
+		}
+		v, err := l.value()
+		if err != nil {
+			return dict, err
+		}
+		if v == pdfKeyword(">>") {
+			return dict, nil
+		}
+		dict[name] = v
+	}
+}
+
+// literalString читает строку в круглых скобках с учетом вложенных скобок и escape-последовательностей
+func (l *pdfLexer) literalString() pdfString {
+	var b []byte
+	depth := 1
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return b
+			}
+		case '\\':
+			if l.pos >= len(l.data) {
+				return b
+			}
+			c = l.data[l.pos]
+			l.pos++
+			switch c {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '\r':
+				if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+					l.pos++
+				}
+				continue
+			case '\n':
+				continue
+			default:
+				if c >= '0' && c <= '7' {
+					value := int(c - '0')
+					for i := 0; i < 2 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; i++ {
+						value = value*8 + int(l.data[l.pos]-'0')
+						l.pos++
+					}
+					c = byte(value)
+				}
+			}
+		}
+		b = append(b, c)
+	}
+	return b
+}
+
+// hexString читает строку в угловых скобках: пары шестнадцатеричных цифр,
+// нечетная последняя цифра дополняется нулем
+func (l *pdfLexer) hexString() pdfString {
+	var b []byte
+	var digit byte
+	half := false
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		if c == '>' {
+			break
+		}
+		v, ok := hexValue(c)
+		if !ok {
+			continue
+		}
+		if half {
+			b = append(b, digit<<4|v)
+		} else {
+			digit = v
+		}
+		half = !half
+	}
+	if half {
+		b = append(b, digit<<4)
+	}
+	return b
+}
+
+// skipInlineImage пропускает данные встроенного изображения после оператора ID до EI
+func (l *pdfLexer) skipInlineImage() {
+	l.pos++
+	for l.pos+2 <= len(l.data) {
+		i := bytes.Index(l.data[l.pos:], []byte("EI"))
+		if i < 0 {
+			l.pos = len(l.data)
+			return
+		}
+		l.pos += i + 2
+		before := l.pos - 3
+		if (before < 0 || isPDFSpace(l.data[before])) && (l.pos == len(l.data) || isPDFSpace(l.data[l.pos])) {
+			return
+		}
+	}
+	l.pos = len(l.data)
+}
+
+func hexValue(c byte) (byte, bool) {
+	switch {
+	case c >= '0' && c <= '9':
+		return c - '0', true
+	case c >= 'a' && c <= 'f':
+		return c - 'a' + 10, true
+	case c >= 'A' && c <= 'F':
+		return c - 'A' + 10, true
+	}
+	return 0, false
+}
+
+// decodeNameEscapes раскрывает последовательности #xx в именах
+func decodeNameEscapes(name []byte) string {
+	if bytes.IndexByte(name, '#') < 0 {
+		return string(name)
+	}
+	var b []byte
+	for i := 0; i < len(name); i++ {
+		if name[i] == '#' && i+2 < len(name) {
+			hi, ok1 := hexValue(name[i+1])
+			lo, ok2 := hexValue(name[i+2])
+			if ok1 && ok2 {
+				b = append(b, hi<<4|lo)
+				i += 2
+				continue
+			}
+		}
+		b = append(b, name[i])
+	}
+	return string(b)
+}
@@ -0,0 +1,294 @@
+package textextract
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// buildPDF собирает PDF из объектов, нумеруя их с 1 в порядке аргументов
+func buildPDF(objects ...string) []byte {
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n")
+	for i, object := range objects {
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	b.WriteString("%%EOF\n")
+	return b.Bytes()
+}
+
+// pdfStreamObject возвращает объект-поток с указанными ключами словаря
+func pdfStreamObject(dict string, data []byte) string {
+	return fmt.Sprintf("<< %s /Length %d >>\nstream\n%s\nendstream", dict, len(data), data)
+}
+
+func deflate(data []byte) []byte {
+	var b bytes.Buffer
+	w := zlib.NewWriter(&b)
+	w.Write(data)
+	w.Close()
+	return b.Bytes()
+}
+
+// singlePagePDF - документ из одной страницы с содержимым content (объект 4)
+// и шрифтом F1 (объект 5)
+func singlePagePDF(content, font string, extra ...string) []byte {
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 4 0 R /Resources << /Font << /F1 5 0 R >> >> >>",
+		content,
+		font,
+	}
+	return buildPDF(append(objects, extra...)...)
+}
+
+const helvetica = "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>"
+
+func TestExtractPDF(t *testing.T) {
+	cmap := []byte(`/CIDInit /ProcSet findresource begin
+begincmap
+1 begincodespacerange <0000> <FFFF> endcodespacerange
+1 beginbfchar <0001> <0041> endbfchar
+2 beginbfrange <0010> <0012> <0430> <0020> <0021> [<0031> <0032>] endbfrange
+endcmap`)
+
+	tests := []struct {
+		name string
+		data []byte
+		want string
+		err  error
+	}{
+		{
+			name: "lines",
+			data: singlePagePDF(pdfStreamObject("", []byte("BT /F1 12 Tf 72 700 Td (Hello) Tj 0 -14 Td (World) Tj ET")), helvetica),
+			want: "Hello\nWorld",
+		},
+		{
+			name: "TJ spacing",
+			data: singlePagePDF(pdfStreamObject("", []byte("BT /F1 12 Tf [(Hel) -20 (lo) -300 (World)] TJ ET")), helvetica),
+			want: "Hello World",
+		},
+		{
+			name: "string escapes",
+			data: singlePagePDF(pdfStreamObject("", []byte(`BT /F1 12 Tf (a\(b\)c\101) Tj ET`)), helvetica),
+			want: "a(b)cA",
+		},
+		{
+			name: "flate content",
+			data: singlePagePDF(pdfStreamObject("/Filter /FlateDecode", deflate([]byte("BT /F1 12 Tf (Compressed) Tj ET"))), helvetica),
+			want: "Compressed",
+		},
+		{
+			name: "ToUnicode",
+			data: singlePagePDF(
+				pdfStreamObject("", []byte("BT /F1 12 Tf <0001001000110012> Tj <00200021> Tj ET")),
+				"<< /Type /Font /Subtype /Type0 /BaseFont /Test /ToUnicode 6 0 R >>",
+				pdfStreamObject("", cmap),
+			),
+			want: "Aабв12",
+		},
+		{
+			name: "form XObject",
+			data: buildPDF(
+				"<< /Type /Catalog /Pages 2 0 R >>",
+				"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+				"<< /Type /Page /Parent 2 0 R /Contents 4 0 R /Resources << /Font << /F1 6 0 R >> /XObject << /X1 5 0 R >> >> >>",
+				pdfStreamObject("", []byte("/X1 Do")),
+				pdfStreamObject("/Type /XObject /Subtype /Form", []byte("BT /F1 12 Tf (Inside form) Tj ET")),
+				helvetica,
+			),
+			want: "Inside form",
+		},
+		{
+			name: "page tree order",
+			data: buildPDF(
+				"<< /Type /Catalog /Pages 2 0 R >>",
+				"<< /Type /Pages /Kids [4 0 R 3 0 R] /Count 2 >>",
+				"<< /Type /Page /Parent 2 0 R /Contents 5 0 R >>",
+				"<< /Type /Page /Parent 2 0 R /Contents 6 0 R >>",
+				pdfStreamObject("", []byte("BT (Second) Tj ET")),
+				pdfStreamObject("", []byte("BT (First) Tj ET")),
+			),
+			want: "First\n\nSecond",
+		},
+		{
+			name: "encrypted",
+			data: buildPDF("<< /Type /Catalog >>", "<< /Filter /Standard /O (owner) /U (user) >>", "<< /Encrypt 2 0 R >>"),
+			err:  ErrEncryptedPDF,
+		},
+		{
+			name: "no text",
+			data: singlePagePDF(pdfStreamObject("", []byte("0 0 100 100 re f")), helvetica),
+			err:  ErrNoText,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Extract(ContentTypePDF, tt.data)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Extract() error = %v, want %v", err, tt.err)
+			}
+			if got != tt.want {
+				t.Errorf("Extract() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractPDFMissingHeader(t *testing.T) {
+	if _, err := Extract(ContentTypePDF, []byte("not a pdf")); err == nil {
+		t.Fatal("Extract() error = nil, want error")
+	}
+}
+
+// TestExtractPDFLimits проверяет, что поддельные документы разбираются быстро:
+// до ограничений каждый из них разбирался минутами или исчерпывал память
+func TestExtractPDFLimits(t *testing.T) {
+	bomb := deflate(bytes.Repeat([]byte("q Q "), 4<<20))
+	form := []byte(strings.Repeat("/X Do ", 8) + "(a) Tj")
+
+	tests := []struct {
+		name string
+		data []byte
+		err  error
+	}{
+		{
+			name: "headers inside unterminated array",
+			data: []byte("%PDF-1.4\n1 0 obj [" + strings.Repeat("2 0 obj ", 200000)),
+			err:  ErrPDFTooComplex,
+		},
+		{
+			name: "deep nesting",
+			data: []byte("%PDF-1.4\n" + strings.Repeat("1 0 obj [ ", 20000) + strings.Repeat("]", 20000)),
+		},
+		{
+			name: "decompression bomb referenced from many pages",
+			data: buildPDF(
+				"<< /Type /Catalog /Pages 2 0 R >>",
+				"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+				"<< /Type /Page /Parent 2 0 R /Contents ["+strings.Repeat("4 0 R ", 200)+"] >>",
+				pdfStreamObject("/Filter /FlateDecode", bomb),
+			),
+			err: ErrPDFTooComplex,
+		},
+		{
+			name: "self-nested forms",
+			data: buildPDF(
+				"<< /Type /Catalog /Pages 2 0 R >>",
+				"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+				"<< /Type /Page /Parent 2 0 R /Contents 4 0 R /Resources << /XObject << /X 4 0 R >> >> >>",
+				pdfStreamObject("/Subtype /Form", form),
+			),
+			err: ErrPDFTooComplex,
+		},
+		{
+			name: "streams without endstream",
+			data: []byte("%PDF-1.4\n" + strings.Repeat("1 0 obj << >> stream\n", 50000)),
+		},
+		{
+			name: "too many objects",
+			data: []byte("%PDF-1.4\n" + strings.Repeat("1 0 obj 1 endobj\n", maxPDFObjects+1)),
+			err:  ErrPDFTooComplex,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			done := make(chan error, 1)
+			go func() {
+				_, err := extractPDF(tt.data)
+				done <- err
+			}()
+
+			select {
+			case err := <-done:
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("extractPDF() error = %v, want %v", err, tt.err)
+				}
+			case <-time.After(10 * time.Second):
+				t.Fatal("extractPDF() did not finish in 10s")
+			}
+		})
+	}
+}
+
+func TestPDFLexerValue(t *testing.T) {
+	tests := []struct {
+		input string
+		want  any
+		err   error
+	}{
+		{input: "42", want: 42.0},
+		{input: "-1.5", want: -1.5},
+		{input: "3 0 R", want: pdfRef{num: 3, gen: 0}},
+		{input: "3 0 Rx", want: 3.0},
+		{input: "/Na#6De", want: pdfName("Name")},
+		{input: `(a\(b\)\101\
+c)`, want: pdfString("a(b)Ac")},
+		{input: "(a (nested) b)", want: pdfString("a (nested) b")},
+		{input: "<48656C 6C6F>", want: pdfString("Hello")},
+		{input: "<4>", want: pdfString{0x40}},
+		{input: "[1 /A (x)]", want: pdfArray{1.0, pdfName("A"), pdfString("x")}},
+		{input: "<< /Type /Page /Kids [1 0 R] >>", want: pdfDict{"Type": pdfName("Page"), "Kids": pdfArray{pdfRef{num: 1}}}},
+		{input: "% comment\ntrue", want: true},
+		{input: "null", want: nil},
+		{input: "Tj", want: pdfKeyword("Tj")},
+		{input: strings.Repeat("[", maxPDFNesting) + strings.Repeat("]", maxPDFNesting), want: nestedArray(maxPDFNesting)},
+		{input: strings.Repeat("[", maxPDFNesting+1) + strings.Repeat("]", maxPDFNesting+1), err: errPDFSyntax},
+		{input: "", err: errPDFSyntax},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			lexer := &pdfLexer{data: []byte(tt.input)}
+			got, err := lexer.value()
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("value() error = %v, want %v", err, tt.err)
+			}
+			if tt.err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("value() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPDFLexerBudget(t *testing.T) {
+	budget := pdfBudget{tokens: 2}
+	lexer := &pdfLexer{data: []byte("1 2 3"), budget: &budget}
+	for i := 0; i < 2; i++ {
+		if _, err := lexer.value(); err != nil {
+			t.Fatalf("value() #%d error = %v", i+1, err)
+		}
+	}
+	if _, err := lexer.value(); !errors.Is(err, ErrPDFTooComplex) {
+		t.Fatalf("value() error = %v, want %v", err, ErrPDFTooComplex)
+	}
+	if !budget.exceeded || lexer.pos != len(lexer.data) {
+		t.Errorf("exceeded = %v, pos = %d; want true, %d", budget.exceeded, lexer.pos, len(lexer.data))
+	}
+}
+
+func nestedArray(depth int) pdfArray {
+	array := pdfArray{}
+	for i := 1; i < depth; i++ {
+		array = pdfArray{array}
+	}
+	return array
+}
+
+func FuzzExtractPDF(f *testing.F) {
+	f.Add(singlePagePDF(pdfStreamObject("", []byte("BT /F1 12 Tf (Hello) Tj ET")), helvetica))
+	f.Add(singlePagePDF(pdfStreamObject("/Filter /FlateDecode", deflate([]byte("BT (x) Tj ET"))), helvetica))
+	f.Add([]byte("%PDF-1.4\n1 0 obj << /Type /ObjStm /N 1 /First 4 /Length 10 >> stream\n2 0 << >>\nendstream endobj"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		extractPDF(data)
+	})
+}
@@ -46,7 +46,7 @@ fi
 mkdir -p data
 
 echo "🔧 Сборка Go backend..."
-go build -o choizee cmd/main.go
+go build -tags sqlite_fts5 -o choizee cmd/main.go
 
 echo "📦 Установка зависимостей frontend..."
 cd web